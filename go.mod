@@ -9,3 +9,5 @@ require (
 	github.com/webview/webview_go v0.0.0-20240831120633-6173450d4dd6
 	golang.org/x/image v0.35.0
 )
+
+require golang.org/x/text v0.33.0 // indirect
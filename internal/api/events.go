@@ -0,0 +1,89 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// sseHeartbeat keeps idle event streams from being closed by proxies
+const sseHeartbeat = 30 * time.Second
+
+// event is one server-sent event
+type event struct {
+	name string
+	data []byte // JSON
+}
+
+// hub fans events out to every connected event stream
+type hub struct {
+	mu      sync.Mutex
+	clients map[chan event]bool
+}
+
+func newHub() *hub {
+	return &hub{clients: make(map[chan event]bool)}
+}
+
+// publish sends an event to every client. Clients that are not keeping up
+// miss the event rather than blocking the app.
+func (h *hub) publish(name string, data []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.clients {
+		select {
+		case ch <- event{name: name, data: data}:
+		default:
+		}
+	}
+}
+
+func (h *hub) subscribe() chan event {
+	ch := make(chan event, 64)
+	h.mu.Lock()
+	h.clients[ch] = true
+	h.mu.Unlock()
+	return ch
+}
+
+func (h *hub) unsubscribe(ch chan event) {
+	h.mu.Lock()
+	delete(h.clients, ch)
+	h.mu.Unlock()
+}
+
+// serveEvents streams events to the client until it disconnects
+func (h *hub) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming not supported"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	ch := h.subscribe()
+	defer h.unsubscribe(ch)
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case ev := <-ch:
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.name, ev.data)
+		}
+		flusher.Flush()
+	}
+}
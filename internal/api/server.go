@@ -0,0 +1,553 @@
+// Package api serves the operations of ui.Handlers over a local HTTP/JSON API
+// so pipeline tools can drive a running board.
+//
+// The server only listens on loopback addresses unless remote access is
+// switched on. Every request needs the server token as "Authorization: Bearer
+// <token>"; /api/events, for EventSource clients that cannot set headers,
+// also accepts it as a "token" query parameter. Responses are JSON; errors are {"error": "..."}
+// with status 400, 401 or 404. Invalid panel fields answer 422 and also list
+// each problem as "fields": [{"field", "message"}].
+//
+//	GET    /api/project              current project
+//	POST   /api/project              new project {"name"}
+//	PATCH  /api/project              {"name", "aspect_ratio", "matte_color"}, all optional
+//	POST   /api/project/save         save to the current path
+//	POST   /api/project/save-as      {"path"}
+//	POST   /api/project/open         {"path"}
+//	GET    /api/project/history      undo/redo stacks
+//	POST   /api/project/undo
+//	POST   /api/project/redo
+//	GET    /api/panels
+//	POST   /api/panels               add a blank panel
+//	PATCH  /api/panels/{id}          {"<field>": value, ...} as accepted by PatchPanel; returns {"panel"}
+//	DELETE /api/panels/{id}
+//	POST   /api/panels/{id}/duplicate optional {"in_place": true} puts the copy right after the panel
+//	POST   /api/panels/{id}/insert   {"position": "before" or "after"} a new blank panel
+//	POST   /api/panels/{id}/split    {"parts"} 2 to 100 panels sharing the panel's duration
+//	POST   /api/panels/{id}/move     {"index"}
+//	POST   /api/panels/batch         {"op", "ids", ...} as one undo step; each op and its fields:
+//	                                 delete, duplicate, move {"index"}, patch {"patch"},
+//	                                 scale {"factor"}, assign and unassign {"character_ids"}
+//	GET    /api/characters
+//	POST   /api/characters           {"name", "description", "image_data"}
+//	DELETE /api/characters/{id}
+//	GET    /api/scenes
+//	GET    /api/audio                project audio tracks
+//	GET    /api/images/{kind}?src=   a "panels" or "characters" image value as {"image": data URI}
+//	GET    /api/panels/{id}/image    a panel's image as {"image": data URI}; events leave inline images out
+//	GET    /api/characters/{id}/image a character's image, likewise
+//	GET    /api/assets/missing       referenced files that cannot be found
+//	POST   /api/assets/relink        search {"path"} (empty: the project folder) for missing files
+//	GET    /api/exports              export jobs
+//	POST   /api/exports/mp4          start a background export {"filename", "width", "height", "fps", "bitrate", "overlays"}
+//	DELETE /api/exports/{id}         cancel a running export
+//	POST   /api/exports/pdf          {"filename", "per_page", "landscape", "paper_size"}
+//	POST   /api/exports/edl          {"filename"}
+//	POST   /api/exports/fcpxml       {"filename"}
+//	GET    /api/events               server-sent events: the app.Event types such as
+//	                                 "panel:updated" and "project:changed" (an app.Event),
+//	                                 "export:progress" and "export:finished" (an export job),
+//	                                 "autosave:failed" {"error"}
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"storyboard_flow/internal/app/exporter"
+	"storyboard_flow/internal/storage"
+	"storyboard_flow/internal/ui"
+)
+
+// DefaultAddr only accepts connections from this machine
+const DefaultAddr = "127.0.0.1:8765"
+
+// eventsPath is the one endpoint that accepts the token as a query parameter
+const eventsPath = "/api/events"
+
+// Server is the HTTP API for one running board
+type Server struct {
+	// AllowRemote lets ListenAndServe use addresses other machines can reach
+	AllowRemote bool
+
+	handlers *ui.Handlers
+	token    string
+	events   *hub
+	mux      *http.ServeMux
+}
+
+// NewServer creates a server for handlers. State and export events are
+// streamed to /api/events.
+func NewServer(handlers *ui.Handlers, token string) *Server {
+	s := &Server{
+		handlers: handlers,
+		token:    token,
+		events:   newHub(),
+		mux:      http.NewServeMux(),
+	}
+
+	handlers.AddNotifier(s.events.publish)
+
+	s.routes()
+	return s
+}
+
+// NewToken returns a random API token
+func NewToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ListenAndServe serves the API on addr until it fails. Without AllowRemote,
+// addr must be a loopback address.
+func (s *Server) ListenAndServe(addr string) error {
+	if err := CheckAddr(addr, s.AllowRemote); err != nil {
+		return err
+	}
+	return http.ListenAndServe(addr, s)
+}
+
+// CheckAddr returns an error for a listen address other machines could reach,
+// such as ":8765" or "0.0.0.0:8765", unless allowRemote is set
+func CheckAddr(addr string, allowRemote bool) error {
+	if allowRemote {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", addr, err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("%s is not a loopback address", addr)
+}
+
+// ServeHTTP checks the token and dispatches the request
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, fmt.Errorf("missing or invalid token"))
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// authorized reports whether a request carries the server token
+func (s *Server) authorized(r *http.Request) bool {
+	token := ""
+	if r.URL.Path == eventsPath {
+		token = r.URL.Query().Get("token")
+	}
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	return s.token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+func (s *Server) routes() {
+	h := s.handlers
+
+	s.mux.HandleFunc("GET /api/project", jsonResult(h.GetProject))
+	s.mux.HandleFunc("POST /api/project", s.newProject)
+	s.mux.HandleFunc("PATCH /api/project", s.updateProject)
+	s.mux.HandleFunc("POST /api/project/save", jsonResult(func() (string, error) {
+		path, err := h.SaveProject()
+		return marshal(map[string]string{"path": path}, err)
+	}))
+	s.mux.HandleFunc("POST /api/project/save-as", s.withPath(h.SaveProjectAs))
+	s.mux.HandleFunc("POST /api/project/open", s.withPath(h.LoadProject))
+	s.mux.HandleFunc("GET /api/project/history", jsonResult(h.GetHistory))
+	s.mux.HandleFunc("POST /api/project/undo", jsonResult(h.Undo))
+	s.mux.HandleFunc("POST /api/project/redo", jsonResult(h.Redo))
+
+	s.mux.HandleFunc("GET /api/panels", jsonResult(h.GetPanels))
+	s.mux.HandleFunc("POST /api/panels", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusCreated)(h.CreatePanel())
+	})
+	s.mux.HandleFunc("PATCH /api/panels/{id}", s.updatePanel)
+	s.mux.HandleFunc("DELETE /api/panels/{id}", func(w http.ResponseWriter, r *http.Request) {
+		writeEmpty(w, h.DeletePanel(r.PathValue("id")))
+	})
+	s.mux.HandleFunc("POST /api/panels/{id}/duplicate", s.duplicatePanel)
+	s.mux.HandleFunc("POST /api/panels/{id}/insert", s.insertPanel)
+	s.mux.HandleFunc("POST /api/panels/{id}/split", s.splitPanel)
+	s.mux.HandleFunc("POST /api/panels/{id}/move", s.movePanel)
+	s.mux.HandleFunc("POST /api/panels/batch", s.batchPanels)
+
+	s.mux.HandleFunc("GET /api/characters", jsonResult(h.GetCharacters))
+	s.mux.HandleFunc("POST /api/characters", s.addCharacter)
+	s.mux.HandleFunc("DELETE /api/characters/{id}", func(w http.ResponseWriter, r *http.Request) {
+		writeEmpty(w, h.DeleteCharacter(r.PathValue("id")))
+	})
+
+	s.mux.HandleFunc("GET /api/scenes", jsonResult(h.GetScenes))
+	s.mux.HandleFunc("GET /api/audio", jsonResult(h.GetAudioTracks))
+	s.mux.HandleFunc("GET /api/panels/{id}/image", func(w http.ResponseWriter, r *http.Request) {
+		image, err := h.GetPanelImage(r.PathValue("id"))
+		writeJSON(w, http.StatusOK)(marshal(map[string]string{"image": image}, err))
+	})
+	s.mux.HandleFunc("GET /api/characters/{id}/image", func(w http.ResponseWriter, r *http.Request) {
+		image, err := h.GetCharacterImage(r.PathValue("id"))
+		writeJSON(w, http.StatusOK)(marshal(map[string]string{"image": image}, err))
+	})
+	s.mux.HandleFunc("GET /api/images/{kind}", func(w http.ResponseWriter, r *http.Request) {
+		image, err := h.GetImage(r.PathValue("kind"), r.URL.Query().Get("src"))
+		writeJSON(w, http.StatusOK)(marshal(map[string]string{"image": image}, err))
+	})
+	s.mux.HandleFunc("GET /api/assets/missing", jsonResult(h.GetMissingAssets))
+	s.mux.HandleFunc("POST /api/assets/relink", s.withPath(h.RelinkAssets))
+
+	s.mux.HandleFunc("GET /api/exports", jsonResult(h.GetExportJobs))
+	s.mux.HandleFunc("POST /api/exports/mp4", s.exportMP4)
+	s.mux.HandleFunc("DELETE /api/exports/{id}", func(w http.ResponseWriter, r *http.Request) {
+		writeEmpty(w, h.CancelExport(r.PathValue("id")))
+	})
+	s.mux.HandleFunc("POST /api/exports/pdf", s.exportPDF)
+	s.mux.HandleFunc("POST /api/exports/edl", s.exportEditList(h.ExportEDL))
+	s.mux.HandleFunc("POST /api/exports/fcpxml", s.exportEditList(h.ExportFCPXML))
+
+	s.mux.HandleFunc("GET "+eventsPath, s.events.serveEvents)
+}
+
+func (s *Server) newProject(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name string `json:"name"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if strings.TrimSpace(body.Name) == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("name is required"))
+		return
+	}
+	if err := s.handlers.CreateNewProject(body.Name); err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	writeJSON(w, http.StatusCreated)(s.handlers.GetProject())
+}
+
+func (s *Server) updateProject(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name        *string `json:"name"`
+		AspectRatio *string `json:"aspect_ratio"`
+		MatteColor  *string `json:"matte_color"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+
+	if body.Name != nil {
+		if err := s.handlers.RenameProject(*body.Name); err != nil {
+			writeError(w, statusFor(err), err)
+			return
+		}
+	}
+	if body.AspectRatio != nil || body.MatteColor != nil {
+		var project struct {
+			AspectRatio string `json:"aspect_ratio"`
+			MatteColor  string `json:"matte_color"`
+		}
+		current, err := s.handlers.GetProject()
+		if err != nil {
+			writeError(w, statusFor(err), err)
+			return
+		}
+		if err := json.Unmarshal([]byte(current), &project); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if body.AspectRatio != nil {
+			project.AspectRatio = *body.AspectRatio
+		}
+		if body.MatteColor != nil {
+			project.MatteColor = *body.MatteColor
+		}
+		if err := s.handlers.SetProjectFormat(project.AspectRatio, project.MatteColor); err != nil {
+			writeError(w, statusFor(err), err)
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK)(s.handlers.GetProject())
+}
+
+// updatePanel applies the body as a patch with PatchPanel
+func (s *Server) updatePanel(w http.ResponseWriter, r *http.Request) {
+	var patch json.RawMessage
+	if !readJSON(w, r, &patch) {
+		return
+	}
+
+	data, err := s.handlers.PatchPanel(r.PathValue("id"), patch)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	writePatchResult(w, data)
+}
+
+// writePatchResult answers with a ui.PanelPatchResult, or 422 and its field
+// errors
+func writePatchResult(w http.ResponseWriter, data string) {
+	var result ui.PanelPatchResult
+	if err := json.Unmarshal([]byte(data), &result); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if len(result.Errors) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]interface{}{"error": result.Errors.Error(), "fields": result.Errors})
+		return
+	}
+	writeJSON(w, http.StatusOK)(data, nil)
+}
+
+func (s *Server) duplicatePanel(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		InPlace bool `json:"in_place"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+
+	duplicate := s.handlers.DuplicatePanel
+	if body.InPlace {
+		duplicate = s.handlers.DuplicatePanelInPlace
+	}
+	writeJSON(w, http.StatusCreated)(duplicate(r.PathValue("id")))
+}
+
+func (s *Server) insertPanel(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Position string `json:"position"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+
+	switch body.Position {
+	case "before":
+		writeJSON(w, http.StatusCreated)(s.handlers.InsertPanelBefore(r.PathValue("id")))
+	case "after":
+		writeJSON(w, http.StatusCreated)(s.handlers.InsertPanelAfter(r.PathValue("id")))
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf(`position must be "before" or "after"`))
+	}
+}
+
+func (s *Server) splitPanel(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Parts int `json:"parts"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	writeJSON(w, http.StatusCreated)(s.handlers.SplitPanel(r.PathValue("id"), body.Parts))
+}
+
+func (s *Server) movePanel(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Index *int `json:"index"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if body.Index == nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("index is required"))
+		return
+	}
+	writeEmpty(w, s.handlers.ReorderPanel(r.PathValue("id"), *body.Index))
+}
+
+func (s *Server) batchPanels(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Op           string          `json:"op"`
+		IDs          []string        `json:"ids"`
+		Index        *int            `json:"index"`
+		Patch        json.RawMessage `json:"patch"`
+		Factor       float64         `json:"factor"`
+		CharacterIDs []string        `json:"character_ids"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if len(body.IDs) == 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("ids is required"))
+		return
+	}
+
+	h := s.handlers
+	switch body.Op {
+	case "delete":
+		writeEmpty(w, h.DeletePanels(body.IDs))
+	case "duplicate":
+		writeJSON(w, http.StatusCreated)(h.DuplicatePanels(body.IDs))
+	case "move":
+		if body.Index == nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("index is required"))
+			return
+		}
+		writeEmpty(w, h.MovePanels(body.IDs, *body.Index))
+	case "patch":
+		data, err := h.PatchPanels(body.IDs, body.Patch)
+		if err != nil {
+			writeError(w, statusFor(err), err)
+			return
+		}
+		writePatchResult(w, data)
+	case "scale":
+		writeEmpty(w, h.ScalePanelDurations(body.IDs, body.Factor))
+	case "assign":
+		writeEmpty(w, h.AssignCharacters(body.IDs, body.CharacterIDs))
+	case "unassign":
+		writeEmpty(w, h.UnassignCharacters(body.IDs, body.CharacterIDs))
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown batch operation %q", body.Op))
+	}
+}
+
+func (s *Server) addCharacter(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		ImageData   string `json:"image_data"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	writeJSON(w, http.StatusCreated)(s.handlers.AddCharacter(body.Name, body.Description, body.ImageData))
+}
+
+func (s *Server) exportMP4(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Filename string            `json:"filename"`
+		Width    int               `json:"width"`
+		Height   int               `json:"height"`
+		FPS      int               `json:"fps"`
+		Bitrate  int               `json:"bitrate"`
+		Overlays exporter.Overlays `json:"overlays"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	writeJSON(w, http.StatusAccepted)(s.handlers.StartExportMP4(body.Filename, body.Width, body.Height, body.FPS, body.Bitrate, body.Overlays))
+}
+
+func (s *Server) exportPDF(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Filename  string `json:"filename"`
+		PerPage   int    `json:"per_page"`
+		Landscape bool   `json:"landscape"`
+		PaperSize string `json:"paper_size"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	writeJSON(w, http.StatusOK)(s.handlers.ExportPDF(body.Filename, body.PerPage, body.Landscape, body.PaperSize))
+}
+
+func (s *Server) exportEditList(export func(filename string) (string, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Filename string `json:"filename"`
+		}
+		if !readJSON(w, r, &body) {
+			return
+		}
+		writeJSON(w, http.StatusOK)(export(body.Filename))
+	}
+}
+
+// withPath serves a handler that takes the "path" of the request body
+func (s *Server) withPath(fn func(path string) (string, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Path string `json:"path"`
+		}
+		if !readJSON(w, r, &body) {
+			return
+		}
+		writeJSON(w, http.StatusOK)(fn(body.Path))
+	}
+}
+
+// jsonResult serves a handler that returns a JSON string
+func jsonResult(fn func() (string, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK)(fn())
+	}
+}
+
+// writeJSON returns a function writing a handler result: the JSON with status
+// on success, an error otherwise
+func writeJSON(w http.ResponseWriter, status int) func(string, error) {
+	return func(data string, err error) {
+		if err != nil {
+			writeError(w, statusFor(err), err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		io.WriteString(w, data)
+	}
+}
+
+// writeEmpty answers 204 No Content, or the error
+func writeEmpty(w http.ResponseWriter, err error) {
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// readJSON decodes the request body into v. An empty body leaves v unchanged.
+// It answers 400 and returns false on malformed JSON.
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid JSON body: %w", err))
+		return false
+	}
+	return true
+}
+
+// statusFor maps handler errors to HTTP statuses
+func statusFor(err error) int {
+	if errors.Is(err, ui.ErrNotFound) || errors.Is(err, storage.ErrFileNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
+
+func marshal(v interface{}, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"storyboard_flow/internal/app"
+	"storyboard_flow/internal/ui"
+)
+
+const testToken = "secret"
+
+// newTestServer returns a server for a new project, and its first panel's ID
+func newTestServer(t *testing.T) (*Server, string) {
+	t.Helper()
+	state := app.NewState()
+	state.NewProject("api")
+	return NewServer(ui.NewHandlers(state), testToken), state.GetPanels()[0].ID
+}
+
+// do serves one request and returns the recorded response. The request's
+// context is already cancelled, so event streams return right away.
+func do(s *Server, method, target, token, body string) *httptest.ResponseRecorder {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := httptest.NewRequestWithContext(ctx, method, target, strings.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	return w
+}
+
+func TestAuthorization(t *testing.T) {
+	s, _ := newTestServer(t)
+	tests := []struct {
+		name   string
+		target string
+		token  string // sent as a bearer token
+		status int
+	}{
+		{"no token", "/api/panels", "", http.StatusUnauthorized},
+		{"wrong token", "/api/panels", "guess", http.StatusUnauthorized},
+		{"token with a suffix", "/api/panels", testToken + "x", http.StatusUnauthorized},
+		{"header token", "/api/panels", testToken, http.StatusOK},
+		{"query token outside the event stream", "/api/panels?token=" + testToken, "", http.StatusUnauthorized},
+		{"query token for the event stream", "/api/events?token=" + testToken, "", http.StatusOK},
+		{"wrong query token for the event stream", "/api/events?token=guess", "", http.StatusUnauthorized},
+		{"header token for the event stream", "/api/events", testToken, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(s, http.MethodGet, tt.target, tt.token, "")
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+
+	if w := do(NewServer(ui.NewHandlers(app.NewState()), ""), http.MethodGet, "/api/panels", "", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("a server without a token answered %d", w.Code)
+	}
+}
+
+func TestErrorStatuses(t *testing.T) {
+	s, panelID := newTestServer(t)
+	missing := "asset:" + strings.Repeat("0", 64) + ".png"
+	tests := []struct {
+		name           string
+		method, target string
+		body           string
+		status         int
+	}{
+		{"unknown panel", http.MethodPatch, "/api/panels/missing", `{"dialogue": "hi"}`, http.StatusNotFound},
+		{"delete an unknown panel", http.MethodDelete, "/api/panels/missing", "", http.StatusNotFound},
+		{"batch with an unknown panel", http.MethodPost, "/api/panels/batch", `{"op": "delete", "ids": ["` + panelID + `", "missing"]}`, http.StatusNotFound},
+		{"unknown character", http.MethodDelete, "/api/characters/missing", "", http.StatusNotFound},
+		{"unknown export", http.MethodDelete, "/api/exports/export-99", "", http.StatusNotFound},
+		{"missing image", http.MethodGet, "/api/images/panels?src=" + missing, "", http.StatusNotFound},
+		{"malformed JSON", http.MethodPatch, "/api/panels/" + panelID, `{"dialogue":`, http.StatusBadRequest},
+		{"bad position", http.MethodPost, "/api/panels/" + panelID + "/insert", `{"position": "above"}`, http.StatusBadRequest},
+		{"invalid field", http.MethodPatch, "/api/panels/" + panelID, `{"duration": -1}`, http.StatusUnprocessableEntity},
+		{"unknown image kind", http.MethodGet, "/api/images/audio?src=" + missing, "", http.StatusBadRequest},
+		{"valid patch", http.MethodPatch, "/api/panels/" + panelID, `{"dialogue": "hi"}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(s, tt.method, tt.target, testToken, tt.body)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if w.Code >= 400 {
+				var body struct {
+					Error string `json:"error"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Error == "" {
+					t.Errorf("error body = %s", w.Body)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateProjectFormat(t *testing.T) {
+	s, _ := newTestServer(t)
+	w := do(s, http.MethodPatch, "/api/project", testToken, `{"matte_color": "#ffffff"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	var project struct {
+		AspectRatio string `json:"aspect_ratio"`
+		MatteColor  string `json:"matte_color"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &project); err != nil {
+		t.Fatal(err)
+	}
+	if project.MatteColor != "#ffffff" || project.AspectRatio == "" {
+		t.Errorf("project format = %+v, want the matte changed and the ratio kept", project)
+	}
+
+	if w := do(s, http.MethodPatch, "/api/project", testToken, `{"aspect_ratio": "NaN"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid ratio answered %d", w.Code)
+	}
+}
+
+func TestCheckAddr(t *testing.T) {
+	tests := []struct {
+		addr        string
+		allowRemote bool
+		ok          bool
+	}{
+		{DefaultAddr, false, true},
+		{"localhost:8765", false, true},
+		{"[::1]:8765", false, true},
+		{"127.0.0.2:8765", false, true},
+		{":8765", false, false},
+		{"0.0.0.0:8765", false, false},
+		{"192.168.1.20:8765", false, false},
+		{"example.com:8765", false, false},
+		{"127.0.0.1", false, false}, // no port
+		{"0.0.0.0:8765", true, true},
+		{":8765", true, true},
+	}
+	for _, tt := range tests {
+		if err := CheckAddr(tt.addr, tt.allowRemote); (err == nil) != tt.ok {
+			t.Errorf("CheckAddr(%q, %v) = %v", tt.addr, tt.allowRemote, err)
+		}
+	}
+
+	s, _ := newTestServer(t)
+	if err := s.ListenAndServe("0.0.0.0:0"); err == nil || !strings.Contains(err.Error(), "loopback") {
+		t.Errorf("ListenAndServe on all interfaces = %v, want a loopback error", err)
+	}
+}
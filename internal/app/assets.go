@@ -0,0 +1,89 @@
+package app
+
+import (
+	"storyboard_flow/internal/models"
+)
+
+// RelinkAssets points image and audio references at relinked files.
+// replacements maps old reference values to new ones, as returned by
+// storage.RelinkAssets; every change is one undo step. It returns false if
+// no reference changed.
+func (s *State) RelinkAssets(replacements map[string]string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.CurrentProject == nil || len(replacements) == 0 {
+		return false
+	}
+
+	// relink updates value and reports whether it changed
+	relink := func(value *string) bool {
+		if next, ok := replacements[*value]; ok && next != *value {
+			*value = next
+			return true
+		}
+		return false
+	}
+
+	var cmds compound
+	for i, panel := range s.CurrentProject.Panels {
+		after := copyPanel(panel)
+		changed := relink(&after.ImageData)
+		for j := range after.AudioClips {
+			if relink(&after.AudioClips[j].Source) {
+				changed = true
+			}
+		}
+		if changed {
+			cmds = append(cmds, &setPanel{index: i, before: copyPanel(panel), after: after})
+		}
+	}
+
+	for i, char := range s.CurrentProject.Characters {
+		after := char
+		if relink(&after.ImagePath) {
+			cmds = append(cmds, &setCharacter{index: i, before: char, after: after})
+		}
+	}
+
+	before := append([]models.AudioClip{}, s.CurrentProject.AudioTracks...)
+	after := append([]models.AudioClip{}, before...)
+	tracksChanged := false
+	for i := range after {
+		if relink(&after[i].Source) {
+			tracksChanged = true
+		}
+	}
+	if tracksChanged {
+		cmds = append(cmds, &setAudioTracks{before: before, after: after})
+	}
+
+	if len(cmds) == 0 {
+		return false
+	}
+	s.exec("Relink assets", "", cmds)
+	return true
+}
+
+// UseStoredImages swaps inline images for the asset references they were
+// saved under, as returned by storage.ImageRefs. The project does not change
+// as far as the user can tell, so this records no undo step, publishes no
+// events and leaves the dirty flag alone.
+func (s *State) UseStoredImages(refs map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.CurrentProject == nil || len(refs) == 0 {
+		return
+	}
+	for i := range s.CurrentProject.Panels {
+		if ref, ok := refs[s.CurrentProject.Panels[i].ImageData]; ok {
+			s.CurrentProject.Panels[i].ImageData = ref
+		}
+	}
+	for i := range s.CurrentProject.Characters {
+		if ref, ok := refs[s.CurrentProject.Characters[i].ImagePath]; ok {
+			s.CurrentProject.Characters[i].ImagePath = ref
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package app
+
+import (
+	"storyboard_flow/internal/models"
+)
+
+// AddAudioTrack adds a music or temp score track to the current project
+func (s *State) AddAudioTrack(clip models.AudioClip) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.CurrentProject == nil {
+		return false
+	}
+
+	before := append([]models.AudioClip{}, s.CurrentProject.AudioTracks...)
+	after := append(append([]models.AudioClip{}, before...), clip)
+	s.exec("Add audio track", "", &setAudioTracks{before: before, after: after})
+	return true
+}
+
+// UpdateAudioTrack updates a project audio track's name, offset or gain
+func (s *State) UpdateAudioTrack(clipID string, updater func(*models.AudioClip)) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.CurrentProject == nil {
+		return false
+	}
+
+	before := append([]models.AudioClip{}, s.CurrentProject.AudioTracks...)
+	after := append([]models.AudioClip{}, before...)
+	i := indexOfClip(after, clipID)
+	if i == -1 {
+		return false
+	}
+	updater(&after[i])
+	after[i].ID = clipID
+
+	s.exec("Edit audio track", "audio:"+clipID, &setAudioTracks{before: before, after: after})
+	return true
+}
+
+// RemoveAudioTrack removes a project audio track
+func (s *State) RemoveAudioTrack(clipID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.CurrentProject == nil {
+		return false
+	}
+
+	before := append([]models.AudioClip{}, s.CurrentProject.AudioTracks...)
+	i := indexOfClip(before, clipID)
+	if i == -1 {
+		return false
+	}
+	after := append(append([]models.AudioClip{}, before[:i]...), before[i+1:]...)
+
+	s.exec("Remove audio track", "", &setAudioTracks{before: before, after: after})
+	return true
+}
+
+// GetAudioTracks returns the project's audio tracks (read-only copy)
+func (s *State) GetAudioTracks() []models.AudioClip {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.CurrentProject == nil {
+		return []models.AudioClip{}
+	}
+
+	return append([]models.AudioClip{}, s.CurrentProject.AudioTracks...)
+}
+
+// AddPanelAudio attaches a dialogue or SFX clip to a panel
+func (s *State) AddPanelAudio(panelID string, clip models.AudioClip) bool {
+	return s.editPanelAudio("Add panel audio", "", panelID, func(p *models.Panel) bool {
+		p.AudioClips = append(p.AudioClips, clip)
+		return true
+	})
+}
+
+// UpdatePanelAudio updates a panel clip's name, offset or gain
+func (s *State) UpdatePanelAudio(panelID, clipID string, updater func(*models.AudioClip)) bool {
+	return s.editPanelAudio("Edit panel audio", "audio:"+clipID, panelID, func(p *models.Panel) bool {
+		i := indexOfClip(p.AudioClips, clipID)
+		if i == -1 {
+			return false
+		}
+		updater(&p.AudioClips[i])
+		p.AudioClips[i].ID = clipID
+		return true
+	})
+}
+
+// RemovePanelAudio detaches a clip from a panel
+func (s *State) RemovePanelAudio(panelID, clipID string) bool {
+	return s.editPanelAudio("Remove panel audio", "", panelID, func(p *models.Panel) bool {
+		i := indexOfClip(p.AudioClips, clipID)
+		if i == -1 {
+			return false
+		}
+		p.AudioClips = append(p.AudioClips[:i], p.AudioClips[i+1:]...)
+		return true
+	})
+}
+
+// SnapPanelToAudio sets a panel's duration so it ends when one of its clips
+// does. An empty clipID uses whichever attached clip ends last.
+func (s *State) SnapPanelToAudio(panelID, clipID string) bool {
+	return s.editPanelAudio("Snap panel to audio", "", panelID, func(p *models.Panel) bool {
+		end := 0.0
+		for _, clip := range p.AudioClips {
+			if clipID != "" && clip.ID != clipID {
+				continue
+			}
+			if e := clip.Offset + clip.Duration; e > end {
+				end = e
+			}
+		}
+		if end <= 0 {
+			return false
+		}
+		p.Duration = end
+		return true
+	})
+}
+
+// editPanelAudio applies edit to a copy of a panel and records it as one undo step.
+// edit returns false to abort without changes.
+func (s *State) editPanelAudio(label, key, panelID string, edit func(*models.Panel) bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.CurrentProject == nil {
+		return false
+	}
+
+	for i := range s.CurrentProject.Panels {
+		if s.CurrentProject.Panels[i].ID == panelID {
+			before := copyPanel(s.CurrentProject.Panels[i])
+			after := copyPanel(before)
+			if !edit(&after) {
+				return false
+			}
+			s.exec(label, key, &setPanel{index: i, before: before, after: after})
+			return true
+		}
+	}
+
+	return false
+}
+
+// indexOfClip returns the position of a clip in clips, or -1
+func indexOfClip(clips []models.AudioClip, clipID string) int {
+	for i := range clips {
+		if clips[i].ID == clipID {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,312 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+
+	"storyboard_flow/internal/models"
+)
+
+// Batch operations work on a selection of panels. Each checks the whole
+// selection first and changes nothing if a panel is missing, then applies
+// every change under one lock as a single undo step.
+
+// selectPanels returns the positions of the panels with the given IDs in board
+// order, ignoring repeated IDs. It fails if ids is empty or names a panel that
+// does not exist. Callers must hold the lock.
+func (s *State) selectPanels(ids []string) ([]int, bool) {
+	if s.CurrentProject == nil || len(ids) == 0 {
+		return nil, false
+	}
+
+	index := make(map[string]int, len(s.CurrentProject.Panels))
+	for i, panel := range s.CurrentProject.Panels {
+		index[panel.ID] = i
+	}
+
+	seen := make(map[int]bool, len(ids))
+	indexes := make([]int, 0, len(ids))
+	for _, id := range ids {
+		i, ok := index[id]
+		if !ok {
+			return nil, false
+		}
+		if !seen[i] {
+			seen[i] = true
+			indexes = append(indexes, i)
+		}
+	}
+	sort.Ints(indexes)
+	return indexes, true
+}
+
+// panelCount describes n panels for undo labels
+func panelCount(n int) string {
+	if n == 1 {
+		return "1 panel"
+	}
+	return fmt.Sprintf("%d panels", n)
+}
+
+// editPanels runs edit on a copy of each selected panel and records the
+// panels it changed as one step. Callers must hold the write lock.
+func (s *State) editPanels(label string, indexes []int, edit func(p *models.Panel)) {
+	var cmds compound
+	for _, i := range indexes {
+		before := s.CurrentProject.Panels[i]
+		after := copyPanel(before)
+		edit(&after)
+		if !reflect.DeepEqual(before, after) {
+			cmds = append(cmds, &setPanel{index: i, before: copyPanel(before), after: after})
+		}
+	}
+	if len(cmds) > 0 {
+		s.exec(fmt.Sprintf(label, panelCount(len(cmds))), "", cmds)
+	}
+}
+
+// DeletePanels removes the selected panels; the rest are renumbered as by
+// DeletePanel
+func (s *State) DeletePanels(panelIDs []string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	indexes, ok := s.selectPanels(panelIDs)
+	if !ok {
+		return false
+	}
+
+	// Remove from the end so the earlier positions stay valid
+	cmds := make(compound, 0, len(indexes))
+	for k := len(indexes) - 1; k >= 0; k-- {
+		i := indexes[k]
+		cmds = append(cmds, &removePanel{index: i, panel: copyPanel(s.CurrentProject.Panels[i])})
+	}
+
+	s.exec("Delete "+panelCount(len(indexes)), "", cmds)
+	return true
+}
+
+// DuplicatePanels appends a copy of each selected panel, in board order, like
+// DuplicatePanel does for one. It returns the copies.
+func (s *State) DuplicatePanels(panelIDs []string) []models.Panel {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	indexes, ok := s.selectPanels(panelIDs)
+	if !ok {
+		return nil
+	}
+
+	copies := make([]models.Panel, 0, len(indexes))
+	cmds := make(compound, 0, len(indexes))
+	for _, i := range indexes {
+		panel := duplicateOf(s.CurrentProject.Panels[i], len(s.CurrentProject.Panels)+len(copies))
+		copies = append(copies, panel)
+		cmds = append(cmds, &insertPanel{index: panel.Order, panel: panel})
+	}
+
+	s.exec("Duplicate "+panelCount(len(indexes)), "", cmds)
+	return copies
+}
+
+// MovePanels moves the selected panels, keeping their board order, so they
+// form one block starting at index in the new order. Like ReorderPanel, moved
+// panels join the scene they land in.
+func (s *State) MovePanels(panelIDs []string, index int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	indexes, ok := s.selectPanels(panelIDs)
+	if !ok {
+		return false
+	}
+	return s.movePanels("Move "+panelCount(len(indexes)), indexes, index)
+}
+
+// movePanels moves the panels at indexes (in board order) to start at index.
+// Callers must hold the write lock.
+func (s *State) movePanels(label string, indexes []int, index int) bool {
+	panels := s.CurrentProject.Panels
+	if index < 0 || index > len(panels)-len(indexes) {
+		return false
+	}
+
+	moving := make(map[int]bool, len(indexes))
+	for _, i := range indexes {
+		moving[i] = true
+	}
+	var block, rest []string
+	for i, panel := range panels {
+		if moving[i] {
+			block = append(block, panel.ID)
+		} else {
+			rest = append(rest, panel.ID)
+		}
+	}
+
+	order := make([]string, 0, len(panels))
+	order = append(order, rest[:index]...)
+	order = append(order, block...)
+	order = append(order, rest[index:]...)
+
+	before := panelIDs(panels)
+	if equalIDs(before, order) {
+		return true // No change
+	}
+
+	cmds := compound{&permutePanels{before: before, after: order}}
+
+	if len(s.CurrentProject.Scenes) > 0 && len(rest) > 0 {
+		sceneID := blockScene(panels, indexes, rest, index)
+		for k, i := range indexes {
+			panel := copyPanel(panels[i])
+			if panel.SceneID == sceneID {
+				continue
+			}
+			panel.Order = index + k
+			after := copyPanel(panel)
+			after.SceneID = sceneID
+			cmds = append(cmds, &setPanel{index: index + k, before: panel, after: after})
+		}
+	}
+
+	s.exec(label, "", cmds)
+	return true
+}
+
+// blockScene returns the scene panels moved to index in rest join, so scenes
+// stay contiguous. Inside a scene that is the surrounding scene. At a scene
+// boundary the block stays in the scene before it if all of it already
+// belongs there, and otherwise joins the scene after it, so panels can be
+// moved to the start of any scene.
+func blockScene(panels []models.Panel, indexes []int, rest []string, index int) string {
+	sceneOf := make(map[string]string, len(panels))
+	for _, panel := range panels {
+		sceneOf[panel.ID] = panel.SceneID
+	}
+	if index == 0 {
+		return sceneOf[rest[0]]
+	}
+	prev := sceneOf[rest[index-1]]
+	if index == len(rest) {
+		return prev
+	}
+	next := sceneOf[rest[index]]
+	if prev == next {
+		return prev
+	}
+	for _, i := range indexes {
+		if panels[i].SceneID != prev {
+			return next
+		}
+	}
+	return prev
+}
+
+// PatchPanels validates patch and applies it to every selected panel. The
+// field errors are returned, and nothing is changed, if the patch is invalid.
+func (s *State) PatchPanels(panelIDs []string, patch models.PanelPatch) (bool, models.FieldErrors) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	indexes, ok := s.selectPanels(panelIDs)
+	if !ok {
+		return false, nil
+	}
+	if errs := patch.Validate(s.CurrentProject); len(errs) > 0 {
+		return true, errs
+	}
+
+	s.editPanels("Edit %s", indexes, func(p *models.Panel) { patch.Apply(p) })
+	return true, nil
+}
+
+// ScalePanelDurations multiplies the duration of the selected panels by factor,
+// rounded to the millisecond
+func (s *State) ScalePanelDurations(panelIDs []string, factor float64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if factor <= 0 || math.IsInf(factor, 0) || math.IsNaN(factor) {
+		return false
+	}
+	indexes, ok := s.selectPanels(panelIDs)
+	if !ok {
+		return false
+	}
+
+	s.editPanels("Retime %s", indexes, func(p *models.Panel) {
+		p.Duration = math.Round(p.Duration*factor*1000) / 1000
+	})
+	return true
+}
+
+// AssignCharacters adds characters to the selected panels. Every character
+// must exist.
+func (s *State) AssignCharacters(panelIDs, characterIDs []string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	indexes, ok := s.selectPanels(panelIDs)
+	if !ok {
+		return false
+	}
+	known := make(map[string]bool, len(s.CurrentProject.Characters))
+	for _, char := range s.CurrentProject.Characters {
+		known[char.ID] = true
+	}
+	for _, id := range characterIDs {
+		if !known[id] {
+			return false
+		}
+	}
+
+	s.editPanels("Assign characters to %s", indexes, func(p *models.Panel) {
+		for _, id := range characterIDs {
+			if !containsID(p.CharacterIDs, id) {
+				p.CharacterIDs = append(p.CharacterIDs, id)
+			}
+		}
+	})
+	return true
+}
+
+// UnassignCharacters removes characters from the selected panels
+func (s *State) UnassignCharacters(panelIDs, characterIDs []string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	indexes, ok := s.selectPanels(panelIDs)
+	if !ok {
+		return false
+	}
+
+	s.editPanels("Unassign characters from %s", indexes, func(p *models.Panel) {
+		ids := make([]string, 0, len(p.CharacterIDs))
+		for _, id := range p.CharacterIDs {
+			if !containsID(characterIDs, id) {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) < len(p.CharacterIDs) {
+			p.CharacterIDs = ids
+		}
+	})
+	return true
+}
+
+// equalIDs reports whether two ID lists are the same
+func equalIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"storyboard_flow/internal/models"
+)
+
+// durations returns the duration of every panel in board order
+func durations(s *State) []float64 {
+	var secs []float64
+	for _, panel := range s.GetPanels() {
+		secs = append(secs, panel.Duration)
+	}
+	return secs
+}
+
+func TestScalePanelDurations(t *testing.T) {
+	s := NewState()
+	s.NewProject("batch")
+	ids := panelIDs(s.GetPanels())
+	for i, id := range ids {
+		secs := float64(i + 1)
+		s.UpdatePanel(id, func(p *models.Panel) { p.Duration = secs })
+	}
+	before := durations(s)
+	steps := len(s.GetHistory().Undo)
+
+	// A selection naming a missing panel changes nothing
+	if s.ScalePanelDurations([]string{ids[0], "missing", ids[2]}, 2) {
+		t.Error("scaling a selection with a missing panel succeeded")
+	}
+	for _, factor := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		if s.ScalePanelDurations(ids[:2], factor) {
+			t.Errorf("scaling by %v succeeded", factor)
+		}
+	}
+	if got := durations(s); !reflect.DeepEqual(got, before) {
+		t.Errorf("durations = %v after failed scaling, want %v", got, before)
+	}
+	if n := len(s.GetHistory().Undo); n != steps {
+		t.Errorf("failed scaling recorded %d undo steps", n-steps)
+	}
+
+	// Scaling is one undo step for the whole selection
+	if !s.ScalePanelDurations([]string{ids[0], ids[2], ids[0]}, 1.5) {
+		t.Fatal("scaling failed")
+	}
+	want := append([]float64{}, before...)
+	want[0], want[2] = 1.5, 4.5
+	if got := durations(s); !reflect.DeepEqual(got, want) {
+		t.Errorf("durations = %v, want %v", got, want)
+	}
+	history := s.GetHistory()
+	if len(history.Undo) != steps+1 || history.Undo[0] != "Retime 2 panels" {
+		t.Errorf("undo steps = %v, want one \"Retime 2 panels\" step", history.Undo)
+	}
+	s.Undo()
+	if got := durations(s); !reflect.DeepEqual(got, before) {
+		t.Errorf("durations = %v after one undo, want %v", got, before)
+	}
+}
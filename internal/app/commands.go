@@ -0,0 +1,381 @@
+package app
+
+import (
+	"storyboard_flow/internal/models"
+)
+
+// command is a reversible change to a project.
+// Commands are recorded in the history after being applied, and the history
+// guarantees they are reverted against the same project state they produced.
+type command interface {
+	apply(p *models.Project)
+	revert(p *models.Project)
+	// record reports what apply, or revert when reverted is set, changes
+	record(c *changeSet, reverted bool)
+}
+
+// mergeable is implemented by commands that can absorb the next command
+// recorded under the same coalescing key
+type mergeable interface {
+	merge(next command) (command, bool)
+}
+
+// compound applies several commands as one history step
+type compound []command
+
+func (c compound) apply(p *models.Project) {
+	for _, cmd := range c {
+		cmd.apply(p)
+	}
+}
+
+func (c compound) revert(p *models.Project) {
+	for i := len(c) - 1; i >= 0; i-- {
+		c[i].revert(p)
+	}
+}
+
+func (c compound) record(cs *changeSet, reverted bool) {
+	if !reverted {
+		for _, cmd := range c {
+			cmd.record(cs, false)
+		}
+		return
+	}
+	for i := len(c) - 1; i >= 0; i-- {
+		c[i].record(cs, true)
+	}
+}
+
+// insertPanel inserts a panel at index
+type insertPanel struct {
+	index int
+	panel models.Panel
+}
+
+func (c *insertPanel) apply(p *models.Project) {
+	p.Panels = append(p.Panels, models.Panel{})
+	copy(p.Panels[c.index+1:], p.Panels[c.index:])
+	p.Panels[c.index] = copyPanel(c.panel)
+	renumberPanels(p, c.index)
+}
+
+func (c *insertPanel) revert(p *models.Project) {
+	p.Panels = append(p.Panels[:c.index], p.Panels[c.index+1:]...)
+	renumberPanels(p, c.index)
+}
+
+func (c *insertPanel) record(cs *changeSet, reverted bool) {
+	if reverted {
+		cs.panel(c.panel.ID, &c.panel)
+	} else {
+		cs.panel(c.panel.ID, nil)
+	}
+}
+
+// removePanel removes the panel at index
+type removePanel struct {
+	index int
+	panel models.Panel
+}
+
+func (c *removePanel) apply(p *models.Project) {
+	(&insertPanel{index: c.index, panel: c.panel}).revert(p)
+}
+
+func (c *removePanel) revert(p *models.Project) {
+	(&insertPanel{index: c.index, panel: c.panel}).apply(p)
+}
+
+func (c *removePanel) record(cs *changeSet, reverted bool) {
+	(&insertPanel{index: c.index, panel: c.panel}).record(cs, !reverted)
+}
+
+// setPanel replaces the panel at index
+type setPanel struct {
+	index  int
+	before models.Panel
+	after  models.Panel
+}
+
+func (c *setPanel) apply(p *models.Project) {
+	p.Panels[c.index] = copyPanel(c.after)
+}
+
+func (c *setPanel) revert(p *models.Project) {
+	p.Panels[c.index] = copyPanel(c.before)
+}
+
+func (c *setPanel) record(cs *changeSet, reverted bool) {
+	if reverted {
+		cs.panel(c.after.ID, &c.after)
+	} else {
+		cs.panel(c.after.ID, &c.before)
+	}
+}
+
+func (c *setPanel) merge(next command) (command, bool) {
+	n, ok := next.(*setPanel)
+	if !ok || n.index != c.index {
+		return nil, false
+	}
+	return &setPanel{index: c.index, before: c.before, after: n.after}, true
+}
+
+// insertCharacter inserts a character at index
+type insertCharacter struct {
+	index     int
+	character models.Character
+}
+
+func (c *insertCharacter) apply(p *models.Project) {
+	p.Characters = append(p.Characters, models.Character{})
+	copy(p.Characters[c.index+1:], p.Characters[c.index:])
+	p.Characters[c.index] = c.character
+}
+
+func (c *insertCharacter) revert(p *models.Project) {
+	p.Characters = append(p.Characters[:c.index], p.Characters[c.index+1:]...)
+}
+
+func (c *insertCharacter) record(cs *changeSet, reverted bool) {
+	if reverted {
+		cs.character(c.character.ID, &c.character)
+	} else {
+		cs.character(c.character.ID, nil)
+	}
+}
+
+// removeCharacter removes the character at index
+type removeCharacter struct {
+	index     int
+	character models.Character
+}
+
+func (c *removeCharacter) apply(p *models.Project) {
+	(&insertCharacter{index: c.index, character: c.character}).revert(p)
+}
+
+func (c *removeCharacter) revert(p *models.Project) {
+	(&insertCharacter{index: c.index, character: c.character}).apply(p)
+}
+
+func (c *removeCharacter) record(cs *changeSet, reverted bool) {
+	(&insertCharacter{index: c.index, character: c.character}).record(cs, !reverted)
+}
+
+// setCharacter replaces the character at index
+type setCharacter struct {
+	index  int
+	before models.Character
+	after  models.Character
+}
+
+func (c *setCharacter) apply(p *models.Project) {
+	p.Characters[c.index] = c.after
+}
+
+func (c *setCharacter) revert(p *models.Project) {
+	p.Characters[c.index] = c.before
+}
+
+func (c *setCharacter) record(cs *changeSet, reverted bool) {
+	if reverted {
+		cs.character(c.after.ID, &c.after)
+	} else {
+		cs.character(c.after.ID, &c.before)
+	}
+}
+
+// renameProject changes the project name
+type renameProject struct {
+	before string
+	after  string
+}
+
+func (c *renameProject) apply(p *models.Project) {
+	p.Name = c.after
+}
+
+func (c *renameProject) revert(p *models.Project) {
+	p.Name = c.before
+}
+
+func (c *renameProject) record(cs *changeSet, reverted bool) {
+	cs.renamed = true
+}
+
+// setProjectFormat changes the project's aspect ratio and matte color
+type setProjectFormat struct {
+	beforeAspect, afterAspect string
+	beforeMatte, afterMatte   string
+}
+
+func (c *setProjectFormat) apply(p *models.Project) {
+	p.AspectRatio = c.afterAspect
+	p.MatteColor = c.afterMatte
+}
+
+func (c *setProjectFormat) revert(p *models.Project) {
+	p.AspectRatio = c.beforeAspect
+	p.MatteColor = c.beforeMatte
+}
+
+func (c *setProjectFormat) record(cs *changeSet, reverted bool) {
+	cs.format = true
+}
+
+// setScenes replaces the project's scene and sequence lists
+type setScenes struct {
+	beforeScenes    []models.Scene
+	afterScenes     []models.Scene
+	beforeSequences []models.Sequence
+	afterSequences  []models.Sequence
+}
+
+func (c *setScenes) apply(p *models.Project) {
+	p.Scenes = append([]models.Scene{}, c.afterScenes...)
+	p.Sequences = append([]models.Sequence{}, c.afterSequences...)
+}
+
+func (c *setScenes) revert(p *models.Project) {
+	p.Scenes = append([]models.Scene{}, c.beforeScenes...)
+	p.Sequences = append([]models.Sequence{}, c.beforeSequences...)
+}
+
+func (c *setScenes) record(cs *changeSet, reverted bool) {
+	cs.scenes = true
+}
+
+// setAudioTracks replaces the project's audio tracks
+type setAudioTracks struct {
+	before []models.AudioClip
+	after  []models.AudioClip
+}
+
+func (c *setAudioTracks) apply(p *models.Project) {
+	p.AudioTracks = append([]models.AudioClip{}, c.after...)
+}
+
+func (c *setAudioTracks) revert(p *models.Project) {
+	p.AudioTracks = append([]models.AudioClip{}, c.before...)
+}
+
+func (c *setAudioTracks) record(cs *changeSet, reverted bool) {
+	cs.audio = true
+}
+
+func (c *setAudioTracks) merge(next command) (command, bool) {
+	n, ok := next.(*setAudioTracks)
+	if !ok {
+		return nil, false
+	}
+	return &setAudioTracks{before: c.before, after: n.after}, true
+}
+
+// permutePanels rearranges panels from one ID order to another
+type permutePanels struct {
+	before []string
+	after  []string
+}
+
+func (c *permutePanels) apply(p *models.Project) {
+	arrangePanels(p, c.after)
+}
+
+func (c *permutePanels) revert(p *models.Project) {
+	arrangePanels(p, c.before)
+}
+
+func (c *permutePanels) record(cs *changeSet, reverted bool) {
+	cs.reordered = true
+}
+
+// arrangePanels reorders p.Panels to follow ids and renumbers them
+func arrangePanels(p *models.Project, ids []string) {
+	byID := make(map[string]models.Panel, len(p.Panels))
+	for _, panel := range p.Panels {
+		byID[panel.ID] = panel
+	}
+	for i, id := range ids {
+		p.Panels[i] = byID[id]
+	}
+	renumberPanels(p, 0)
+}
+
+// panelIDs returns the IDs of panels in their current order
+func panelIDs(panels []models.Panel) []string {
+	ids := make([]string, len(panels))
+	for i, panel := range panels {
+		ids[i] = panel.ID
+	}
+	return ids
+}
+
+// copyProject returns a deep copy of a project
+func copyProject(src *models.Project) *models.Project {
+	dst := *src
+	dst.Panels = make([]models.Panel, len(src.Panels))
+	for i, panel := range src.Panels {
+		dst.Panels[i] = copyPanel(panel)
+	}
+	dst.Characters = append([]models.Character{}, src.Characters...)
+	dst.Sequences = append([]models.Sequence{}, src.Sequences...)
+	dst.Scenes = append([]models.Scene{}, src.Scenes...)
+	dst.AudioTracks = append([]models.AudioClip{}, src.AudioTracks...)
+	return &dst
+}
+
+// copyPanel returns a panel that shares no slices, maps or pointers with src
+func copyPanel(src models.Panel) models.Panel {
+	dst := src
+	if src.CharacterIDs != nil {
+		dst.CharacterIDs = make([]string, len(src.CharacterIDs))
+		copy(dst.CharacterIDs, src.CharacterIDs)
+	}
+	if src.AudioClips != nil {
+		dst.AudioClips = make([]models.AudioClip, len(src.AudioClips))
+		copy(dst.AudioClips, src.AudioClips)
+	}
+	if src.Motion != nil {
+		motion := *src.Motion
+		dst.Motion = &motion
+	}
+	if src.Transition != nil {
+		transition := *src.Transition
+		dst.Transition = &transition
+	}
+	if src.Focus != nil {
+		focus := *src.Focus
+		dst.Focus = &focus
+	}
+	if src.Source != nil {
+		source := *src.Source
+		dst.Source = &source
+	}
+	if src.Metadata != nil {
+		dst.Metadata = make(map[string]string, len(src.Metadata))
+		for k, v := range src.Metadata {
+			dst.Metadata[k] = v
+		}
+	}
+	return dst
+}
+
+// renumberPanels updates Order fields from index start onwards
+func renumberPanels(p *models.Project, start int) {
+	for i := start; i < len(p.Panels); i++ {
+		p.Panels[i].Order = i
+	}
+}
+
+// moveItem moves the element at from to index to, shifting the ones in between
+func moveItem[T any](items []T, from, to int) {
+	item := items[from]
+	if from < to {
+		copy(items[from:to], items[from+1:to+1])
+	} else {
+		copy(items[to+1:from+1], items[to:from])
+	}
+	items[to] = item
+}
@@ -0,0 +1,268 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"storyboard_flow/internal/models"
+)
+
+// EventType names what an Event reports
+type EventType string
+
+// Event types. Every change ends with an EventChanged carrying the label of
+// the change, after the events describing it.
+const (
+	EventPanelAdded       EventType = "panel:added"
+	EventPanelUpdated     EventType = "panel:updated"
+	EventPanelDeleted     EventType = "panel:deleted"
+	EventPanelsReordered  EventType = "panels:reordered"
+	EventCharacterAdded   EventType = "character:added"
+	EventCharacterUpdated EventType = "character:updated"
+	EventCharacterDeleted EventType = "character:deleted"
+	EventScenesChanged    EventType = "scenes:changed"
+	EventAudioChanged     EventType = "audio:changed"
+	EventProjectLoaded    EventType = "project:loaded"
+	EventProjectRenamed   EventType = "project:renamed"
+	EventProjectFormat    EventType = "project:format"
+	EventDirtyChanged     EventType = "project:dirty"
+	EventChanged          EventType = "project:changed"
+)
+
+// Event describes one change to the state. Which fields are set depends on
+// Type. Added panels and characters come as copies; updates only carry the
+// fields that changed. Inline (data URI) images are never sent: ImageInline
+// says the image changed to one, to be fetched separately.
+type Event struct {
+	Type     EventType `json:"type"`
+	Revision int       `json:"revision"` // state revision after the change
+
+	Label       string                     `json:"label,omitempty"`        // EventChanged: e.g. "Add panel" or "Undo Add panel"
+	PanelID     string                     `json:"panel_id,omitempty"`     // panel events
+	Index       int                        `json:"index"`                  // panel or character added or updated: its position
+	Panel       *models.Panel              `json:"panel,omitempty"`        // EventPanelAdded
+	Order       []string                   `json:"order,omitempty"`        // EventPanelsReordered: panel IDs in their new order
+	CharacterID string                     `json:"character_id,omitempty"` // character events
+	Character   *models.Character          `json:"character,omitempty"`    // EventCharacterAdded
+	Changes     map[string]json.RawMessage `json:"changes,omitempty"`      // panel or character updated: changed JSON fields and their new values
+	ImageInline bool                       `json:"image_inline,omitempty"` // panel or character added or updated: its image is a data URI left out of the event
+	Name        string                     `json:"name,omitempty"`         // EventProjectLoaded, EventProjectRenamed
+	AspectRatio string                     `json:"aspect_ratio,omitempty"` // EventProjectFormat
+	MatteColor  string                     `json:"matte_color,omitempty"`  // EventProjectFormat
+	Dirty       bool                       `json:"dirty"`                  // EventDirtyChanged
+}
+
+// Subscribe registers fn to receive every event. fn runs while the state is
+// locked, so it must return quickly and must not call back into State. The
+// returned function unregisters it.
+func (s *State) Subscribe(fn func(Event)) (cancel func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.subscribers == nil {
+		s.subscribers = make(map[int]func(Event))
+	}
+	id := s.nextSubscriber
+	s.nextSubscriber++
+	s.subscribers[id] = fn
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.subscribers, id)
+	}
+}
+
+// publish sends events to subscribers. Callers must hold the write lock.
+func (s *State) publish(events ...Event) {
+	for _, e := range events {
+		e.Revision = s.revision
+		for _, fn := range s.subscribers {
+			fn(e)
+		}
+	}
+}
+
+// setDirty updates the dirty flag, publishing EventDirtyChanged when it
+// flips. Callers must hold the write lock.
+func (s *State) setDirty(dirty bool) {
+	if s.IsDirty == dirty {
+		return
+	}
+	s.IsDirty = dirty
+	s.publish(Event{Type: EventDirtyChanged, Dirty: dirty})
+}
+
+// changeSet collects what a change touched, as reported by its commands.
+// Panels and characters are tracked by ID, in the order first touched, with
+// their value before the change, so that a panel added and removed again
+// within one change reports nothing and updates report only what differs.
+type changeSet struct {
+	loaded    bool
+	renamed   bool
+	format    bool
+	scenes    bool
+	audio     bool
+	reordered bool
+
+	panels     []string
+	panelsWere map[string]*models.Panel // nil for a panel that did not exist
+	characters []string
+	charsWere  map[string]*models.Character
+}
+
+// panel records that the panel id changed; before is its value before the
+// change, nil if it did not exist
+func (c *changeSet) panel(id string, before *models.Panel) {
+	if c.panelsWere == nil {
+		c.panelsWere = make(map[string]*models.Panel)
+	}
+	if _, ok := c.panelsWere[id]; !ok {
+		c.panelsWere[id] = before
+		c.panels = append(c.panels, id)
+	}
+}
+
+// character records that the character id changed, like panel
+func (c *changeSet) character(id string, before *models.Character) {
+	if c.charsWere == nil {
+		c.charsWere = make(map[string]*models.Character)
+	}
+	if _, ok := c.charsWere[id]; !ok {
+		c.charsWere[id] = before
+		c.characters = append(c.characters, id)
+	}
+}
+
+// events turns the change set into events against the project as it is now:
+// deletions first, then additions in panel order, then updates
+func (c *changeSet) events(p *models.Project) []Event {
+	var events []Event
+	if c.loaded {
+		return []Event{{Type: EventProjectLoaded, Name: p.Name}}
+	}
+
+	index := make(map[string]int, len(p.Panels))
+	for i, panel := range p.Panels {
+		index[panel.ID] = i
+	}
+	var added, updated []Event
+	for _, id := range c.panels {
+		i, exists := index[id]
+		before := c.panelsWere[id]
+		switch {
+		case !exists && before != nil:
+			events = append(events, Event{Type: EventPanelDeleted, PanelID: id})
+		case exists && before == nil:
+			panel := copyPanel(p.Panels[i])
+			e := Event{Type: EventPanelAdded, PanelID: id, Index: i, Panel: &panel}
+			e.ImageInline = leaveOutImage(&panel.ImageData)
+			added = append(added, e)
+		case exists:
+			e := Event{Type: EventPanelUpdated, PanelID: id, Index: i}
+			if e.Changes, e.ImageInline = fieldChanges(before, &p.Panels[i], "image_data"); len(e.Changes) > 0 || e.ImageInline {
+				updated = append(updated, e)
+			}
+		}
+	}
+	// Inserting additions one after another in this order reproduces the
+	// project's order
+	sort.SliceStable(added, func(i, j int) bool { return added[i].Index < added[j].Index })
+	events = append(append(events, added...), updated...)
+	if c.reordered {
+		events = append(events, Event{Type: EventPanelsReordered, Order: panelIDs(p.Panels)})
+	}
+
+	chars := make(map[string]int, len(p.Characters))
+	for i, char := range p.Characters {
+		chars[char.ID] = i
+	}
+	for _, id := range c.characters {
+		i, exists := chars[id]
+		before := c.charsWere[id]
+		switch {
+		case !exists && before != nil:
+			events = append(events, Event{Type: EventCharacterDeleted, CharacterID: id})
+		case exists && before == nil:
+			char := p.Characters[i]
+			e := Event{Type: EventCharacterAdded, CharacterID: id, Index: i, Character: &char}
+			e.ImageInline = leaveOutImage(&char.ImagePath)
+			events = append(events, e)
+		case exists:
+			e := Event{Type: EventCharacterUpdated, CharacterID: id, Index: i}
+			if e.Changes, e.ImageInline = fieldChanges(before, &p.Characters[i], "image_path"); len(e.Changes) > 0 || e.ImageInline {
+				events = append(events, e)
+			}
+		}
+	}
+
+	if c.scenes {
+		events = append(events, Event{Type: EventScenesChanged})
+	}
+	if c.audio {
+		events = append(events, Event{Type: EventAudioChanged})
+	}
+	if c.renamed {
+		events = append(events, Event{Type: EventProjectRenamed, Name: p.Name})
+	}
+	if c.format {
+		events = append(events, Event{Type: EventProjectFormat, AspectRatio: p.AspectRatio, MatteColor: p.MatteColor})
+	}
+	return events
+}
+
+// leaveOutImage clears an inline image from an event payload and reports
+// whether there was one
+func leaveOutImage(image *string) bool {
+	if !strings.HasPrefix(*image, "data:") {
+		return false
+	}
+	*image = ""
+	return true
+}
+
+// fieldChanges returns the JSON fields that differ between before and after,
+// with their values in after; a field after leaves out is null. An inline
+// image in the field named image is left out and reported instead.
+func fieldChanges(before, after interface{}, image string) (map[string]json.RawMessage, bool) {
+	was, err := jsonFields(before)
+	if err != nil {
+		return nil, false
+	}
+	now, err := jsonFields(after)
+	if err != nil {
+		return nil, false
+	}
+
+	changes := make(map[string]json.RawMessage)
+	for field, value := range now {
+		if !bytes.Equal(value, was[field]) {
+			changes[field] = value
+		}
+	}
+	for field := range was {
+		if _, ok := now[field]; !ok {
+			changes[field] = json.RawMessage("null")
+		}
+	}
+
+	inline := false
+	if value, ok := changes[image]; ok && bytes.HasPrefix(value, []byte(`"data:`)) {
+		delete(changes, image)
+		inline = true
+	}
+	return changes, inline
+}
+
+// jsonFields returns the top-level fields of v's JSON encoding
+func jsonFields(v interface{}) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(data, &fields)
+	return fields, err
+}
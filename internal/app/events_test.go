@@ -0,0 +1,180 @@
+package app
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"storyboard_flow/internal/models"
+)
+
+const inlineImage = "data:image/png;base64,iVBORw0KGgo="
+
+// record collects the events s publishes, leaving out EventChanged and
+// EventDirtyChanged
+func record(s *State) *[]Event {
+	var events []Event
+	s.Subscribe(func(e Event) {
+		if e.Type != EventChanged && e.Type != EventDirtyChanged {
+			events = append(events, e)
+		}
+	})
+	return &events
+}
+
+// changedFields returns the sorted keys of e.Changes
+func changedFields(e Event) []string {
+	var fields []string
+	for field := range e.Changes {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// noInlineImages fails if any event would send a data URI
+func noInlineImages(t *testing.T, events []Event) {
+	t.Helper()
+	for _, e := range events {
+		data, err := json.Marshal(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(data), "data:") {
+			t.Errorf("%s event carries an inline image: %s", e.Type, data)
+		}
+	}
+}
+
+func TestPanelUpdatedCarriesChangedFields(t *testing.T) {
+	s := NewState()
+	s.NewProject("events")
+	id := s.GetPanels()[1].ID
+	s.UpdatePanel(id, func(p *models.Panel) { p.ImageData = inlineImage })
+	events := record(s)
+
+	s.UpdatePanel(id, func(p *models.Panel) {
+		p.Dialogue = "Hello"
+		p.Duration = 4
+	})
+	if len(*events) != 1 {
+		t.Fatalf("got %d events, want 1: %+v", len(*events), *events)
+	}
+	e := (*events)[0]
+	if e.Type != EventPanelUpdated || e.PanelID != id || e.Index != 1 || e.Panel != nil {
+		t.Errorf("event = %+v, want an update of panel 1 without the panel", e)
+	}
+	if got, want := changedFields(e), []string{"dialogue", "duration"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("changed fields = %v, want %v", got, want)
+	}
+	if string(e.Changes["dialogue"]) != `"Hello"` || string(e.Changes["duration"]) != "4" {
+		t.Errorf("changes = %s, %s", e.Changes["dialogue"], e.Changes["duration"])
+	}
+	if e.ImageInline {
+		t.Error("an update leaving the image alone reported an inline image")
+	}
+
+	// Undo reports the old values; a dropped field is null. Edits to one
+	// panel share an undo step, so this edits another.
+	*events = nil
+	s.UpdatePanel(s.GetPanels()[2].ID, func(p *models.Panel) { p.Motion = &models.CameraMotion{} })
+	s.Undo()
+	if len(*events) != 2 {
+		t.Fatalf("got %d events, want 2: %+v", len(*events), *events)
+	}
+	if e := (*events)[1]; string(e.Changes["motion"]) != "null" || len(e.Changes) != 1 {
+		t.Errorf("undo changes = %v, want motion dropped", e.Changes)
+	}
+	noInlineImages(t, *events)
+
+	// An edit that changes nothing reports nothing
+	*events = nil
+	s.UpdatePanel(id, func(p *models.Panel) { p.Dialogue = "Hello" })
+	if len(*events) != 0 {
+		t.Errorf("an edit changing nothing sent %+v", *events)
+	}
+}
+
+func TestInlineImagesLeftOutOfEvents(t *testing.T) {
+	s := NewState()
+	s.NewProject("events")
+	id := s.GetPanels()[0].ID
+	events := record(s)
+
+	s.UpdatePanel(id, func(p *models.Panel) { p.ImageData = inlineImage })
+	if len(*events) != 1 || !(*events)[0].ImageInline || len((*events)[0].Changes) != 0 {
+		t.Errorf("inline image change sent %+v, want an image_inline update without changes", *events)
+	}
+
+	*events = nil
+	s.UpdatePanel(id, func(p *models.Panel) { p.ImageData = "asset:abc.png" })
+	if len(*events) != 1 || (*events)[0].ImageInline || string((*events)[0].Changes["image_data"]) != `"asset:abc.png"` {
+		t.Errorf("asset reference change sent %+v, want it in the changes", *events)
+	}
+
+	*events = nil
+	s.UpdatePanel(id, func(p *models.Panel) { p.ImageData = inlineImage })
+	s.DuplicatePanel(id)
+	s.AddCharacter("Ada", "", inlineImage)
+	noInlineImages(t, *events)
+	var added, chars int
+	for _, e := range *events {
+		switch e.Type {
+		case EventPanelAdded:
+			added++
+			if !e.ImageInline || e.Panel == nil || e.Panel.ImageData != "" {
+				t.Errorf("added panel = %+v, want it without its inline image", e)
+			}
+		case EventCharacterAdded:
+			chars++
+			if !e.ImageInline || e.Character == nil || e.Character.Name != "Ada" {
+				t.Errorf("added character = %+v, want it without its inline image", e)
+			}
+		}
+	}
+	if added != 1 || chars != 1 {
+		t.Errorf("got %d added panels and %d added characters, want 1 each", added, chars)
+	}
+
+	// The state keeps the image
+	if got := s.GetPanels()[0].ImageData; got != inlineImage {
+		t.Errorf("panel image = %q after the event", got)
+	}
+}
+
+func TestAddAndDeleteEvents(t *testing.T) {
+	s := NewState()
+	s.NewProject("events")
+	events := record(s)
+
+	panel := s.AddPanel()
+	if len(*events) != 1 || (*events)[0].Type != EventPanelAdded || (*events)[0].Panel == nil || (*events)[0].Panel.ID != panel.ID {
+		t.Fatalf("adding a panel sent %+v", *events)
+	}
+
+	*events = nil
+	s.DeletePanel(panel.ID)
+	if len(*events) != 1 || (*events)[0].Type != EventPanelDeleted || (*events)[0].PanelID != panel.ID {
+		t.Errorf("deleting a panel sent %+v", *events)
+	}
+
+	// Undoing the delete adds the panel back whole
+	*events = nil
+	s.Undo()
+	if len(*events) != 1 || (*events)[0].Type != EventPanelAdded || (*events)[0].Panel == nil {
+		t.Errorf("undoing a delete sent %+v", *events)
+	}
+
+	*events = nil
+	char := s.AddCharacter("Ada", "", "")
+	s.DeleteCharacter(char.ID)
+	var types []EventType
+	for _, e := range *events {
+		types = append(types, e.Type)
+	}
+	if want := []EventType{EventCharacterAdded, EventCharacterDeleted}; !reflect.DeepEqual(types, want) {
+		t.Errorf("character events = %v, want %v", types, want)
+	}
+}
@@ -0,0 +1,151 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"storyboard_flow/internal/models"
+	"storyboard_flow/internal/storage"
+)
+
+// UnresolvedAudio describes an audio clip left out of an export
+type UnresolvedAudio struct {
+	ClipID  string `json:"clip_id"`
+	PanelID string `json:"panel_id,omitempty"` // empty for project tracks
+	Reason  string `json:"reason"`
+}
+
+// audioPlacement is one audio file positioned on the export timeline
+type audioPlacement struct {
+	path  string
+	start float64 // seconds from the start of the video; negative trims the head
+	gain  float64 // dB
+}
+
+// ProbeAudioDuration returns the length of an audio file in seconds using ffprobe
+func ProbeAudioDuration(path string) (float64, error) {
+	out, err := exec.Command("ffprobe",
+		"-v", "error",
+		"-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		path,
+	).Output()
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe failed: %w", err)
+	}
+
+	secs, err := strconv.ParseFloat(strings.TrimSpace(string(out)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("could not read audio duration: %w", err)
+	}
+	return secs, nil
+}
+
+// ResolveAudioPath turns an AudioClip.Source into a path on disk. Sources may
+// be audio store references, file:// URLs, absolute paths or paths relative
+// to baseDir.
+func ResolveAudioPath(src, baseDir string) (string, error) {
+	if strings.TrimSpace(src) == "" {
+		return "", fmt.Errorf("clip has no audio file")
+	}
+	return (&storage.Resolver{Dir: baseDir}).Resolve(storage.AssetAudio, src)
+}
+
+// placeAudio lays out the project tracks and every panel clip on the export
+// timeline. Clips that cannot be resolved are recorded in the report.
+func placeAudio(p *models.Project, clips []timelineClip, opts ExportOptions, report *ExportReport) []audioPlacement {
+	var placements []audioPlacement
+
+	add := func(clip models.AudioClip, panelID string, start float64) {
+		path, err := ResolveAudioPath(clip.Source, opts.BaseDir)
+		if err != nil {
+			report.UnresolvedAudio = append(report.UnresolvedAudio, UnresolvedAudio{
+				ClipID:  clip.ID,
+				PanelID: panelID,
+				Reason:  err.Error(),
+			})
+			return
+		}
+		placements = append(placements, audioPlacement{path: path, start: start, gain: clip.Gain})
+	}
+
+	// With a scene selection the video starts part way into the board,
+	// so project tracks shift left by the same amount
+	skipped := 0
+	if len(clips) > 0 && len(opts.SceneIDs) > 0 {
+		full := opts
+		full.SceneIDs = nil
+		for _, c := range buildTimeline(p, full, &ExportReport{}) {
+			if c.panel.ID == clips[0].panel.ID {
+				skipped = c.start
+				break
+			}
+		}
+	}
+	for _, track := range p.AudioTracks {
+		add(track, "", track.Offset-float64(skipped)/float64(opts.FPS))
+	}
+
+	for _, clip := range clips {
+		start := float64(clip.start) / float64(opts.FPS)
+		for _, audio := range clip.panel.AudioClips {
+			add(audio, clip.panel.ID, start+audio.Offset)
+		}
+	}
+
+	return placements
+}
+
+// mixAudio renders the placed clips into one AAC file exactly length seconds
+// long, so muxing it never shortens the video
+func mixAudio(ctx context.Context, placements []audioPlacement, length float64, outputPath string) error {
+	args := []string{"-y", "-loglevel", "error"}
+	var filters []string
+	var labels string
+
+	for i, pl := range placements {
+		args = append(args, "-i", pl.path)
+
+		chain := fmt.Sprintf("[%d:a]", i)
+		if pl.start < 0 {
+			chain += fmt.Sprintf("atrim=start=%s,asetpts=PTS-STARTPTS,", ffSecs(-pl.start))
+		} else if pl.start > 0 {
+			chain += fmt.Sprintf("adelay=%d:all=1,", int(pl.start*1000+0.5))
+		}
+		chain += fmt.Sprintf("volume=%sdB[a%d]", ffSecs(pl.gain), i)
+
+		filters = append(filters, chain)
+		labels += fmt.Sprintf("[a%d]", i)
+	}
+
+	filters = append(filters, fmt.Sprintf("%samix=inputs=%d:duration=longest:normalize=0,apad,atrim=0:%s[out]",
+		labels, len(placements), ffSecs(length)))
+
+	args = append(args,
+		"-filter_complex", strings.Join(filters, ";"),
+		"-map", "[out]",
+		"-c:a", "aac",
+		"-b:a", "192k",
+		outputPath,
+	)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("failed to mix audio: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// ffSecs formats seconds for an ffmpeg filter argument
+func ffSecs(v float64) string {
+	return strconv.FormatFloat(v, 'f', 3, 64)
+}
@@ -0,0 +1,83 @@
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"storyboard_flow/internal/models"
+)
+
+// edlRecordStart is where the record timeline starts, 01:00:00:00 by convention
+const edlRecordStart = 3600
+
+// ExportProjectToEDL writes a CMX3600 edit decision list with one event per
+// panel, plus a folder of panel stills next to it ("<name>_stills") that the
+// events refer to by clip name. Shot type, camera angle/move, scene heading
+// and dialogue are written as comments under each event.
+func ExportProjectToEDL(p *models.Project, outputPath string, opts ExportOptions) (*ExportReport, error) {
+	if p == nil {
+		return nil, fmt.Errorf("nil project")
+	}
+
+	report := &ExportReport{
+		OutputPath:      outputPath,
+		Unresolved:      []UnresolvedPanel{},
+		UnresolvedAudio: []UnresolvedAudio{},
+		Scenes:          []SceneTiming{},
+	}
+
+	clips := buildTimeline(p, opts, report)
+	if len(clips) == 0 {
+		return nil, fmt.Errorf("nothing to export")
+	}
+
+	if err := writeStills(clips, stillsDir(outputPath), matteColor(p), opts, report); err != nil {
+		return report, err
+	}
+
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	w := bufio.NewWriter(f)
+	fmt.Fprintf(w, "TITLE: %s\r\n", oneLine(p.Name))
+	fmt.Fprintf(w, "FCM: NON-DROP FRAME\r\n\r\n")
+
+	recordStart := edlRecordStart * opts.FPS
+	for i, clip := range clips {
+		fmt.Fprintf(w, "%03d  %-8s V     C        %s %s %s %s\r\n",
+			i+1, "AX",
+			timecode(0, opts.FPS),
+			timecode(clip.frames, opts.FPS),
+			timecode(recordStart+clip.start, opts.FPS),
+			timecode(recordStart+clip.start+clip.frames, opts.FPS))
+		fmt.Fprintf(w, "* FROM CLIP NAME: %s\r\n", clip.still)
+		if clip.scene != nil {
+			fmt.Fprintf(w, "* SCENE: %s\r\n", oneLine(sceneLabel(clip.scene)))
+		}
+		if notes := clipNotes(clip.panel); notes != "" {
+			fmt.Fprintf(w, "* COMMENT: %s\r\n", notes)
+		}
+		if clip.panel.Dialogue != "" {
+			fmt.Fprintf(w, "* DIALOGUE: %s\r\n", oneLine(clip.panel.Dialogue))
+		}
+		fmt.Fprintf(w, "\r\n")
+	}
+
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return report, f.Close()
+}
+
+// sceneLabel returns a scene's heading prefixed with its script number, if any
+func sceneLabel(scene *models.Scene) string {
+	if scene.Number != "" {
+		return scene.Number + " " + scene.Heading.String()
+	}
+	return scene.Heading.String()
+}
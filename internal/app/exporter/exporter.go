@@ -1,17 +1,16 @@
 package exporter
 
 import (
+	"context"
 	"fmt"
 	"image"
 	idraw "image/draw"
 	"os"
 	"path/filepath"
-	"sort"
 
 	"image/color"
 
 	vidio "github.com/AlexEidt/Vidio"
-	xdraw "golang.org/x/image/draw"
 
 	"storyboard_flow/internal/models"
 )
@@ -23,23 +22,75 @@ type ExportOptions struct {
 	FPS         int
 	Bitrate     int
 	DefaultSecs float64
+	BaseDir     string   // directory relative image paths are resolved against (usually the project folder)
+	Strict      bool     // fail the export instead of writing blank frames for unresolved images
+	SceneIDs    []string // export only these scenes; empty exports the whole board
+	Mute        bool     // leave out project audio tracks and panel clips
+	Overlays    Overlays // information burned into each frame of an MP4
+
+	// Progress, if set, is called after each frame with the number written so far
+	Progress func(done, total int)
+}
+
+// ExportReport summarizes a finished export
+type ExportReport struct {
+	OutputPath      string            `json:"output_path"`
+	Frames          int               `json:"frames"`
+	Unresolved      []UnresolvedPanel `json:"unresolved"`
+	UnresolvedAudio []UnresolvedAudio `json:"unresolved_audio"`
+	Scenes          []SceneTiming     `json:"scenes"`
+}
+
+// SceneTiming locates a scene inside an exported video
+type SceneTiming struct {
+	SceneID    string `json:"scene_id"` // empty for panels without a scene
+	Heading    string `json:"heading"`
+	StartFrame int    `json:"start_frame"`
+	Frames     int    `json:"frames"`
 }
 
 // ExportProjectToMP4 exports the given project to an MP4 using Vidio (ffmpeg wrapper).
-// Currently panels must reference local image file paths in Panel.ImageData.
-func ExportProjectToMP4(p *models.Project, outputPath string, opts ExportOptions) error {
+// Panels are written scene by scene in playback order. Project audio tracks
+// and panel clips are mixed with their offsets and gain and muxed in.
+// Panel images may be data URIs or file paths. Panels whose image cannot be
+// resolved are rendered as blank frames and listed in the returned report,
+// unless opts.Strict is set, in which case the export fails before writing.
+// Cancelling ctx stops the export and removes the partial file.
+func ExportProjectToMP4(ctx context.Context, p *models.Project, outputPath string, opts ExportOptions) (*ExportReport, error) {
 	if p == nil {
-		return fmt.Errorf("nil project")
+		return nil, fmt.Errorf("nil project")
+	}
+
+	report := &ExportReport{
+		OutputPath:      outputPath,
+		Unresolved:      []UnresolvedPanel{},
+		UnresolvedAudio: []UnresolvedAudio{},
+		Scenes:          []SceneTiming{},
+	}
+
+	clips := buildTimeline(p, opts, report)
+	if len(clips) == 0 {
+		return nil, fmt.Errorf("nothing to export")
+	}
+
+	// Check every image up front so problems are known before ffmpeg starts
+	unresolved := checkPanels(clipPanels(clips), opts.BaseDir, report)
+
+	var placements []audioPlacement
+	if !opts.Mute {
+		placements = placeAudio(p, clips, opts, report)
 	}
 
-	// Ensure panels are in order
-	panels := make([]models.Panel, len(p.Panels))
-	copy(panels, p.Panels)
-	sort.Slice(panels, func(i, j int) bool { return panels[i].Order < panels[j].Order })
+	if opts.Strict && len(report.Unresolved) > 0 {
+		return report, fmt.Errorf("%d panel image(s) could not be resolved", len(report.Unresolved))
+	}
+	if opts.Strict && len(report.UnresolvedAudio) > 0 {
+		return report, fmt.Errorf("%d audio clip(s) could not be resolved", len(report.UnresolvedAudio))
+	}
 
 	// Create parent dir
 	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
-		return err
+		return nil, err
 	}
 
 	options := &vidio.Options{
@@ -48,66 +99,185 @@ func ExportProjectToMP4(p *models.Project, outputPath string, opts ExportOptions
 		Codec:   "libx264",
 	}
 
+	// Mix all audio into one track first; Vidio muxes it in as a stream file
+	if len(placements) > 0 {
+		mix, err := os.CreateTemp("", "storyboard-audio-*.m4a")
+		if err != nil {
+			return nil, err
+		}
+		mix.Close()
+		defer os.Remove(mix.Name())
+
+		length := float64(report.Frames) / float64(opts.FPS)
+		if err := mixAudio(ctx, placements, length, mix.Name()); err != nil {
+			return nil, err
+		}
+		options.StreamFile = mix.Name()
+	}
+
 	writer, err := vidio.NewVideoWriter(outputPath, opts.Width, opts.Height, options)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer writer.Close()
 
-	// For each panel, load image and write repeated frames for duration
-	for _, panel := range panels {
-		img, err := loadAndPrepareImage(panel.ImageData, opts.Width, opts.Height)
-		if err != nil {
-			// fallback: produce a blank frame
-			img = image.NewRGBA(image.Rect(0, 0, opts.Width, opts.Height))
-			bg := image.NewUniform(color.White)
-			idraw.Draw(img, img.Bounds(), bg, image.Point{}, idraw.Src)
+	// A failed or cancelled export leaves no partial file behind
+	finished := false
+	defer func() {
+		writer.Close()
+		if !finished {
+			os.Remove(outputPath)
+		}
+	}()
+
+	out := &videoOut{
+		ctx:    ctx,
+		writer: writer,
+		total:  report.Frames,
+		last:   image.NewRGBA(image.Rect(0, 0, opts.Width, opts.Height)),
+		mixed:  image.NewRGBA(image.Rect(0, 0, opts.Width, opts.Height)),
+		matte:  matteColor(p),
+	}
+	if opts.Overlays.Enabled() {
+		if out.overlay, err = newOverlayRenderer(p, opts); err != nil {
+			return nil, err
+		}
+		out.burned = image.NewRGBA(image.Rect(0, 0, opts.Width, opts.Height))
+	}
+
+	// Write each panel for its duration, animating camera moves and transitions
+	for _, clip := range clips {
+		if err := out.writePanel(clip, unresolved[clip.panel.ID], opts, report); err != nil {
+			return nil, err
+		}
+	}
+
+	finished = true
+	return report, nil
+}
+
+// videoOut writes frames to the encoder and remembers the last one for transitions
+type videoOut struct {
+	ctx     context.Context
+	writer  *vidio.VideoWriter
+	written int // frames written so far
+	total   int
+	last    *image.RGBA // final frame of the previous clip
+	mixed   *image.RGBA // scratch buffer for transition frames
+	started bool
+	matte   color.Color // bars around images of a different shape
+
+	// Burn-ins are drawn on a copy so transitions blend clean frames
+	overlay *overlayRenderer // nil without overlays
+	burned  *image.RGBA
+}
+
+// writePanel writes one clip's frames
+func (o *videoOut) writePanel(clip timelineClip, skip bool, opts ExportOptions, report *ExportReport) error {
+	motion := clip.panel.EffectiveMotion()
+
+	src := clip.panel.ImageData
+	if skip {
+		src = ""
+	}
+	fit := panelFit(clip.panel, o.matte)
+	r, err := newPanelRenderer(src, opts.BaseDir, motion, fit, opts.Width, opts.Height)
+	if err != nil {
+		report.Unresolved = append(report.Unresolved, UnresolvedPanel{
+			PanelID: clip.panel.ID,
+			Order:   clip.panel.Order,
+			Reason:  err.Error(),
+		})
+		if r, err = newPanelRenderer("", opts.BaseDir, motion, fit, opts.Width, opts.Height); err != nil {
+			return err
 		}
+	}
+
+	var prev *image.RGBA
+	if o.started {
+		prev = o.last
+	}
+	n := transitionFrames(clip.panel.Transition, clip.frames, opts.FPS)
 
-		secs := panel.Duration
-		if secs <= 0 {
-			secs = opts.DefaultSecs
+	if o.overlay != nil {
+		o.overlay.startClip(clip, opts.Width)
+	}
+
+	var frame *image.RGBA
+	for i := 0; i < clip.frames; i++ {
+		t := 0.0
+		if clip.frames > 1 {
+			t = float64(i) / float64(clip.frames-1)
 		}
-		frames := int(secs * float64(opts.FPS))
-		if frames <= 0 {
-			frames = int(opts.FPS) // at least one second
+		frame = applyTransition(o.mixed, r.render(t), prev, clip.panel.Transition, i, clip.frames, n)
+
+		out := frame
+		if o.overlay != nil {
+			copy(o.burned.Pix, frame.Pix)
+			o.overlay.draw(o.burned, clip.start+i)
+			out = o.burned
+		}
+
+		if err := o.ctx.Err(); err != nil {
+			return err
 		}
 
 		// Vidio expects a flattened RGBA byte slice
-		buf := img.Pix
-		for i := 0; i < frames; i++ {
-			if err := writer.Write(buf); err != nil {
-				return err
-			}
+		if err := o.writer.Write(out.Pix); err != nil {
+			return err
+		}
+
+		o.written++
+		if opts.Progress != nil {
+			opts.Progress(o.written, o.total)
 		}
 	}
 
+	if frame != nil {
+		copy(o.last.Pix, frame.Pix)
+		o.started = true
+	}
 	return nil
 }
 
-// loadAndPrepareImage loads an image from a file path and resizes it to width/height as RGBA
-func loadAndPrepareImage(path string, width, height int) (*image.RGBA, error) {
-	if path == "" {
-		return nil, fmt.Errorf("empty image path")
+// selectScenes keeps only the requested scenes; an empty list keeps everything
+func selectScenes(groups []models.SceneGroup, sceneIDs []string) []models.SceneGroup {
+	if len(sceneIDs) == 0 {
+		return groups
 	}
 
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, err
+	wanted := make(map[string]bool, len(sceneIDs))
+	for _, id := range sceneIDs {
+		wanted[id] = true
 	}
-	defer f.Close()
 
-	src, _, err := image.Decode(f)
+	var selected []models.SceneGroup
+	for _, group := range groups {
+		if group.Scene != nil && wanted[group.Scene.ID] {
+			selected = append(selected, group)
+		}
+	}
+	return selected
+}
+
+// loadAndPrepareImage resolves a panel image and places it on a width x height
+// RGBA frame according to fit
+func loadAndPrepareImage(src, baseDir string, width, height int, fit imageFit) (*image.RGBA, error) {
+	img, err := decodePanelImage(src, baseDir)
 	if err != nil {
 		return nil, err
 	}
 
+	// Transparent areas are backed with white so they don't come out black after yuv420p conversion
 	dst := image.NewRGBA(image.Rect(0, 0, width, height))
-	xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), xdraw.Over, nil)
-
-	// if dst has no alpha filled, ensure background is white — not strictly necessary for all inputs
-	bg := image.NewUniform(color.White)
-	idraw.Draw(dst, dst.Bounds(), bg, image.Point{}, idraw.Over)
+	placeImage(dst, img, fit)
 
 	return dst, nil
 }
+
+// blankFrame returns a white frame, drawn in place of panels without a usable image
+func blankFrame(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	bg := image.NewUniform(color.White)
+	idraw.Draw(img, img.Bounds(), bg, image.Point{}, idraw.Src)
+	return img
+}
@@ -0,0 +1,206 @@
+package exporter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"storyboard_flow/internal/models"
+)
+
+// FCPXML document structure, limited to what a still-image animatic needs
+type fcpxmlDoc struct {
+	XMLName   xml.Name     `xml:"fcpxml"`
+	Version   string       `xml:"version,attr"`
+	Resources fcpResources `xml:"resources"`
+	Event     fcpEvent     `xml:"library>event"`
+}
+
+type fcpResources struct {
+	Formats []fcpFormat `xml:"format"`
+	Assets  []fcpAsset  `xml:"asset"`
+}
+
+type fcpFormat struct {
+	ID            string `xml:"id,attr"`
+	Name          string `xml:"name,attr,omitempty"`
+	FrameDuration string `xml:"frameDuration,attr,omitempty"`
+	Width         int    `xml:"width,attr"`
+	Height        int    `xml:"height,attr"`
+}
+
+type fcpAsset struct {
+	ID       string      `xml:"id,attr"`
+	Name     string      `xml:"name,attr"`
+	Start    string      `xml:"start,attr"`
+	Duration string      `xml:"duration,attr"`
+	HasVideo string      `xml:"hasVideo,attr"`
+	Format   string      `xml:"format,attr"`
+	MediaRep fcpMediaRep `xml:"media-rep"`
+}
+
+type fcpMediaRep struct {
+	Kind string `xml:"kind,attr"`
+	Src  string `xml:"src,attr"`
+}
+
+type fcpEvent struct {
+	Name    string     `xml:"name,attr"`
+	Project fcpProject `xml:"project"`
+}
+
+type fcpProject struct {
+	Name     string      `xml:"name,attr"`
+	Sequence fcpSequence `xml:"sequence"`
+}
+
+type fcpSequence struct {
+	Format   string    `xml:"format,attr"`
+	Duration string    `xml:"duration,attr"`
+	TCStart  string    `xml:"tcStart,attr"`
+	TCFormat string    `xml:"tcFormat,attr"`
+	Clips    []fcpClip `xml:"spine>clip"`
+}
+
+type fcpClip struct {
+	Name     string      `xml:"name,attr"`
+	Offset   string      `xml:"offset,attr"`
+	Duration string      `xml:"duration,attr"`
+	Note     string      `xml:"note,omitempty"`
+	Video    fcpVideo    `xml:"video"`
+	Markers  []fcpMarker `xml:"marker"`
+}
+
+type fcpVideo struct {
+	Ref      string `xml:"ref,attr"`
+	Offset   string `xml:"offset,attr"`
+	Duration string `xml:"duration,attr"`
+}
+
+type fcpMarker struct {
+	Start    string `xml:"start,attr"`
+	Duration string `xml:"duration,attr"`
+	Value    string `xml:"value,attr"`
+	Note     string `xml:"note,attr,omitempty"`
+}
+
+// ExportProjectToFCPXML writes an FCPXML 1.9 timeline with one clip per panel,
+// plus a folder of panel stills next to it ("<name>_stills") that the clips
+// reference. Each clip gets a marker with the shot type, camera angle/move and
+// dialogue, and the scene heading as its note.
+func ExportProjectToFCPXML(p *models.Project, outputPath string, opts ExportOptions) (*ExportReport, error) {
+	if p == nil {
+		return nil, fmt.Errorf("nil project")
+	}
+
+	report := &ExportReport{
+		OutputPath:      outputPath,
+		Unresolved:      []UnresolvedPanel{},
+		UnresolvedAudio: []UnresolvedAudio{},
+		Scenes:          []SceneTiming{},
+	}
+
+	clips := buildTimeline(p, opts, report)
+	if len(clips) == 0 {
+		return nil, fmt.Errorf("nothing to export")
+	}
+
+	dir := stillsDir(outputPath)
+	if err := writeStills(clips, dir, matteColor(p), opts, report); err != nil {
+		return report, err
+	}
+
+	// Rational time in frames, e.g. "72/24s"
+	rt := func(frames int) string {
+		if frames == 0 {
+			return "0s"
+		}
+		return fmt.Sprintf("%d/%ds", frames, opts.FPS)
+	}
+
+	doc := fcpxmlDoc{
+		Version: "1.9",
+		Resources: fcpResources{
+			Formats: []fcpFormat{
+				{ID: "r1", FrameDuration: rt(1), Width: opts.Width, Height: opts.Height},
+				{ID: "r2", Name: "FFVideoFormatRateUndefined", Width: opts.Width, Height: opts.Height},
+			},
+		},
+		Event: fcpEvent{
+			Name: p.Name,
+			Project: fcpProject{
+				Name: p.Name,
+				Sequence: fcpSequence{
+					Format:   "r1",
+					Duration: rt(report.Frames),
+					TCStart:  "0s",
+					TCFormat: "NDF",
+				},
+			},
+		},
+	}
+
+	for i, clip := range clips {
+		assetID := fmt.Sprintf("a%d", i+1)
+		src := url.URL{Scheme: "file", Path: absPath(filepath.Join(dir, clip.still))}
+		doc.Resources.Assets = append(doc.Resources.Assets, fcpAsset{
+			ID:       assetID,
+			Name:     clip.still,
+			Start:    "0s",
+			Duration: "0s",
+			HasVideo: "1",
+			Format:   "r2",
+			MediaRep: fcpMediaRep{Kind: "original-media", Src: src.String()},
+		})
+
+		c := fcpClip{
+			Name:     fmt.Sprintf("Panel %d", clip.panel.Order+1),
+			Offset:   rt(clip.start),
+			Duration: rt(clip.frames),
+			Video:    fcpVideo{Ref: assetID, Offset: "0s", Duration: rt(clip.frames)},
+		}
+		if clip.scene != nil {
+			c.Note = sceneLabel(clip.scene)
+		}
+		if notes := clipNotes(clip.panel); notes != "" || clip.panel.Dialogue != "" {
+			if notes == "" {
+				notes = "Dialogue"
+			}
+			c.Markers = append(c.Markers, fcpMarker{
+				Start:    "0s",
+				Duration: rt(1),
+				Value:    notes,
+				Note:     oneLine(clip.panel.Dialogue),
+			})
+		}
+		doc.Event.Project.Sequence.Clips = append(doc.Event.Project.Sequence.Clips, c)
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	out := []byte(xml.Header + "<!DOCTYPE fcpxml>\n\n")
+	out = append(out, data...)
+	out = append(out, '\n')
+	if err := os.WriteFile(outputPath, out, 0644); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// absPath returns path as an absolute, slash-separated path for URLs
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	path = filepath.ToSlash(path)
+	if path[0] != '/' {
+		path = "/" + path // Windows drive letters
+	}
+	return path
+}
@@ -0,0 +1,118 @@
+package exporter
+
+import (
+	"image"
+	"image/color"
+	idraw "image/draw"
+	"math"
+
+	xdraw "golang.org/x/image/draw"
+
+	"storyboard_flow/internal/models"
+)
+
+// imageFit says how a panel image is placed in an output frame
+type imageFit struct {
+	mode  string // models.FitContain, FitFill or FitCrop
+	focus models.FocalPoint
+	matte color.Color
+	// pixelScale is the frame pixels per image pixel in crop mode, so a crop
+	// looks the same when the frame is rendered larger or smaller than the
+	// export size. Zero means 1.
+	pixelScale float64
+}
+
+// panelFit returns the placement settings for a panel
+func panelFit(panel models.Panel, matte color.Color) imageFit {
+	fit := imageFit{mode: panel.Fit, focus: models.FocalPoint{X: 0.5, Y: 0.5}, matte: matte}
+	if panel.Focus != nil {
+		fit.focus = *panel.Focus
+	}
+	return fit
+}
+
+// matteColor reads the project's matte color, defaulting to black
+func matteColor(p *models.Project) color.Color {
+	r, g, b, err := models.ParseHexColor(p.MatteColor)
+	if err != nil {
+		return color.Black
+	}
+	return color.RGBA{r, g, b, 0xff}
+}
+
+// FrameSize returns the export size for an aspect ratio. A zero width or
+// height is derived from the other; with both zero, ratios at least as wide
+// as 16:9 are 1280 wide and narrower ones 720 high. Sizes are kept even for
+// the H.264 encoder.
+func FrameSize(aspect float64, width, height int) (int, int) {
+	if aspect <= 0 || math.IsNaN(aspect) || math.IsInf(aspect, 0) {
+		aspect = models.DefaultAspectRatio
+	}
+	switch {
+	case width > 0 && height > 0:
+	case width > 0:
+		height = int(math.Round(float64(width) / aspect))
+	case height > 0:
+		width = int(math.Round(float64(height) * aspect))
+	case aspect >= models.DefaultAspectRatio:
+		width = 1280
+		height = int(math.Round(1280 / aspect))
+	default:
+		height = 720
+		width = int(math.Round(720 * aspect))
+	}
+	return max(width&^1, 2), max(height&^1, 2)
+}
+
+// placeImage draws img into dst according to fit. Transparent areas of the
+// image come out white; the rest of the frame is filled with the matte.
+func placeImage(dst *image.RGBA, img image.Image, fit imageFit) {
+	frame := dst.Bounds()
+	src := img.Bounds()
+	if src.Empty() {
+		return
+	}
+
+	// Scale factor from image pixels to frame pixels
+	sx := float64(frame.Dx()) / float64(src.Dx())
+	sy := float64(frame.Dy()) / float64(src.Dy())
+	var scale float64
+	switch fit.mode {
+	case models.FitFill:
+		scale = math.Max(sx, sy)
+	case models.FitCrop:
+		scale = fit.pixelScale
+		if scale <= 0 {
+			scale = 1
+		}
+	default:
+		scale = math.Min(sx, sy)
+	}
+
+	// Size of the image on the frame, then its offset so the focal point
+	// sits as near the frame center as the edges allow
+	w := float64(src.Dx()) * scale
+	h := float64(src.Dy()) * scale
+	x := placeAxis(float64(frame.Dx()), w, fit.focus.X)
+	y := placeAxis(float64(frame.Dy()), h, fit.focus.Y)
+
+	target := image.Rect(
+		int(math.Round(x)), int(math.Round(y)),
+		int(math.Round(x+w)), int(math.Round(y+h)),
+	).Add(frame.Min)
+
+	idraw.Draw(dst, frame, image.NewUniform(fit.matte), image.Point{}, idraw.Src)
+	idraw.Draw(dst, target.Intersect(frame), image.NewUniform(color.White), image.Point{}, idraw.Src)
+	xdraw.CatmullRom.Scale(dst, target, img, src, xdraw.Over, nil)
+}
+
+// placeAxis returns where an image of length size starts on a frame axis of
+// length frame. Images that fit are centered; larger ones are shifted so
+// focus (0..1 along the image) is centered without exposing an edge.
+func placeAxis(frame, size, focus float64) float64 {
+	if size <= frame {
+		return (frame - size) / 2
+	}
+	pos := frame/2 - focus*size
+	return math.Min(0, math.Max(frame-size, pos))
+}
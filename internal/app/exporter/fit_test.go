@@ -0,0 +1,134 @@
+package exporter
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"storyboard_flow/internal/models"
+)
+
+func TestFrameSize(t *testing.T) {
+	tests := []struct {
+		name          string
+		aspect        float64
+		width, height int
+		wantW, wantH  int
+	}{
+		{"16:9 default", 16.0 / 9.0, 0, 0, 1280, 720},
+		{"scope is 1280 wide", 2.39, 0, 0, 1280, 536},
+		{"4:3 is 720 high", 4.0 / 3.0, 0, 0, 960, 720},
+		{"vertical is 720 high", 9.0 / 16.0, 0, 0, 404, 720},
+		{"height from width", 2.0, 1920, 0, 1920, 960},
+		{"width from height", 2.0, 0, 1080, 2160, 1080},
+		{"both given", 2.0, 640, 480, 640, 480},
+		{"kept even", 1.85, 999, 0, 998, 540},
+		{"never below 2", 100, 0, 1, 100, 2},
+		{"zero falls back to 16:9", 0, 0, 0, 1280, 720},
+		{"NaN falls back to 16:9", math.NaN(), 1920, 0, 1920, 1080},
+		{"Inf falls back to 16:9", math.Inf(1), 0, 720, 1280, 720},
+	}
+	for _, tt := range tests {
+		if w, h := FrameSize(tt.aspect, tt.width, tt.height); w != tt.wantW || h != tt.wantH {
+			t.Errorf("%s: %dx%d, want %dx%d", tt.name, w, h, tt.wantW, tt.wantH)
+		}
+	}
+}
+
+// split returns a w x h image, left on the left half and right on the right
+func split(w, h int, left, right color.RGBA) *image.RGBA {
+	img := solid(w, h, left)
+	for y := 0; y < h; y++ {
+		for x := w / 2; x < w; x++ {
+			img.SetRGBA(x, y, right)
+		}
+	}
+	return img
+}
+
+func TestPlaceImage(t *testing.T) {
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	black := color.RGBA{0, 0, 0, 0xff}
+	white := color.RGBA{0xff, 0xff, 0xff, 0xff}
+	blue := color.RGBA{0, 0, 0xff, 0xff}
+	center := models.FocalPoint{X: 0.5, Y: 0.5}
+
+	type pixel struct {
+		x, y int
+		want color.RGBA
+	}
+	tests := []struct {
+		name   string
+		img    image.Image
+		fit    imageFit
+		pixels []pixel
+	}{
+		{
+			name: "contain letterboxes a wide image",
+			img:  solid(200, 100, red),
+			fit:  imageFit{mode: models.FitContain, focus: center, matte: blue},
+			pixels: []pixel{
+				{50, 10, blue}, {50, 30, red}, {50, 70, red}, {50, 90, blue},
+			},
+		},
+		{
+			name:   "fill keeps the focus in view on the left",
+			img:    split(200, 100, black, white),
+			fit:    imageFit{mode: models.FitFill, focus: models.FocalPoint{X: 0, Y: 0.5}, matte: blue},
+			pixels: []pixel{{5, 50, black}, {95, 50, black}, {50, 0, black}},
+		},
+		{
+			name:   "fill keeps the focus in view on the right",
+			img:    split(200, 100, black, white),
+			fit:    imageFit{mode: models.FitFill, focus: models.FocalPoint{X: 1, Y: 0.5}, matte: blue},
+			pixels: []pixel{{5, 50, white}, {95, 50, white}},
+		},
+		{
+			name:   "crop keeps the image size",
+			img:    solid(20, 20, red),
+			fit:    imageFit{mode: models.FitCrop, focus: center, matte: blue},
+			pixels: []pixel{{35, 50, blue}, {45, 50, red}, {55, 50, red}, {65, 50, blue}},
+		},
+		{
+			name:   "crop follows the pixel scale",
+			img:    solid(20, 20, red),
+			fit:    imageFit{mode: models.FitCrop, focus: center, matte: blue, pixelScale: 2},
+			pixels: []pixel{{25, 50, blue}, {35, 50, red}, {65, 50, red}, {75, 50, blue}},
+		},
+		{
+			name:   "transparency comes out white",
+			img:    solid(100, 100, color.RGBA{}),
+			fit:    imageFit{mode: models.FitContain, focus: center, matte: blue},
+			pixels: []pixel{{50, 50, white}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := image.NewRGBA(image.Rect(0, 0, 100, 100))
+			placeImage(dst, tt.img, tt.fit)
+			for _, p := range tt.pixels {
+				if got := dst.RGBAAt(p.x, p.y); got != p.want {
+					t.Errorf("pixel (%d,%d) = %v, want %v", p.x, p.y, got, p.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPlaceAxis(t *testing.T) {
+	tests := []struct {
+		frame, size, focus, want float64
+	}{
+		{100, 50, 0.9, 25},   // fits: centered whatever the focus
+		{100, 200, 0.5, -50}, // focus centered
+		{100, 200, 0, 0},     // stops at the left edge
+		{100, 200, 1, -100},  // stops at the right edge
+		{100, 200, 0.6, -70},
+	}
+	for _, tt := range tests {
+		if got := placeAxis(tt.frame, tt.size, tt.focus); got != tt.want {
+			t.Errorf("placeAxis(%v, %v, %v) = %v, want %v", tt.frame, tt.size, tt.focus, got, tt.want)
+		}
+	}
+}
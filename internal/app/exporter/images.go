@@ -0,0 +1,116 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	// Register the decoders for every format the frontend can hand us
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/webp"
+
+	"storyboard_flow/internal/storage"
+)
+
+// UnresolvedPanel describes a panel whose image could not be loaded during export
+type UnresolvedPanel struct {
+	PanelID string `json:"panel_id"`
+	Order   int    `json:"order"`
+	Reason  string `json:"reason"`
+}
+
+// decodePanelImage resolves a Panel.ImageData value and decodes it.
+// Supported sources are data URIs, asset store references, file:// URLs,
+// absolute paths and paths relative to baseDir (falling back to the working
+// directory).
+func decodePanelImage(src, baseDir string) (image.Image, error) {
+	r, closer, err := openPanelImage(src, baseDir)
+	if err != nil {
+		return nil, err
+	}
+	defer closer()
+
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, nil
+}
+
+// openPanelImage returns a reader over the encoded bytes of a panel image
+func openPanelImage(src, baseDir string) (io.Reader, func(), error) {
+	src = strings.TrimSpace(src)
+	if src == "" {
+		return nil, nil, fmt.Errorf("panel has no image")
+	}
+
+	if strings.HasPrefix(src, "data:") {
+		data, err := decodeDataURI(src)
+		if err != nil {
+			return nil, nil, err
+		}
+		return bytes.NewReader(data), func() {}, nil
+	}
+
+	path, err := (&storage.Resolver{Dir: baseDir}).Resolve(storage.AssetPanel, src)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, func() { f.Close() }, nil
+}
+
+// CheckPanelImage verifies that a Panel.ImageData value resolves to a decodable
+// image without decoding the full pixel data
+func CheckPanelImage(src, baseDir string) error {
+	r, closer, err := openPanelImage(src, baseDir)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	if _, _, err := image.DecodeConfig(r); err != nil {
+		return fmt.Errorf("unsupported or corrupt image: %w", err)
+	}
+	return nil
+}
+
+// decodeDataURI returns the payload of a data URI
+// Format is "data:[<mediatype>][;base64],<data>"
+func decodeDataURI(uri string) ([]byte, error) {
+	header, payload, ok := strings.Cut(uri, ",")
+	if !ok {
+		return nil, fmt.Errorf("malformed data URI")
+	}
+
+	if strings.HasSuffix(header, ";base64") {
+		data, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			// Some encoders drop the padding
+			data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(payload, "="))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode base64 data URI: %w", err)
+		}
+		return data, nil
+	}
+
+	data, err := url.PathUnescape(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode data URI: %w", err)
+	}
+	return []byte(data), nil
+}
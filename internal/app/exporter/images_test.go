@@ -0,0 +1,115 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"storyboard_flow/internal/storage"
+)
+
+// testPNG returns a w x h PNG filled with c
+func testPNG(t *testing.T, w, h int, c color.Color) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeDataURI(t *testing.T) {
+	payload := []byte("hello, world!")
+	padded := base64.StdEncoding.EncodeToString(payload)
+
+	tests := []struct {
+		name    string
+		uri     string
+		want    string
+		wantErr bool
+	}{
+		{"padded base64", "data:image/png;base64," + padded, "hello, world!", false},
+		{"unpadded base64", "data:image/png;base64," + strings.TrimRight(padded, "="), "hello, world!", false},
+		{"percent-encoded", "data:text/plain,hello%2C%20world%21", "hello, world!", false},
+		{"no comma", "data:image/png;base64", "", true},
+		{"bad base64", "data:image/png;base64,@@@@", "", true},
+		{"bad escape", "data:text/plain,%zz", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeDataURI(tt.uri)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodePanelImageSources(t *testing.T) {
+	dir := t.TempDir()
+	data := testPNG(t, 3, 2, color.RGBA{R: 255, A: 255})
+
+	if err := os.MkdirAll(filepath.Join(dir, "shots"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	abs := filepath.Join(dir, "shots", "a.png")
+	if err := os.WriteFile(abs, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	ref, err := storage.NewAssetStore(dir).Put(data, ".png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"data URI", "data:image/png;base64," + base64.StdEncoding.EncodeToString(data)},
+		{"asset reference", ref},
+		{"relative path", "shots/a.png"},
+		{"absolute path", abs},
+		{"file URL", "file://" + filepath.ToSlash(abs)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := decodePanelImage(tt.src, dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b := img.Bounds(); b.Dx() != 3 || b.Dy() != 2 {
+				t.Errorf("decoded a %dx%d image, want 3x2", b.Dx(), b.Dy())
+			}
+			if err := CheckPanelImage(tt.src, dir); err != nil {
+				t.Errorf("CheckPanelImage: %v", err)
+			}
+		})
+	}
+
+	for _, src := range []string{"", "shots/missing.png", "asset:" + strings.Repeat("0", 64) + ".png", "data:image/png;base64,bm90IGFuIGltYWdl"} {
+		if _, err := decodePanelImage(src, dir); err == nil {
+			t.Errorf("%q: expected an error", src)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package exporter
+
+import (
+	"image"
+	"math"
+
+	xdraw "golang.org/x/image/draw"
+
+	"storyboard_flow/internal/models"
+)
+
+const (
+	// maxMotionScale caps how far a move may push in, which bounds the size of
+	// the oversampled source image kept in memory
+	maxMotionScale = 3.0
+	// defaultTransitionSecs is used when a transition has no duration set
+	defaultTransitionSecs = 0.5
+)
+
+// panelRenderer produces the frames of one panel, following its camera motion
+type panelRenderer struct {
+	base   *image.RGBA // panel image at output size times the largest scale
+	motion *models.CameraMotion
+	frame  *image.RGBA // reused output buffer
+	static bool
+}
+
+// newPanelRenderer prepares a panel's image for rendering at width x height.
+// src may be empty for a blank panel.
+func newPanelRenderer(src, baseDir string, motion *models.CameraMotion, fit imageFit, width, height int) (*panelRenderer, error) {
+	r := &panelRenderer{motion: motion, static: motion == nil}
+
+	// Oversample so pushing in stays sharp
+	k := 1.0
+	if motion != nil {
+		k = math.Min(math.Max(math.Max(motion.Start.Scale, motion.End.Scale), 1), maxMotionScale)
+	}
+	bw, bh := int(math.Round(float64(width)*k)), int(math.Round(float64(height)*k))
+
+	if src == "" {
+		r.base = blankFrame(bw, bh)
+	} else {
+		fit.pixelScale = k
+		img, err := loadAndPrepareImage(src, baseDir, bw, bh, fit)
+		if err != nil {
+			return nil, err
+		}
+		r.base = img
+	}
+
+	if r.static {
+		r.frame = r.base
+	} else {
+		r.frame = image.NewRGBA(image.Rect(0, 0, width, height))
+	}
+	return r, nil
+}
+
+// render returns the frame at progress t (0 at the first frame, 1 at the last).
+// The returned image is reused by the next call.
+func (r *panelRenderer) render(t float64) *image.RGBA {
+	if r.static {
+		return r.frame
+	}
+
+	e := ease(r.motion.Easing, t)
+	f := models.Framing{
+		X:     lerp(r.motion.Start.X, r.motion.End.X, e),
+		Y:     lerp(r.motion.Start.Y, r.motion.End.Y, e),
+		Scale: lerp(r.motion.Start.Scale, r.motion.End.Scale, e),
+	}
+
+	xdraw.ApproxBiLinear.Scale(r.frame, r.frame.Bounds(), r.base, cropWindow(r.base.Bounds(), f), xdraw.Src, nil)
+	return r.frame
+}
+
+// cropWindow returns the part of bounds shown by a framing, kept inside bounds
+func cropWindow(bounds image.Rectangle, f models.Framing) image.Rectangle {
+	scale := math.Max(f.Scale, 1)
+	bw, bh := float64(bounds.Dx()), float64(bounds.Dy())
+	w, h := bw/scale, bh/scale
+
+	x := math.Min(math.Max(f.X*bw-w/2, 0), bw-w)
+	y := math.Min(math.Max(f.Y*bh-h/2, 0), bh-h)
+
+	x0, y0 := int(math.Round(x)), int(math.Round(y))
+	return image.Rect(x0, y0, x0+int(math.Round(w)), y0+int(math.Round(h))).Add(bounds.Min).Intersect(bounds)
+}
+
+// ease maps linear progress t through an easing curve
+func ease(curve string, t float64) float64 {
+	switch curve {
+	case models.EaseLinear:
+		return t
+	case models.EaseIn:
+		return t * t
+	case models.EaseOut:
+		return 1 - (1-t)*(1-t)
+	default:
+		return t * t * (3 - 2*t)
+	}
+}
+
+func lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
+
+// transitionFrames returns how many frames a panel's transition lasts
+func transitionFrames(tr *models.Transition, clipFrames, fps int) int {
+	if tr == nil || tr.Type == "" || tr.Type == models.TransitionCut {
+		return 0
+	}
+	secs := tr.Duration
+	if secs <= 0 {
+		secs = defaultTransitionSecs
+	}
+	n := int(math.Round(secs * float64(fps)))
+	if n > clipFrames {
+		n = clipFrames
+	}
+	return n
+}
+
+// applyTransition composes frame i of a clip with its transition into dst.
+// prev is the last frame of the previous clip, nil at the start of the video.
+// It returns the frame to write, which is frame itself outside the transition.
+func applyTransition(dst, frame, prev *image.RGBA, tr *models.Transition, i, frames, n int) *image.RGBA {
+	if n == 0 {
+		return frame
+	}
+
+	switch tr.Type {
+	case models.TransitionFadeToBlack:
+		if i < frames-n {
+			return frame
+		}
+		blend(dst, frame, nil, float64(i-(frames-n)+1)/float64(n))
+	case models.TransitionFadeFromBlack:
+		if i >= n {
+			return frame
+		}
+		blend(dst, nil, frame, float64(i)/float64(n))
+	case models.TransitionDissolve:
+		if i >= n || prev == nil {
+			return frame
+		}
+		blend(dst, prev, frame, float64(i+1)/float64(n+1))
+	case models.TransitionWipe:
+		if i >= n || prev == nil {
+			return frame
+		}
+		wipe(dst, prev, frame, float64(i+1)/float64(n+1))
+	default:
+		return frame
+	}
+	return dst
+}
+
+// blend writes a*(1-t) + b*t into dst; a nil image stands for black
+func blend(dst, a, b *image.RGBA, t float64) {
+	wb := int(t * 256)
+	wa := 256 - wb
+	for i := range dst.Pix {
+		var va, vb int
+		if a != nil {
+			va = int(a.Pix[i])
+		}
+		if b != nil {
+			vb = int(b.Pix[i])
+		}
+		dst.Pix[i] = uint8((va*wa + vb*wb) >> 8)
+	}
+	// Keep frames opaque
+	for i := 3; i < len(dst.Pix); i += 4 {
+		dst.Pix[i] = 0xff
+	}
+}
+
+// wipe writes b over a left to right, revealing a fraction t of b
+func wipe(dst, a, b *image.RGBA, t float64) {
+	edge := int(t * float64(dst.Rect.Dx()))
+	copy(dst.Pix, a.Pix)
+	for y := 0; y < dst.Rect.Dy(); y++ {
+		row := y * dst.Stride
+		copy(dst.Pix[row:row+edge*4], b.Pix[row:row+edge*4])
+	}
+}
@@ -0,0 +1,192 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"testing"
+
+	"storyboard_flow/internal/models"
+)
+
+func TestEase(t *testing.T) {
+	tests := []struct {
+		curve string
+		t     float64
+		want  float64
+	}{
+		{models.EaseLinear, 0.25, 0.25},
+		{models.EaseIn, 0.5, 0.25},
+		{models.EaseOut, 0.5, 0.75},
+		{models.EaseInOut, 0.25, 0.15625},
+		{models.EaseInOut, 0.5, 0.5},
+		{"", 0.75, 0.84375}, // ease in and out by default
+	}
+	for _, tt := range tests {
+		if got := ease(tt.curve, tt.t); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("ease(%q, %v) = %v, want %v", tt.curve, tt.t, got, tt.want)
+		}
+	}
+
+	// Every curve starts at 0 and ends at 1
+	for _, curve := range []string{models.EaseLinear, models.EaseIn, models.EaseOut, models.EaseInOut} {
+		if ease(curve, 0) != 0 || ease(curve, 1) != 1 {
+			t.Errorf("%s runs from %v to %v", curve, ease(curve, 0), ease(curve, 1))
+		}
+	}
+}
+
+func TestCropWindow(t *testing.T) {
+	bounds := image.Rect(0, 0, 100, 50)
+	tests := []struct {
+		name string
+		f    models.Framing
+		want image.Rectangle
+	}{
+		{"full frame", models.Framing{X: 0.5, Y: 0.5, Scale: 1}, bounds},
+		{"push in on the center", models.Framing{X: 0.5, Y: 0.5, Scale: 2}, image.Rect(25, 13, 75, 38)},
+		{"kept inside the left edge", models.Framing{X: 0, Y: 0.5, Scale: 2}, image.Rect(0, 13, 50, 38)},
+		{"kept inside the bottom right", models.Framing{X: 1, Y: 1, Scale: 4}, image.Rect(75, 38, 100, 50)},
+		{"scales below 1 show the full frame", models.Framing{X: 0.2, Y: 0.2, Scale: 0.5}, bounds},
+	}
+	for _, tt := range tests {
+		if got := cropWindow(bounds, tt.f); got != tt.want {
+			t.Errorf("%s: %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// halfAndHalf returns a data URI of a w x h PNG, black on the left and white
+// on the right
+func halfAndHalf(t *testing.T, w, h int) string {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x >= w/2 {
+				img.Set(x, y, color.White)
+			} else {
+				img.Set(x, y, color.Black)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestPanelRendererFollowsMotion(t *testing.T) {
+	motion := &models.CameraMotion{
+		Start:  models.Framing{X: 0.25, Y: 0.5, Scale: 2},
+		End:    models.Framing{X: 0.75, Y: 0.5, Scale: 2},
+		Easing: models.EaseLinear,
+	}
+	fit := imageFit{mode: models.FitFill, focus: models.FocalPoint{X: 0.5, Y: 0.5}, matte: color.Black}
+	r, err := newPanelRenderer(halfAndHalf(t, 64, 32), "", motion, fit, 32, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := r.base.Bounds(); b.Dx() != 64 || b.Dy() != 32 {
+		t.Errorf("source oversampled to %v, want twice the frame size", b)
+	}
+
+	// The move pans from the black half to the white half
+	if c := r.render(0).RGBAAt(16, 8); c.R != 0 {
+		t.Errorf("first frame center = %v, want black", c)
+	}
+	if c := r.render(1).RGBAAt(16, 8); c.R != 0xff {
+		t.Errorf("last frame center = %v, want white", c)
+	}
+	if b := r.render(0.5).Bounds(); b.Dx() != 32 || b.Dy() != 16 {
+		t.Errorf("frame is %v, want the export size", b)
+	}
+
+	static, err := newPanelRenderer("", "", nil, fit, 32, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if static.render(0) != static.render(1) {
+		t.Error("a panel without motion renders new frames")
+	}
+}
+
+func TestTransitionFrames(t *testing.T) {
+	tests := []struct {
+		name   string
+		tr     *models.Transition
+		frames int
+		fps    int
+		want   int
+	}{
+		{"no transition", nil, 48, 24, 0},
+		{"cut", &models.Transition{Type: models.TransitionCut, Duration: 1}, 48, 24, 0},
+		{"default length", &models.Transition{Type: models.TransitionDissolve}, 48, 24, 12},
+		{"set length", &models.Transition{Type: models.TransitionWipe, Duration: 1}, 75, 25, 25},
+		{"capped at the clip", &models.Transition{Type: models.TransitionFadeToBlack, Duration: 4}, 30, 30, 30},
+	}
+	for _, tt := range tests {
+		if got := transitionFrames(tt.tr, tt.frames, tt.fps); got != tt.want {
+			t.Errorf("%s: %d frames, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+// solid returns a w x h frame filled with c
+func solid(w, h int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	return img
+}
+
+func TestApplyTransition(t *testing.T) {
+	white := color.RGBA{0xff, 0xff, 0xff, 0xff}
+	black := color.RGBA{0, 0, 0, 0xff}
+	prev, frame := solid(4, 1, black), solid(4, 1, white)
+
+	tests := []struct {
+		name    string
+		typ     string
+		i       int
+		prev    *image.RGBA
+		want    []uint8 // red channel of each pixel
+		through bool    // frame is returned untouched
+	}{
+		{"fade to black ends black", models.TransitionFadeToBlack, 9, prev, []uint8{0, 0, 0, 0}, false},
+		{"fade to black before it starts", models.TransitionFadeToBlack, 5, prev, nil, true},
+		{"fade from black starts black", models.TransitionFadeFromBlack, 0, prev, []uint8{0, 0, 0, 0}, false},
+		{"fade from black halfway", models.TransitionFadeFromBlack, 2, prev, []uint8{127, 127, 127, 127}, false},
+		{"fade from black after it ends", models.TransitionFadeFromBlack, 4, prev, nil, true},
+		{"dissolve mixes in the previous frame", models.TransitionDissolve, 1, prev, []uint8{101, 101, 101, 101}, false},
+		{"dissolve at the start of the video", models.TransitionDissolve, 0, nil, nil, true},
+		{"wipe reveals from the left", models.TransitionWipe, 1, prev, []uint8{0xff, 0, 0, 0}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := image.NewRGBA(frame.Rect)
+			tr := &models.Transition{Type: tt.typ}
+			got := applyTransition(dst, frame, tt.prev, tr, tt.i, 10, 4)
+			if tt.through {
+				if got != frame {
+					t.Error("frame outside the transition was changed")
+				}
+				return
+			}
+			for x, want := range tt.want {
+				if c := got.RGBAAt(x, 0); c.R != want || c.A != 0xff {
+					t.Errorf("pixel %d = %v, want red %d and opaque", x, c, want)
+				}
+			}
+		})
+	}
+
+	if got := applyTransition(image.NewRGBA(frame.Rect), frame, prev, nil, 0, 10, 0); got != frame {
+		t.Error("a clip without a transition was changed")
+	}
+}
@@ -0,0 +1,247 @@
+package exporter
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+
+	"storyboard_flow/internal/models"
+)
+
+// Overlays selects the information burned into each frame of an MP4 export
+type Overlays struct {
+	Timecode  bool `json:"timecode"`  // record timecode, top right
+	Panel     bool `json:"panel"`     // panel number and scene/shot number, top left
+	ShotInfo  bool `json:"shot_info"` // shot type, camera angle and move, under the panel number
+	Subtitles bool `json:"subtitles"` // the panel's dialogue, bottom center
+	Watermark bool `json:"watermark"` // project name and export date, bottom right
+	SafeArea  bool `json:"safe_area"` // action-safe (90%) and title-safe (80%) guides
+}
+
+// Enabled reports whether any overlay is switched on
+func (o Overlays) Enabled() bool {
+	return o.Timecode || o.Panel || o.ShotInfo || o.Subtitles || o.Watermark || o.SafeArea
+}
+
+var (
+	overlayTextColor = image.NewUniform(color.White)
+	overlayBoxColor  = image.NewUniform(color.RGBA{0, 0, 0, 160})
+	overlayGuide     = image.NewUniform(color.RGBA{80, 80, 80, 160})
+)
+
+// The bundled Go fonts are parsed once and shared by every export
+var (
+	overlayFontsOnce sync.Once
+	overlayRegular   *opentype.Font
+	overlayMono      *opentype.Font
+	overlayFontsErr  error
+)
+
+func loadOverlayFonts() error {
+	overlayFontsOnce.Do(func() {
+		if overlayRegular, overlayFontsErr = opentype.Parse(goregular.TTF); overlayFontsErr != nil {
+			return
+		}
+		overlayMono, overlayFontsErr = opentype.Parse(gomono.TTF)
+	})
+	return overlayFontsErr
+}
+
+// overlayRenderer burns the selected overlays into frames
+type overlayRenderer struct {
+	opts      Overlays
+	fps       int
+	label     font.Face
+	mono      font.Face
+	subtitle  font.Face
+	margin    image.Point // distance of the text from the frame edges
+	watermark string
+
+	// Text of the clip being written, set by startClip
+	panelText string
+	shotText  string
+	subtitles []string
+}
+
+// newOverlayRenderer prepares fonts sized for a width x height frame
+func newOverlayRenderer(p *models.Project, opts ExportOptions) (*overlayRenderer, error) {
+	if err := loadOverlayFonts(); err != nil {
+		return nil, fmt.Errorf("failed to load overlay font: %w", err)
+	}
+
+	face := func(f *opentype.Font, size int) (font.Face, error) {
+		return opentype.NewFace(f, &opentype.FaceOptions{Size: float64(size), DPI: 72, Hinting: font.HintingFull})
+	}
+
+	r := &overlayRenderer{
+		opts:      opts.Overlays,
+		fps:       opts.FPS,
+		margin:    image.Pt(opts.Width/20, opts.Height/20), // action-safe edge
+		watermark: fmt.Sprintf("%s  %s", p.Name, time.Now().Format("2006-01-02")),
+	}
+
+	var err error
+	labelSize := max(opts.Height/32, 10)
+	if r.label, err = face(overlayRegular, labelSize); err != nil {
+		return nil, err
+	}
+	if r.mono, err = face(overlayMono, labelSize); err != nil {
+		return nil, err
+	}
+	if r.subtitle, err = face(overlayRegular, max(opts.Height/22, 12)); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// startClip prepares the text shown while clip is on screen
+func (r *overlayRenderer) startClip(clip timelineClip, width int) {
+	r.panelText = fmt.Sprintf("Panel %d", clip.panel.Order+1)
+	if clip.scene != nil {
+		num := clip.scene.Number
+		if num == "" {
+			num = fmt.Sprint(clip.scene.Order + 1)
+		}
+		r.panelText += fmt.Sprintf("  Sc %s / Shot %d", num, clip.shot)
+	}
+
+	var shot []string
+	for _, s := range []string{clip.panel.ShotType, clip.panel.CameraAngle, clip.panel.CameraMove} {
+		if s != "" {
+			shot = append(shot, s)
+		}
+	}
+	r.shotText = strings.Join(shot, " / ")
+
+	r.subtitles = nil
+	if dialogue := strings.TrimSpace(clip.panel.Dialogue); dialogue != "" {
+		maxWidth := width - 2*r.margin.X
+		for _, para := range strings.Split(dialogue, "\n") {
+			r.subtitles = append(r.subtitles, wrapOverlayText(r.subtitle, para, maxWidth)...)
+		}
+	}
+}
+
+// draw burns the overlays for timeline frame into dst
+func (r *overlayRenderer) draw(dst *image.RGBA, frame int) {
+	b := dst.Bounds()
+
+	if r.opts.SafeArea {
+		drawGuide(dst, insetRect(b, 20))
+		drawGuide(dst, insetRect(b, 10))
+	}
+
+	if r.opts.Timecode {
+		tc := timecode(edlRecordStart*r.fps+frame, r.fps)
+		r.drawLabel(dst, r.mono, tc, b.Max.X-r.margin.X, b.Min.Y+r.margin.Y, 1)
+	}
+
+	y := b.Min.Y + r.margin.Y
+	if r.opts.Panel {
+		y += r.drawLabel(dst, r.label, r.panelText, b.Min.X+r.margin.X, y, -1)
+	}
+	if r.opts.ShotInfo && r.shotText != "" {
+		r.drawLabel(dst, r.label, r.shotText, b.Min.X+r.margin.X, y, -1)
+	}
+
+	bottom := b.Max.Y - r.margin.Y
+	if r.opts.Watermark {
+		h := lineHeight(r.label)
+		r.drawLabel(dst, r.label, r.watermark, b.Max.X-r.margin.X, bottom-h, 1)
+		bottom -= h + h/2
+	}
+	if r.opts.Subtitles && len(r.subtitles) > 0 {
+		h := lineHeight(r.subtitle)
+		y := bottom - h*len(r.subtitles)
+		for _, line := range r.subtitles {
+			y += r.drawLabel(dst, r.subtitle, line, (b.Min.X+b.Max.X)/2, y, 0)
+		}
+	}
+}
+
+// drawLabel writes text on a translucent box whose top edge is at y. align
+// anchors x to the box's left edge (-1), center (0) or right edge (1).
+// It returns the height of the box.
+func (r *overlayRenderer) drawLabel(dst *image.RGBA, face font.Face, text string, x, y, align int) int {
+	if text == "" {
+		return 0
+	}
+	metrics := face.Metrics()
+	pad := metrics.Ascent.Ceil() / 4
+	h := lineHeight(face)
+	w := font.MeasureString(face, text).Ceil() + 2*pad
+
+	switch align {
+	case 0:
+		x -= w / 2
+	case 1:
+		x -= w
+	}
+
+	draw.Draw(dst, image.Rect(x, y, x+w, y+h), overlayBoxColor, image.Point{}, draw.Over)
+	d := font.Drawer{
+		Dst:  dst,
+		Src:  overlayTextColor,
+		Face: face,
+		Dot:  fixed.P(x+pad, y+pad+metrics.Ascent.Ceil()),
+	}
+	d.DrawString(text)
+	return h
+}
+
+// lineHeight returns the height of one padded label line
+func lineHeight(face font.Face) int {
+	metrics := face.Metrics()
+	return metrics.Ascent.Ceil() + metrics.Descent.Ceil() + metrics.Ascent.Ceil()/2
+}
+
+// wrapOverlayText breaks text into lines no wider than maxWidth pixels
+func wrapOverlayText(face font.Face, text string, maxWidth int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if line != "" && font.MeasureString(face, candidate).Ceil() > maxWidth {
+			lines = append(lines, line)
+			line = word
+		} else {
+			line = candidate
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// insetRect shrinks r by pct percent of its size, split evenly between sides
+func insetRect(r image.Rectangle, pct int) image.Rectangle {
+	dx := r.Dx() * pct / 200
+	dy := r.Dy() * pct / 200
+	return image.Rect(r.Min.X+dx, r.Min.Y+dy, r.Max.X-dx, r.Max.Y-dy)
+}
+
+// drawGuide outlines r with a thin translucent line
+func drawGuide(dst *image.RGBA, r image.Rectangle) {
+	for _, edge := range []image.Rectangle{
+		image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+1),
+		image.Rect(r.Min.X, r.Max.Y-1, r.Max.X, r.Max.Y),
+		image.Rect(r.Min.X, r.Min.Y+1, r.Min.X+1, r.Max.Y-1),
+		image.Rect(r.Max.X-1, r.Min.Y+1, r.Max.X, r.Max.Y-1),
+	} {
+		draw.Draw(dst, edge, overlayGuide, image.Point{}, draw.Over)
+	}
+}
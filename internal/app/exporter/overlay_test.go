@@ -0,0 +1,181 @@
+package exporter
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font"
+
+	"storyboard_flow/internal/models"
+)
+
+func TestOverlaysEnabled(t *testing.T) {
+	if (Overlays{}).Enabled() {
+		t.Error("no overlays reported as enabled")
+	}
+	for _, o := range []Overlays{
+		{Timecode: true}, {Panel: true}, {ShotInfo: true},
+		{Subtitles: true}, {Watermark: true}, {SafeArea: true},
+	} {
+		if !o.Enabled() {
+			t.Errorf("%+v reported as disabled", o)
+		}
+	}
+}
+
+func TestInsetRect(t *testing.T) {
+	r := image.Rect(0, 0, 1920, 1080)
+	if got, want := insetRect(r, 10), image.Rect(96, 54, 1824, 1026); got != want {
+		t.Errorf("action safe = %v, want %v", got, want)
+	}
+	if got, want := insetRect(r, 20), image.Rect(192, 108, 1728, 972); got != want {
+		t.Errorf("title safe = %v, want %v", got, want)
+	}
+}
+
+func TestDrawGuide(t *testing.T) {
+	dst := solid(10, 10, color.RGBA{0xff, 0xff, 0xff, 0xff})
+	drawGuide(dst, image.Rect(2, 2, 8, 8))
+
+	for _, p := range []image.Point{{2, 2}, {7, 2}, {2, 7}, {7, 7}, {5, 2}, {2, 5}} {
+		if c := dst.RGBAAt(p.X, p.Y); c.R == 0xff {
+			t.Errorf("edge pixel %v not drawn", p)
+		}
+	}
+	for _, p := range []image.Point{{1, 1}, {5, 5}, {8, 8}} {
+		if c := dst.RGBAAt(p.X, p.Y); c.R != 0xff {
+			t.Errorf("pixel %v off the edge was drawn", p)
+		}
+	}
+}
+
+// testRenderer returns an overlay renderer for a 640x360 frame at 25 fps
+func testRenderer(t *testing.T, o Overlays) *overlayRenderer {
+	t.Helper()
+	opts := ExportOptions{Width: 640, Height: 360, FPS: 25, Overlays: o}
+	r, err := newOverlayRenderer(models.NewProject("overlay"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestWrapOverlayText(t *testing.T) {
+	face := testRenderer(t, Overlays{}).subtitle
+	text := "the quick brown fox jumps over the lazy dog"
+	width := font.MeasureString(face, "the quick brown").Ceil()
+
+	lines := wrapOverlayText(face, text, width)
+	if len(lines) < 3 {
+		t.Fatalf("wrapped into %v, want at least three lines", lines)
+	}
+	for _, line := range lines {
+		if font.MeasureString(face, line).Ceil() > width {
+			t.Errorf("line %q is wider than %d", line, width)
+		}
+	}
+	if got := strings.Join(lines, " "); got != text {
+		t.Errorf("wrapping changed the words to %q", got)
+	}
+
+	// A word wider than the line is kept whole
+	if got := wrapOverlayText(face, "supercalifragilistic", 10); !reflect.DeepEqual(got, []string{"supercalifragilistic"}) {
+		t.Errorf("long word wrapped into %v", got)
+	}
+	if got := wrapOverlayText(face, "   ", width); got != nil {
+		t.Errorf("blank text wrapped into %v", got)
+	}
+}
+
+func TestStartClip(t *testing.T) {
+	r := testRenderer(t, Overlays{})
+	panel := models.Panel{
+		Order:      4,
+		ShotType:   "Close-up",
+		CameraMove: "Pan",
+		Dialogue:   "First line\nSecond line",
+	}
+	scene := &models.Scene{Order: 1}
+
+	r.startClip(timelineClip{panel: panel, scene: scene, shot: 3}, 640)
+	if want := "Panel 5  Sc 2 / Shot 3"; r.panelText != want {
+		t.Errorf("panel text = %q, want %q", r.panelText, want)
+	}
+	if want := "Close-up / Pan"; r.shotText != want {
+		t.Errorf("shot text = %q, want %q", r.shotText, want)
+	}
+	if want := []string{"First line", "Second line"}; !reflect.DeepEqual(r.subtitles, want) {
+		t.Errorf("subtitles = %v, want %v", r.subtitles, want)
+	}
+
+	scene.Number = "12A"
+	r.startClip(timelineClip{panel: models.Panel{}, scene: scene, shot: 1}, 640)
+	if want := "Panel 1  Sc 12A / Shot 1"; r.panelText != want {
+		t.Errorf("panel text = %q, want %q", r.panelText, want)
+	}
+	if r.subtitles != nil || r.shotText != "" {
+		t.Errorf("text of the previous clip kept: %q, %v", r.shotText, r.subtitles)
+	}
+
+	r.startClip(timelineClip{panel: models.Panel{}}, 640)
+	if want := "Panel 1"; r.panelText != want {
+		t.Errorf("panel without a scene = %q, want %q", r.panelText, want)
+	}
+}
+
+// touched reports whether any pixel of r differs from the black frame
+func touched(img *image.RGBA, r image.Rectangle) bool {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if c := img.RGBAAt(x, y); c.R != 0 || c.G != 0 || c.B != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestOverlayDraw(t *testing.T) {
+	black := color.RGBA{0, 0, 0, 0xff}
+	frame := image.Rect(0, 0, 640, 360)
+	topLeft := image.Rect(0, 0, 320, 90)
+	topRight := image.Rect(320, 0, 640, 90)
+	bottomCenter := image.Rect(200, 270, 440, 360)
+
+	tests := []struct {
+		name     string
+		opts     Overlays
+		drawn    []image.Rectangle
+		clean    []image.Rectangle
+		dialogue string
+	}{
+		{"nothing", Overlays{}, nil, []image.Rectangle{frame}, "Hi"},
+		{"timecode", Overlays{Timecode: true}, []image.Rectangle{topRight}, []image.Rectangle{topLeft, bottomCenter}, "Hi"},
+		{"panel", Overlays{Panel: true}, []image.Rectangle{topLeft}, []image.Rectangle{topRight, bottomCenter}, "Hi"},
+		{"subtitles", Overlays{Subtitles: true}, []image.Rectangle{bottomCenter}, []image.Rectangle{topLeft, topRight}, "Hi"},
+		{"subtitles without dialogue", Overlays{Subtitles: true}, nil, []image.Rectangle{frame}, ""},
+		{"safe area", Overlays{SafeArea: true}, []image.Rectangle{insetRect(frame, 10)}, []image.Rectangle{image.Rect(0, 0, 30, 16)}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := testRenderer(t, tt.opts)
+			r.startClip(timelineClip{panel: models.Panel{Dialogue: tt.dialogue}}, frame.Dx())
+			dst := solid(frame.Dx(), frame.Dy(), black)
+			r.draw(dst, 0)
+
+			for _, area := range tt.drawn {
+				if !touched(dst, area) {
+					t.Errorf("nothing drawn in %v", area)
+				}
+			}
+			for _, area := range tt.clean {
+				if touched(dst, area) {
+					t.Errorf("something drawn in %v", area)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,369 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"storyboard_flow/internal/models"
+)
+
+// PDFOptions configures a storyboard PDF export
+type PDFOptions struct {
+	PerPage   int      // panels per page: 1, 3 or 6
+	Landscape bool     // landscape pages instead of portrait
+	PaperSize string   // "letter" (default) or "a4"
+	BaseDir   string   // directory relative image paths are resolved against
+	Strict    bool     // fail instead of drawing empty frames for unresolved images
+	SceneIDs  []string // export only these scenes; empty exports the whole board
+}
+
+// Page geometry in points
+const (
+	pdfMargin    = 36.0
+	pdfGap       = 18.0
+	pdfHeader    = 24.0 // space above the panel grid for the page header
+	pdfFooter    = 18.0 // space below the panel grid for page numbers
+	pdfImageDPI  = 144.0
+	pdfJPEGLevel = 85
+)
+
+// pdfPanel is a panel with the scene it is printed under
+type pdfPanel struct {
+	panel          models.Panel
+	scene          *models.Scene
+	characterNames []string
+}
+
+// pdfLayout describes how panels are arranged on a page
+type pdfLayout struct {
+	cols, rows int
+	side       bool    // text beside the image instead of under it
+	fontSize   float64 // body text size
+}
+
+// ExportProjectToPDF writes a printable storyboard: a title page from the
+// project followed by panel pages in playback order. Each panel shows its
+// number, scene, shot metadata, characters, action notes and dialogue.
+// Only the standard PDF fonts are used and nothing time-dependent is
+// written, so the same project always produces the same file.
+func ExportProjectToPDF(p *models.Project, outputPath string, opts PDFOptions) (*ExportReport, error) {
+	if p == nil {
+		return nil, fmt.Errorf("nil project")
+	}
+
+	layout, err := choosePDFLayout(opts.PerPage, opts.Landscape)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string, len(p.Characters))
+	for _, char := range p.Characters {
+		names[char.ID] = char.Name
+	}
+
+	var items []pdfPanel
+	for _, group := range selectScenes(p.PanelsByScene(), opts.SceneIDs) {
+		for _, panel := range group.Panels {
+			item := pdfPanel{panel: panel, scene: group.Scene}
+			for _, id := range panel.CharacterIDs {
+				if name, ok := names[id]; ok {
+					item.characterNames = append(item.characterNames, name)
+				}
+			}
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("nothing to export")
+	}
+
+	report := &ExportReport{
+		OutputPath:      outputPath,
+		Unresolved:      []UnresolvedPanel{},
+		UnresolvedAudio: []UnresolvedAudio{},
+		Scenes:          []SceneTiming{},
+	}
+
+	panels := make([]models.Panel, len(items))
+	for i, item := range items {
+		panels[i] = item.panel
+	}
+	unresolved := checkPanels(panels, opts.BaseDir, report)
+	if opts.Strict && len(report.Unresolved) > 0 {
+		return report, fmt.Errorf("%d panel image(s) could not be resolved", len(report.Unresolved))
+	}
+
+	width, height := paperSize(opts.PaperSize, opts.Landscape)
+	aspect := p.Aspect()
+	matte := matteColor(p)
+
+	doc := &pdfDocument{}
+	fonts := map[*pdfFont]int{}
+	for _, font := range []*pdfFont{helvetica, helveticaBold} {
+		fonts[font] = doc.add(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", font.base))
+	}
+	pagesID := doc.reserve()
+
+	var pages []*pdfPage
+	title := &pdfPage{width: width, height: height}
+	drawTitlePage(title, p)
+	pages = append(pages, title)
+
+	perPage := layout.cols * layout.rows
+	total := (len(items) + perPage - 1) / perPage
+	for start := 0; start < len(items); start += perPage {
+		page := &pdfPage{width: width, height: height}
+		drawPageFrame(page, p.Name, len(pages), total)
+
+		end := start + perPage
+		if end > len(items) {
+			end = len(items)
+		}
+		for i, item := range items[start:end] {
+			x, y, w, h := layout.cell(i, width, height)
+			if err := drawPanelCell(doc, page, item, layout, x, y, w, h, aspect, matte, unresolved[item.panel.ID], opts.BaseDir, report); err != nil {
+				return nil, err
+			}
+		}
+		pages = append(pages, page)
+	}
+
+	var kids []string
+	for _, page := range pages {
+		contents := doc.addStream("", page.content.Bytes())
+		kids = append(kids, fmt.Sprintf("%d 0 R", doc.add(fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources %s /Contents %d 0 R >>",
+			pagesID, num(width), num(height), page.resources(fonts), contents))))
+	}
+	doc.set(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+
+	root := doc.add(fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+	info := doc.add(fmt.Sprintf("<< /Title %s /Producer (Storyboard Flow) >>", pdfTextString(p.Name)))
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if err := doc.writeTo(f, root, info); err != nil {
+		return nil, err
+	}
+
+	return report, f.Close()
+}
+
+// choosePDFLayout returns the grid for a panels-per-page count
+func choosePDFLayout(perPage int, landscape bool) (pdfLayout, error) {
+	switch perPage {
+	case 0, 6:
+		if landscape {
+			return pdfLayout{cols: 3, rows: 2, fontSize: 8}, nil
+		}
+		return pdfLayout{cols: 2, rows: 3, fontSize: 8}, nil
+	case 3:
+		if landscape {
+			return pdfLayout{cols: 3, rows: 1, fontSize: 9}, nil
+		}
+		return pdfLayout{cols: 1, rows: 3, side: true, fontSize: 9}, nil
+	case 1:
+		return pdfLayout{cols: 1, rows: 1, side: landscape, fontSize: 11}, nil
+	}
+	return pdfLayout{}, fmt.Errorf("unsupported layout: %d panels per page (use 1, 3 or 6)", perPage)
+}
+
+// cell returns the top-left corner and size of grid cell i
+func (l pdfLayout) cell(i int, pageW, pageH float64) (x, y, w, h float64) {
+	areaW := pageW - 2*pdfMargin
+	areaH := pageH - 2*pdfMargin - pdfHeader - pdfFooter
+	w = (areaW - pdfGap*float64(l.cols-1)) / float64(l.cols)
+	h = (areaH - pdfGap*float64(l.rows-1)) / float64(l.rows)
+	x = pdfMargin + float64(i%l.cols)*(w+pdfGap)
+	y = pdfMargin + pdfHeader + float64(i/l.cols)*(h+pdfGap)
+	return x, y, w, h
+}
+
+// paperSize returns the page size in points
+func paperSize(name string, landscape bool) (float64, float64) {
+	w, h := 612.0, 792.0 // US Letter
+	if strings.EqualFold(name, "a4") {
+		w, h = 595.28, 841.89
+	}
+	if landscape {
+		return h, w
+	}
+	return w, h
+}
+
+// drawTitlePage lays out the project name, summary and cast
+func drawTitlePage(page *pdfPage, p *models.Project) {
+	cx := page.width / 2
+	y := page.height * 0.3
+
+	name := p.Name
+	if name == "" {
+		name = "Untitled Storyboard"
+	}
+	for _, line := range helveticaBold.wrap(name, 28, page.width-4*pdfMargin) {
+		page.textCenter(helveticaBold, 28, cx, y, line)
+		y += 34
+	}
+	y += 6
+
+	runtime := 0.0
+	for _, panel := range p.Panels {
+		runtime += panel.Duration
+	}
+	secs := int(math.Round(runtime))
+	summary := fmt.Sprintf("%d panels  |  %d scenes  |  %d:%02d runtime", len(p.Panels), len(p.Scenes), secs/60, secs%60)
+	page.textCenter(helvetica, 12, cx, y, summary)
+	y += 18
+	page.textCenter(helvetica, 12, cx, y, fmt.Sprintf("Aspect ratio %s  |  %d fps", p.AspectRatio, p.FrameRate))
+	y += 18
+	if !p.CreatedAt.IsZero() {
+		page.textCenter(helvetica, 12, cx, y, "Created "+p.CreatedAt.UTC().Format("January 2, 2006"))
+		y += 18
+	}
+
+	if len(p.Characters) == 0 {
+		return
+	}
+
+	y += 30
+	page.textCenter(helveticaBold, 14, cx, y, "Characters")
+	y += 24
+
+	textW := page.width * 0.6
+	x := cx - textW/2
+	bottom := page.height - 2*pdfMargin
+	for i, char := range p.Characters {
+		if y > bottom {
+			page.text(helvetica, 10, x, y, fmt.Sprintf("...and %d more", len(p.Characters)-i))
+			break
+		}
+		page.text(helveticaBold, 11, x, y, helveticaBold.fit(char.Name, 11, textW))
+		y += 14
+		if char.Description != "" {
+			for _, line := range helvetica.wrap(char.Description, 10, textW) {
+				page.text(helvetica, 10, x, y, line)
+				y += 13
+			}
+		}
+		y += 6
+	}
+}
+
+// drawPageFrame draws the header and page number of a panel page
+func drawPageFrame(page *pdfPage, projectName string, number, total int) {
+	page.text(helveticaBold, 10, pdfMargin, pdfMargin+10, helveticaBold.fit(projectName, 10, page.width/2))
+	page.line(pdfMargin, pdfMargin+16, page.width-pdfMargin, pdfMargin+16, 0.5, 0.6)
+	page.textRight(helvetica, 9, page.width-pdfMargin, page.height-pdfMargin+4, fmt.Sprintf("Page %d of %d", number, total))
+}
+
+// drawPanelCell draws one panel's frame and notes inside a grid cell
+func drawPanelCell(doc *pdfDocument, page *pdfPage, item pdfPanel, layout pdfLayout, x, y, w, h, aspect float64, matte color.Color, skip bool, baseDir string, report *ExportReport) error {
+	// Size the frame to the project's aspect ratio, leaving room for text
+	var imgW, imgH, textX, textY, textW, textH float64
+	if layout.side {
+		imgH = h
+		imgW = imgH * aspect
+		if imgW > w*0.55 {
+			imgW = w * 0.55
+			imgH = imgW / aspect
+		}
+		textX, textY, textW, textH = x+imgW+12, y, w-imgW-12, h
+	} else {
+		imgW = w
+		imgH = imgW / aspect
+		if imgH > h*0.65 {
+			imgH = h * 0.65
+			imgW = imgH * aspect
+		}
+		textX, textY, textW, textH = x, y+imgH+8, w, h-imgH-8
+	}
+
+	drawn := false
+	if !skip {
+		px := int(math.Round(imgW * pdfImageDPI / 72))
+		py := int(math.Round(imgH * pdfImageDPI / 72))
+		// Crops match the default video export size
+		videoW, _ := FrameSize(aspect, 0, 0)
+		fit := panelFit(item.panel, matte)
+		fit.pixelScale = float64(px) / float64(videoW)
+		img, err := loadAndPrepareImage(item.panel.ImageData, baseDir, px, py, fit)
+		if err != nil {
+			report.Unresolved = append(report.Unresolved, UnresolvedPanel{
+				PanelID: item.panel.ID,
+				Order:   item.panel.Order,
+				Reason:  err.Error(),
+			})
+		} else {
+			var buf bytes.Buffer
+			if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: pdfJPEGLevel}); err != nil {
+				return err
+			}
+			id := doc.addStream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /DCTDecode", px, py), buf.Bytes())
+			page.image(id, x, y, imgW, imgH)
+			drawn = true
+		}
+	}
+	if !drawn {
+		page.fillRect(x, y, imgW, imgH, 0.93)
+		page.textCenter(helvetica, layout.fontSize, x+imgW/2, y+imgH/2+layout.fontSize/3, "No image")
+	}
+	page.rect(x, y, imgW, imgH, 0.75, 0)
+
+	drawPanelText(page, item, layout.fontSize, textX, textY, textW, textH)
+	return nil
+}
+
+// drawPanelText writes a panel's notes, cutting off with an ellipsis when the box is full
+func drawPanelText(page *pdfPage, item pdfPanel, size, x, y, w, h float64) {
+	lead := size * 1.3
+	panel := item.panel
+
+	y += size
+	bottom := y - size + h
+	page.text(helveticaBold, size+1, x, y, fmt.Sprintf("Panel %d", panel.Order+1))
+	page.textRight(helvetica, size, x+w, y, strconv.FormatFloat(panel.Duration, 'f', 1, 64)+"s")
+	y += lead + 1
+
+	var lines []string
+	if item.scene != nil {
+		lines = append(lines, helvetica.fit("Scene "+sceneLabel(item.scene), size, w))
+	}
+	if notes := clipNotes(panel); notes != "" {
+		lines = append(lines, helvetica.wrap(notes, size, w)...)
+	}
+	if len(item.characterNames) > 0 {
+		lines = append(lines, helvetica.wrap("Characters: "+strings.Join(item.characterNames, ", "), size, w)...)
+	}
+	if panel.ActionNotes != "" {
+		lines = append(lines, helvetica.wrap("Action: "+panel.ActionNotes, size, w)...)
+	}
+	if panel.Dialogue != "" {
+		lines = append(lines, helvetica.wrap("Dialogue: "+panel.Dialogue, size, w)...)
+	}
+
+	for i, line := range lines {
+		if y+lead > bottom && i < len(lines)-1 {
+			page.text(helvetica, size, x, y, helvetica.fit(line+" …", size, w))
+			return
+		}
+		if y > bottom {
+			return
+		}
+		page.text(helvetica, size, x, y, line)
+		y += lead
+	}
+}
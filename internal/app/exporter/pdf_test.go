@@ -0,0 +1,88 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"testing"
+
+	"storyboard_flow/internal/models"
+)
+
+func TestExportPDFStructure(t *testing.T) {
+	p := models.NewProject(`Draft (v2) \ final`)
+	p.Panels[0].Dialogue = `(hi) \ bye`
+	p.Panels[1].ActionNotes = `Open paren ( only`
+
+	out := filepath.Join(t.TempDir(), "board.pdf")
+	if _, err := ExportProjectToPDF(p, out, PDFOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// startxref points at the cross-reference table
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(data)
+	if m == nil {
+		t.Fatal("missing startxref trailer")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(data[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at the xref table", xref)
+	}
+
+	// Every xref entry gives the offset of its object
+	var count int
+	if _, err := fmt.Sscanf(string(data[xref:]), "xref\n0 %d\n", &count); err != nil {
+		t.Fatalf("bad xref header: %v", err)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n \n`).FindAllSubmatch(data[xref:], -1)
+	if len(entries) != count-1 {
+		t.Fatalf("xref lists %d objects, header says %d", len(entries), count-1)
+	}
+	for i, entry := range entries {
+		off, _ := strconv.Atoi(string(entry[1]))
+		want := fmt.Sprintf("%d 0 obj\n", i+1)
+		if off >= len(data) || !bytes.HasPrefix(data[off:], []byte(want)) {
+			t.Errorf("object %d: offset %d does not start %q", i+1, off, want)
+		}
+	}
+	if !bytes.Contains(data, []byte(fmt.Sprintf("/Size %d ", count))) {
+		t.Errorf("trailer /Size does not match the xref count %d", count)
+	}
+
+	// Special characters are escaped and read back unchanged
+	if !bytes.Contains(data, []byte(`/Title (Draft \(v2\) \\ final)`)) {
+		t.Error("document title is not escaped")
+	}
+	var shown []string
+	unescape := regexp.MustCompile(`\\(.)`)
+	for _, s := range regexp.MustCompile(`\(((?:\\.|[^\\()])*)\) Tj`).FindAllSubmatch(data, -1) {
+		shown = append(shown, string(unescape.ReplaceAll(s[1], []byte("$1"))))
+	}
+	for _, want := range []string{`Draft (v2) \ final`, `Dialogue: (hi) \ bye`, `Action: Open paren ( only`} {
+		if !slices.Contains(shown, want) {
+			t.Errorf("text %q not drawn; got %q", want, shown)
+		}
+	}
+}
+
+func TestEscapePDFString(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"plain", "plain"},
+		{"(a)", `\(a\)`},
+		{`back\slash`, `back\\slash`},
+		{`)\(`, `\)\\\(`},
+	}
+	for _, tt := range tests {
+		if got := escapePDFString([]byte(tt.in)); got != tt.want {
+			t.Errorf("escapePDFString(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
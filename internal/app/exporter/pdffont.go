@@ -0,0 +1,137 @@
+package exporter
+
+import "strings"
+
+// pdfFont is one of the standard PDF fonts. Standard fonts are never embedded,
+// so the file only depends on these metrics and comes out byte-identical on
+// every machine.
+type pdfFont struct {
+	resource string // resource name used in content streams
+	base     string // PostScript name
+	widths   [95]int
+}
+
+// Advance widths of printable ASCII (32-126) in 1/1000 em, from the Adobe AFM files
+var (
+	helvetica = &pdfFont{resource: "F1", base: "Helvetica", widths: [95]int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}}
+	helveticaBold = &pdfFont{resource: "F2", base: "Helvetica-Bold", widths: [95]int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}}
+)
+
+// winAnsiExtras maps the punctuation scripts commonly use outside Latin-1
+// to WinAnsiEncoding, with their Helvetica widths
+var winAnsiExtras = map[rune]struct {
+	code  byte
+	width int
+}{
+	'‘': {0x91, 222},  // left single quote
+	'’': {0x92, 222},  // right single quote
+	'“': {0x93, 333},  // left double quote
+	'”': {0x94, 333},  // right double quote
+	'•': {0x95, 350},  // bullet
+	'–': {0x96, 556},  // en dash
+	'—': {0x97, 1000}, // em dash
+	'…': {0x85, 1000}, // ellipsis
+}
+
+// encode converts text to WinAnsiEncoding bytes. Characters the standard
+// fonts cannot show are replaced with '?'.
+func (f *pdfFont) encode(text string) []byte {
+	out := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r >= 32 && r <= 126:
+			out = append(out, byte(r))
+		case r >= 0xA0 && r <= 0xFF:
+			out = append(out, byte(r))
+		case r == '\t':
+			out = append(out, ' ')
+		default:
+			if extra, ok := winAnsiExtras[r]; ok {
+				out = append(out, extra.code)
+			} else {
+				out = append(out, '?')
+			}
+		}
+	}
+	return out
+}
+
+// width returns the width of text in points at the given size
+func (f *pdfFont) width(text string, size float64) float64 {
+	total := 0
+	for _, r := range text {
+		switch {
+		case r >= 32 && r <= 126:
+			total += f.widths[r-32]
+		case r == '\t':
+			total += f.widths[0]
+		default:
+			if extra, ok := winAnsiExtras[r]; ok {
+				total += extra.width
+			} else {
+				total += 556 // Latin-1 letters are close to the digit width
+			}
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// wrap breaks text into lines no wider than maxWidth. Explicit line breaks are
+// kept and words longer than a line are split.
+func (f *pdfFont) wrap(text string, size, maxWidth float64) []string {
+	var lines []string
+	for _, para := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if f.width(candidate, size) <= maxWidth {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			// Split words that do not fit on a line of their own
+			for len([]rune(word)) > 1 && f.width(word, size) > maxWidth {
+				cut := len([]rune(word)) - 1
+				for cut > 1 && f.width(string([]rune(word)[:cut]), size) > maxWidth {
+					cut--
+				}
+				lines = append(lines, string([]rune(word)[:cut]))
+				word = string([]rune(word)[cut:])
+			}
+			line = word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// fit truncates text with an ellipsis so it is no wider than maxWidth
+func (f *pdfFont) fit(text string, size, maxWidth float64) string {
+	if f.width(text, size) <= maxWidth {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && f.width(string(runes)+"…", size) > maxWidth {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes)) + "…"
+}
@@ -0,0 +1,172 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// pdfDocument is a minimal PDF 1.4 writer: numbered objects, a cross-reference
+// table and nothing time- or machine-dependent, so equal input gives equal bytes.
+type pdfDocument struct {
+	objects [][]byte // object bodies, index 0 is object 1
+}
+
+// reserve allocates an object number whose body is set later
+func (d *pdfDocument) reserve() int {
+	d.objects = append(d.objects, nil)
+	return len(d.objects)
+}
+
+// set stores the body of a reserved object
+func (d *pdfDocument) set(id int, body string) {
+	d.objects[id-1] = []byte(body)
+}
+
+// add stores a new object and returns its number
+func (d *pdfDocument) add(body string) int {
+	id := d.reserve()
+	d.set(id, body)
+	return id
+}
+
+// addStream stores a stream object with the given extra dictionary entries
+func (d *pdfDocument) addStream(dict string, data []byte) int {
+	var b bytes.Buffer
+	if dict != "" {
+		dict += " "
+	}
+	fmt.Fprintf(&b, "<< %s/Length %d >>\nstream\n", dict, len(data))
+	b.Write(data)
+	b.WriteString("\nendstream")
+
+	id := d.reserve()
+	d.objects[id-1] = b.Bytes()
+	return id
+}
+
+// writeTo writes the whole file; root and info are object numbers
+func (d *pdfDocument) writeTo(w io.Writer, root, info int) error {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	offsets := make([]int, len(d.objects))
+	for i, body := range d.objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n", i+1)
+		b.Write(body)
+		b.WriteString("\nendobj\n")
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(d.objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(d.objects)+1, root, info, xref)
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// pdfPage collects the content stream of one page. Coordinates passed in are
+// measured from the top-left corner, as in the rest of the app, and flipped
+// to PDF's bottom-left origin here.
+type pdfPage struct {
+	width, height float64
+	content       bytes.Buffer
+	images        []int // XObject numbers, drawn as /Im<index+1>
+}
+
+// text draws a single line of text with its baseline at y
+func (p *pdfPage) text(font *pdfFont, size, x, y float64, s string) {
+	fmt.Fprintf(&p.content, "BT /%s %s Tf %s %s Td (%s) Tj ET\n",
+		font.resource, num(size), num(x), num(p.height-y), escapePDFString(font.encode(s)))
+}
+
+// textRight draws text right-aligned to x
+func (p *pdfPage) textRight(font *pdfFont, size, x, y float64, s string) {
+	p.text(font, size, x-font.width(s, size), y, s)
+}
+
+// textCenter draws text centered on x
+func (p *pdfPage) textCenter(font *pdfFont, size, x, y float64, s string) {
+	p.text(font, size, x-font.width(s, size)/2, y, s)
+}
+
+// rect strokes a rectangle whose top-left corner is x, y
+func (p *pdfPage) rect(x, y, w, h, lineWidth, gray float64) {
+	fmt.Fprintf(&p.content, "q %s w %s G %s %s %s %s re S Q\n",
+		num(lineWidth), num(gray), num(x), num(p.height-y-h), num(w), num(h))
+}
+
+// fillRect fills a rectangle whose top-left corner is x, y with a gray level
+func (p *pdfPage) fillRect(x, y, w, h, gray float64) {
+	fmt.Fprintf(&p.content, "q %s g %s %s %s %s re f Q\n",
+		num(gray), num(x), num(p.height-y-h), num(w), num(h))
+}
+
+// line strokes a line between two points
+func (p *pdfPage) line(x1, y1, x2, y2, lineWidth, gray float64) {
+	fmt.Fprintf(&p.content, "q %s w %s G %s %s m %s %s l S Q\n",
+		num(lineWidth), num(gray), num(x1), num(p.height-y1), num(x2), num(p.height-y2))
+}
+
+// image draws an image XObject into the box whose top-left corner is x, y
+func (p *pdfPage) image(id int, x, y, w, h float64) {
+	p.images = append(p.images, id)
+	fmt.Fprintf(&p.content, "q %s 0 0 %s %s %s cm /Im%d Do Q\n",
+		num(w), num(h), num(x), num(p.height-y-h), len(p.images))
+}
+
+// resources returns the page's resource dictionary
+func (p *pdfPage) resources(fonts map[*pdfFont]int) string {
+	var b strings.Builder
+	b.WriteString("<< /Font <<")
+	for _, font := range []*pdfFont{helvetica, helveticaBold} {
+		fmt.Fprintf(&b, " /%s %d 0 R", font.resource, fonts[font])
+	}
+	b.WriteString(" >>")
+	if len(p.images) > 0 {
+		b.WriteString(" /XObject <<")
+		for i, id := range p.images {
+			fmt.Fprintf(&b, " /Im%d %d 0 R", i+1, id)
+		}
+		b.WriteString(" >>")
+	}
+	b.WriteString(" >>")
+	return b.String()
+}
+
+// num formats a coordinate with at most two decimals and no trailing zeros
+func num(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
+
+// escapePDFString escapes the characters that are special inside a PDF literal string
+func escapePDFString(b []byte) string {
+	var out strings.Builder
+	for _, c := range b {
+		switch c {
+		case '(', ')', '\\':
+			out.WriteByte('\\')
+			out.WriteByte(c)
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.String()
+}
+
+// pdfTextString encodes a document information string
+func pdfTextString(s string) string {
+	return "(" + escapePDFString(helvetica.encode(s)) + ")"
+}
@@ -0,0 +1,175 @@
+package exporter
+
+import (
+	"fmt"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"storyboard_flow/internal/models"
+)
+
+// timelineClip is one panel placed on the export timeline
+type timelineClip struct {
+	panel  models.Panel
+	scene  *models.Scene // nil for panels without a scene
+	shot   int           // 1-based position within the scene
+	start  int           // first frame on the timeline
+	frames int
+	still  string // still image filename, stable across exports
+}
+
+// buildTimeline lays out the selected scenes' panels back to back, in frames
+func buildTimeline(p *models.Project, opts ExportOptions, report *ExportReport) []timelineClip {
+	var clips []timelineClip
+	frame := 0
+	for _, group := range selectScenes(p.PanelsByScene(), opts.SceneIDs) {
+		timing := SceneTiming{StartFrame: frame}
+		if group.Scene != nil {
+			timing.SceneID = group.Scene.ID
+			timing.Heading = group.Scene.Heading.String()
+		}
+
+		for i, panel := range group.Panels {
+			count := panelFrames(panel, opts)
+			clips = append(clips, timelineClip{
+				panel:  panel,
+				scene:  group.Scene,
+				shot:   i + 1,
+				start:  frame,
+				frames: count,
+				still:  stillName(panel),
+			})
+			frame += count
+			timing.Frames += count
+		}
+
+		report.Scenes = append(report.Scenes, timing)
+	}
+	report.Frames = frame
+
+	return clips
+}
+
+// clipPanels returns the panels of clips in timeline order
+func clipPanels(clips []timelineClip) []models.Panel {
+	panels := make([]models.Panel, len(clips))
+	for i, clip := range clips {
+		panels[i] = clip.panel
+	}
+	return panels
+}
+
+// panelFrames returns how many frames a panel lasts at opts.FPS
+func panelFrames(panel models.Panel, opts ExportOptions) int {
+	secs := panel.Duration
+	if secs <= 0 {
+		secs = opts.DefaultSecs
+	}
+	count := int(math.Round(secs * float64(opts.FPS)))
+	if count <= 0 {
+		count = opts.FPS // at least one second
+	}
+	return count
+}
+
+// stillName returns the still image filename for a panel. It only depends on
+// the panel ID so re-exports relink to the same files after reordering.
+func stillName(panel models.Panel) string {
+	return "panel_" + panel.ID + ".png"
+}
+
+// stillsDir returns the folder stills are written to for an edit list at outputPath
+func stillsDir(outputPath string) string {
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + "_stills"
+}
+
+// checkPanels verifies every panel image up front and records the failures.
+// It returns the IDs of panels that should be rendered blank.
+func checkPanels(panels []models.Panel, baseDir string, report *ExportReport) map[string]bool {
+	unresolved := make(map[string]bool)
+	for _, panel := range panels {
+		if err := CheckPanelImage(panel.ImageData, baseDir); err != nil {
+			report.Unresolved = append(report.Unresolved, UnresolvedPanel{
+				PanelID: panel.ID,
+				Order:   panel.Order,
+				Reason:  err.Error(),
+			})
+			unresolved[panel.ID] = true
+		}
+	}
+	return unresolved
+}
+
+// writeStills renders each clip's panel to a PNG in dir at the export size.
+// Unresolved panels are written as blank stills so the edit list stays complete.
+func writeStills(clips []timelineClip, dir string, matte color.Color, opts ExportOptions, report *ExportReport) error {
+	unresolved := checkPanels(clipPanels(clips), opts.BaseDir, report)
+	if opts.Strict && len(report.Unresolved) > 0 {
+		return fmt.Errorf("%d panel image(s) could not be resolved", len(report.Unresolved))
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	for _, clip := range clips {
+		img := blankFrame(opts.Width, opts.Height)
+		if !unresolved[clip.panel.ID] {
+			loaded, err := loadAndPrepareImage(clip.panel.ImageData, opts.BaseDir, opts.Width, opts.Height, panelFit(clip.panel, matte))
+			if err != nil {
+				report.Unresolved = append(report.Unresolved, UnresolvedPanel{
+					PanelID: clip.panel.ID,
+					Order:   clip.panel.Order,
+					Reason:  err.Error(),
+				})
+			} else {
+				img = loaded
+			}
+		}
+
+		f, err := os.Create(filepath.Join(dir, clip.still))
+		if err != nil {
+			return err
+		}
+		if err := png.Encode(f, img); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// timecode formats a frame count as non-drop-frame HH:MM:SS:FF
+func timecode(frames, fps int) string {
+	ff := frames % fps
+	secs := frames / fps
+	return fmt.Sprintf("%02d:%02d:%02d:%02d", secs/3600, secs/60%60, secs%60, ff)
+}
+
+// clipNotes returns the shot description carried by a clip as comments or markers
+func clipNotes(panel models.Panel) string {
+	var parts []string
+	if panel.ShotType != "" {
+		parts = append(parts, "Shot: "+panel.ShotType)
+	}
+	if panel.CameraAngle != "" {
+		parts = append(parts, "Angle: "+panel.CameraAngle)
+	}
+	if panel.CameraMove != "" {
+		parts = append(parts, "Move: "+panel.CameraMove)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// oneLine collapses line breaks so multi-line text fits a single comment
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,221 @@
+package exporter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"storyboard_flow/internal/models"
+)
+
+func TestTimecode(t *testing.T) {
+	tests := []struct {
+		frames, fps int
+		want        string
+	}{
+		{0, 24, "00:00:00:00"},
+		{23, 24, "00:00:00:23"},
+		{24, 24, "00:00:01:00"},
+		{1499, 25, "00:00:59:24"},
+		{1500, 25, "00:01:00:00"},
+		{3600 * 25, 25, "01:00:00:00"},
+		{90, 30, "00:00:03:00"},
+		{(86399 * 30) + 29, 30, "23:59:59:29"},
+	}
+	for _, tt := range tests {
+		if got := timecode(tt.frames, tt.fps); got != tt.want {
+			t.Errorf("timecode(%d, %d) = %s, want %s", tt.frames, tt.fps, got, tt.want)
+		}
+	}
+}
+
+func TestPanelFrames(t *testing.T) {
+	tests := []struct {
+		secs float64
+		fps  int
+		want int
+	}{
+		{2, 24, 48},
+		{1.5, 24, 36},
+		{0.5, 25, 13}, // 12.5 rounds up
+		{1.02, 25, 26},
+		{0, 30, 90}, // DefaultSecs
+		{-1, 30, 90},
+		{0.01, 24, 24}, // never less than a frame; a second instead
+	}
+	for _, tt := range tests {
+		opts := ExportOptions{FPS: tt.fps, DefaultSecs: 3}
+		if got := panelFrames(models.Panel{Duration: tt.secs}, opts); got != tt.want {
+			t.Errorf("%vs at %d fps = %d frames, want %d", tt.secs, tt.fps, got, tt.want)
+		}
+	}
+}
+
+// editListProject returns a project of three panels lasting 2s, 0.5s and the
+// default duration
+func editListProject() *models.Project {
+	p := models.NewProject("golden")
+	p.Panels = nil
+	for i, secs := range []float64{2, 0.5, 0} {
+		panel := models.NewPanel(i)
+		panel.ID = fmt.Sprintf("p%d", i+1)
+		panel.Duration = secs
+		p.Panels = append(p.Panels, *panel)
+	}
+	p.Panels[1].Dialogue = "Hello\nthere"
+	return p
+}
+
+func editListOptions(fps int) ExportOptions {
+	return ExportOptions{Width: 16, Height: 9, FPS: fps, DefaultSecs: 3}
+}
+
+func TestExportEDLGolden(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "golden.edl")
+	report, err := ExportProjectToEDL(editListProject(), out, editListOptions(25))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"TITLE: golden",
+		"FCM: NON-DROP FRAME",
+		"",
+		"001  AX       V     C        00:00:00:00 00:00:02:00 01:00:00:00 01:00:02:00",
+		"* FROM CLIP NAME: panel_p1.png",
+		"* COMMENT: Shot: Medium, Angle: Eye-level, Move: Static",
+		"",
+		"002  AX       V     C        00:00:00:00 00:00:00:13 01:00:02:00 01:00:02:13",
+		"* FROM CLIP NAME: panel_p2.png",
+		"* COMMENT: Shot: Medium, Angle: Eye-level, Move: Static",
+		"* DIALOGUE: Hello there",
+		"",
+		"003  AX       V     C        00:00:00:00 00:00:03:00 01:00:02:13 01:00:05:13",
+		"* FROM CLIP NAME: panel_p3.png",
+		"* COMMENT: Shot: Medium, Angle: Eye-level, Move: Static",
+		"",
+		"",
+	}, "\r\n")
+	if string(data) != want {
+		t.Errorf("EDL:\n%s\nwant:\n%s", data, want)
+	}
+	if report.Frames != 50+13+75 {
+		t.Errorf("report has %d frames, want %d", report.Frames, 50+13+75)
+	}
+	for _, still := range []string{"panel_p1.png", "panel_p2.png", "panel_p3.png"} {
+		if _, err := os.Stat(filepath.Join(stillsDir(out), still)); err != nil {
+			t.Errorf("still missing: %v", err)
+		}
+	}
+}
+
+func TestExportEDLRecordContinuity(t *testing.T) {
+	for _, fps := range []int{24, 25, 30} {
+		t.Run(fmt.Sprint(fps), func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "board.edl")
+			if _, err := ExportProjectToEDL(editListProject(), out, editListOptions(fps)); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Each event records right where the previous one ended, and
+			// records as long as its source runs
+			previousOut := timecode(edlRecordStart*fps, fps)
+			events := 0
+			for _, line := range strings.Split(string(data), "\r\n") {
+				fields := strings.Fields(line)
+				if len(fields) != 8 || fields[2] != "V" {
+					continue
+				}
+				events++
+				srcIn, srcOut, recIn, recOut := fields[4], fields[5], fields[6], fields[7]
+				if recIn != previousOut {
+					t.Errorf("event %d records in at %s, previous event ended at %s", events, recIn, previousOut)
+				}
+				if srcIn != timecode(0, fps) {
+					t.Errorf("event %d source starts at %s", events, srcIn)
+				}
+				if frames(t, recOut, fps)-frames(t, recIn, fps) != frames(t, srcOut, fps) {
+					t.Errorf("event %d records %s to %s for a %s source", events, recIn, recOut, srcOut)
+				}
+				previousOut = recOut
+			}
+			if events != 3 {
+				t.Errorf("found %d events, want 3", events)
+			}
+		})
+	}
+}
+
+// frames parses a non-drop-frame timecode
+func frames(t *testing.T, tc string, fps int) int {
+	t.Helper()
+	var h, m, s, f int
+	if _, err := fmt.Sscanf(tc, "%d:%d:%d:%d", &h, &m, &s, &f); err != nil {
+		t.Fatalf("bad timecode %q: %v", tc, err)
+	}
+	return ((h*60+m)*60+s)*fps + f
+}
+
+func TestExportFCPXMLRationalTime(t *testing.T) {
+	tests := []struct {
+		fps       int
+		frame     string
+		offsets   []string
+		durations []string
+		total     string
+	}{
+		{24, "1/24s", []string{"0s", "48/24s", "60/24s"}, []string{"48/24s", "12/24s", "72/24s"}, "132/24s"},
+		{25, "1/25s", []string{"0s", "50/25s", "63/25s"}, []string{"50/25s", "13/25s", "75/25s"}, "138/25s"},
+		{30, "1/30s", []string{"0s", "60/30s", "75/30s"}, []string{"60/30s", "15/30s", "90/30s"}, "165/30s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.fps), func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "board.fcpxml")
+			if _, err := ExportProjectToFCPXML(editListProject(), out, editListOptions(tt.fps)); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var doc fcpxmlDoc
+			if err := xml.Unmarshal(data, &doc); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := doc.Resources.Formats[0].FrameDuration; got != tt.frame {
+				t.Errorf("frameDuration = %s, want %s", got, tt.frame)
+			}
+			seq := doc.Event.Project.Sequence
+			if seq.Duration != tt.total {
+				t.Errorf("sequence duration = %s, want %s", seq.Duration, tt.total)
+			}
+			if len(seq.Clips) != len(tt.offsets) {
+				t.Fatalf("got %d clips, want %d", len(seq.Clips), len(tt.offsets))
+			}
+			for i, clip := range seq.Clips {
+				if clip.Offset != tt.offsets[i] || clip.Duration != tt.durations[i] {
+					t.Errorf("clip %d at %s for %s, want %s for %s", i+1, clip.Offset, clip.Duration, tt.offsets[i], tt.durations[i])
+				}
+				if clip.Video.Duration != clip.Duration {
+					t.Errorf("clip %d video lasts %s, clip %s", i+1, clip.Video.Duration, clip.Duration)
+				}
+			}
+			if marker := seq.Clips[1].Markers; len(marker) != 1 || marker[0].Note != "Hello there" || marker[0].Duration != tt.frame {
+				t.Errorf("dialogue marker = %+v", marker)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package app
+
+import (
+	"time"
+)
+
+const (
+	// historyLimit caps the number of undo steps kept in memory
+	historyLimit = 200
+	// coalesceWindow is how long consecutive edits with the same key keep merging
+	// into one step (e.g. typing dialogue one keystroke at a time)
+	coalesceWindow = 1500 * time.Millisecond
+)
+
+// historyEntry is one undoable step
+type historyEntry struct {
+	label string
+	key   string // coalescing key; empty never coalesces
+	at    time.Time
+	cmd   command
+}
+
+// history is an undo/redo stack of applied commands
+type history struct {
+	undo []historyEntry
+	redo []historyEntry
+}
+
+// HistoryInfo describes the undo/redo stacks for the UI
+type HistoryInfo struct {
+	CanUndo bool     `json:"can_undo"`
+	CanRedo bool     `json:"can_redo"`
+	Undo    []string `json:"undo"` // labels, most recent first
+	Redo    []string `json:"redo"` // labels, next redo first
+}
+
+// push records an applied command, merging it into the previous step when
+// both share a coalescing key and arrive within the coalescing window
+func (h *history) push(label, key string, cmd command) {
+	now := time.Now()
+	h.redo = nil
+
+	if key != "" && len(h.undo) > 0 {
+		top := &h.undo[len(h.undo)-1]
+		if top.key == key && now.Sub(top.at) < coalesceWindow {
+			if m, ok := top.cmd.(mergeable); ok {
+				if merged, ok := m.merge(cmd); ok {
+					top.cmd = merged
+					top.at = now
+					return
+				}
+			}
+		}
+	}
+
+	h.undo = append(h.undo, historyEntry{label: label, key: key, at: now, cmd: cmd})
+	if len(h.undo) > historyLimit {
+		h.undo = h.undo[len(h.undo)-historyLimit:]
+	}
+}
+
+// popUndo removes the most recent step and moves it onto the redo stack
+func (h *history) popUndo() (historyEntry, bool) {
+	if len(h.undo) == 0 {
+		return historyEntry{}, false
+	}
+	entry := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	// A redone step must never coalesce with a later edit
+	entry.key = ""
+	h.redo = append(h.redo, entry)
+	return entry, true
+}
+
+// popRedo removes the next redo step and moves it back onto the undo stack
+func (h *history) popRedo() (historyEntry, bool) {
+	if len(h.redo) == 0 {
+		return historyEntry{}, false
+	}
+	entry := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+	h.undo = append(h.undo, entry)
+	return entry, true
+}
+
+// reset clears both stacks
+func (h *history) reset() {
+	h.undo = nil
+	h.redo = nil
+}
+
+// info returns a description of both stacks
+func (h *history) info() HistoryInfo {
+	info := HistoryInfo{
+		CanUndo: len(h.undo) > 0,
+		CanRedo: len(h.redo) > 0,
+		Undo:    make([]string, 0, len(h.undo)),
+		Redo:    make([]string, 0, len(h.redo)),
+	}
+	for i := len(h.undo) - 1; i >= 0; i-- {
+		info.Undo = append(info.Undo, h.undo[i].label)
+	}
+	for i := len(h.redo) - 1; i >= 0; i-- {
+		info.Redo = append(info.Redo, h.redo[i].label)
+	}
+	return info
+}
@@ -0,0 +1,245 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"storyboard_flow/internal/models"
+)
+
+// setDialogue edits the dialogue of a panel the way typing does
+func setDialogue(s *State, id, text string) {
+	s.UpdatePanel(id, func(p *models.Panel) { p.Dialogue = text })
+}
+
+// dialogue returns the dialogue of the first panel
+func dialogue(s *State) string {
+	return s.GetPanels()[0].Dialogue
+}
+
+func TestHistory(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps func(t *testing.T, s *State, id string)
+		text  string   // dialogue afterwards
+		undo  []string // undo labels, most recent first
+		redo  []string
+		dirty bool
+	}{
+		{
+			name:  "exec records a step",
+			steps: func(t *testing.T, s *State, id string) { setDialogue(s, id, "hi") },
+			text:  "hi",
+			undo:  []string{"Edit panel"},
+			dirty: true,
+		},
+		{
+			name: "undo reverts and moves the step to redo",
+			steps: func(t *testing.T, s *State, id string) {
+				setDialogue(s, id, "hi")
+				s.Undo()
+			},
+			text:  "",
+			redo:  []string{"Edit panel"},
+			dirty: true,
+		},
+		{
+			name: "redo applies again",
+			steps: func(t *testing.T, s *State, id string) {
+				setDialogue(s, id, "hi")
+				s.Undo()
+				s.Redo()
+			},
+			text:  "hi",
+			undo:  []string{"Edit panel"},
+			dirty: true,
+		},
+		{
+			name: "edits within the window coalesce",
+			steps: func(t *testing.T, s *State, id string) {
+				setDialogue(s, id, "h")
+				setDialogue(s, id, "hi")
+				setDialogue(s, id, "hi!")
+			},
+			text:  "hi!",
+			undo:  []string{"Edit panel"},
+			dirty: true,
+		},
+		{
+			name: "edits after the window do not coalesce",
+			steps: func(t *testing.T, s *State, id string) {
+				setDialogue(s, id, "h")
+				s.history.undo[len(s.history.undo)-1].at = time.Now().Add(-2 * coalesceWindow)
+				setDialogue(s, id, "hi")
+			},
+			text:  "hi",
+			undo:  []string{"Edit panel", "Edit panel"},
+			dirty: true,
+		},
+		{
+			name: "edits of different panels do not coalesce",
+			steps: func(t *testing.T, s *State, id string) {
+				second := s.AddPanel()
+				setDialogue(s, id, "hi")
+				setDialogue(s, second.ID, "there")
+			},
+			text:  "hi",
+			undo:  []string{"Edit panel", "Edit panel", "Add panel"},
+			dirty: true,
+		},
+		{
+			name: "a new edit clears redo",
+			steps: func(t *testing.T, s *State, id string) {
+				setDialogue(s, id, "hi")
+				s.AddPanel()
+				s.Undo()
+				setDialogue(s, id, "hello")
+			},
+			text:  "hello",
+			undo:  []string{"Edit panel"},
+			dirty: true,
+		},
+		{
+			name: "an undone step does not coalesce once redone",
+			steps: func(t *testing.T, s *State, id string) {
+				setDialogue(s, id, "hi")
+				s.Undo()
+				s.Redo()
+				setDialogue(s, id, "hello")
+			},
+			text:  "hello",
+			undo:  []string{"Edit panel", "Edit panel"},
+			dirty: true,
+		},
+		{
+			name:  "an update that changes nothing is not recorded",
+			steps: func(t *testing.T, s *State, id string) { setDialogue(s, id, "") },
+			text:  "",
+		},
+		{
+			name: "undo with an empty stack does nothing",
+			steps: func(t *testing.T, s *State, id string) {
+				if _, ok := s.Undo(); ok {
+					t.Error("undo succeeded without history")
+				}
+				if _, ok := s.Redo(); ok {
+					t.Error("redo succeeded without history")
+				}
+			},
+			text: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewState()
+			s.NewProject("history")
+			id := s.GetPanels()[0].ID
+			s.MarkClean()
+
+			tt.steps(t, s, id)
+
+			if got := dialogue(s); got != tt.text {
+				t.Errorf("dialogue = %q, want %q", got, tt.text)
+			}
+			info := s.GetHistory()
+			if !reflect.DeepEqual(info.Undo, nonNil(tt.undo)) {
+				t.Errorf("undo = %v, want %v", info.Undo, tt.undo)
+			}
+			if !reflect.DeepEqual(info.Redo, nonNil(tt.redo)) {
+				t.Errorf("redo = %v, want %v", info.Redo, tt.redo)
+			}
+			if info.CanUndo != (len(tt.undo) > 0) || info.CanRedo != (len(tt.redo) > 0) {
+				t.Errorf("can undo %v, can redo %v", info.CanUndo, info.CanRedo)
+			}
+			if dirty := s.Snapshot().Dirty; dirty != tt.dirty {
+				t.Errorf("dirty = %v, want %v", dirty, tt.dirty)
+			}
+		})
+	}
+}
+
+// nonNil returns labels, or an empty list for nil
+func nonNil(labels []string) []string {
+	if labels == nil {
+		return []string{}
+	}
+	return labels
+}
+
+func TestHistoryLimit(t *testing.T) {
+	s := NewState()
+	s.NewProject("limit")
+	for i := 0; i < historyLimit+10; i++ {
+		s.AddPanel()
+	}
+	if n := len(s.GetHistory().Undo); n != historyLimit {
+		t.Errorf("kept %d undo steps, want %d", n, historyLimit)
+	}
+}
+
+func TestUndoTracksDirtyAgainstSave(t *testing.T) {
+	s := NewState()
+	s.NewProject("dirty")
+	id := s.GetPanels()[0].ID
+	setDialogue(s, id, "saved")
+	s.MarkSaved(s.Snapshot().Revision)
+
+	if s.Snapshot().Dirty {
+		t.Fatal("project is dirty right after saving")
+	}
+	s.Undo()
+	if !s.Snapshot().Dirty {
+		t.Error("undoing past the save left the project clean")
+	}
+	s.Redo()
+	if dialogue(s) != "saved" {
+		t.Errorf("redo restored %q", dialogue(s))
+	}
+}
+
+func TestDeleteCharacterCascade(t *testing.T) {
+	s := NewState()
+	s.NewProject("cast")
+	ada := s.AddCharacter("Ada", "", "")
+	bob := s.AddCharacter("Bob", "", "")
+	first := s.GetPanels()[0].ID
+	second := s.AddPanel().ID
+	s.UpdatePanel(first, func(p *models.Panel) { p.CharacterIDs = []string{ada.ID, bob.ID} })
+	s.UpdatePanel(second, func(p *models.Panel) { p.CharacterIDs = []string{ada.ID} })
+	before := s.GetProject()
+	steps := len(s.GetHistory().Undo)
+
+	if !s.DeleteCharacter(ada.ID) {
+		t.Fatal("delete failed")
+	}
+	if chars := s.GetCharacters(); len(chars) != 1 || chars[0].ID != bob.ID {
+		t.Errorf("characters after delete = %v", chars)
+	}
+	panels := s.GetPanels()
+	if ids := panels[0].CharacterIDs; !reflect.DeepEqual(ids, []string{bob.ID}) {
+		t.Errorf("first panel characters = %v, want only Bob", ids)
+	}
+	if ids := panels[1].CharacterIDs; len(ids) != 0 {
+		t.Errorf("second panel characters = %v, want none", ids)
+	}
+	if n := len(s.GetHistory().Undo); n != steps+1 {
+		t.Errorf("delete took %d undo steps, want 1", n-steps)
+	}
+
+	s.Undo()
+	after := s.GetProject()
+	if !reflect.DeepEqual(after.Characters, before.Characters) {
+		t.Errorf("undo restored characters %v, want %v", after.Characters, before.Characters)
+	}
+	for i := range before.Panels {
+		if !reflect.DeepEqual(after.Panels[i].CharacterIDs, before.Panels[i].CharacterIDs) {
+			t.Errorf("panel %d characters = %v after undo, want %v", i, after.Panels[i].CharacterIDs, before.Panels[i].CharacterIDs)
+		}
+	}
+
+	if s.DeleteCharacter("missing") {
+		t.Error("deleting an unknown character succeeded")
+	}
+}
@@ -0,0 +1,271 @@
+package app
+
+import (
+	"math"
+	"strings"
+
+	"storyboard_flow/internal/models"
+	"storyboard_flow/internal/script"
+)
+
+// ImportResult summarizes a screenplay import
+type ImportResult struct {
+	Panels     int `json:"panels"`
+	Scenes     int `json:"scenes"`
+	Sequences  int `json:"sequences"`
+	Characters int `json:"characters"`
+}
+
+// scriptImport accumulates what a screenplay adds to a project
+type scriptImport struct {
+	source     string
+	project    *models.Project
+	panels     []models.Panel
+	scenes     []models.Scene
+	sequences  []models.Sequence
+	characters []models.Character
+	byName     map[string]string // upper-case character name -> ID
+	sceneID    string
+	sceneNum   string // script number of the current scene
+	sequenceID string
+	pending    []string // notes waiting for the next panel
+}
+
+// ImportScript appends the scenes, panels and characters of a parsed
+// screenplay to the current project as a single undo step.
+//
+// Every action line becomes a panel, every dialogue speech becomes a panel
+// carrying the speaker, and sections become sequences. Characters are matched
+// by name and created when missing. If the project only holds blank panels
+// (a fresh project), those are replaced.
+func (s *State) ImportScript(doc *script.Document, sourceFile string) (ImportResult, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.CurrentProject == nil || doc == nil {
+		return ImportResult{}, false
+	}
+	p := s.CurrentProject
+
+	imp := &scriptImport{
+		source:  sourceFile,
+		project: p,
+		byName:  make(map[string]string),
+	}
+	for _, char := range p.Characters {
+		imp.byName[strings.ToUpper(char.Name)] = char.ID
+	}
+	imp.build(doc.Elements)
+
+	if len(imp.panels) == 0 {
+		return ImportResult{}, false
+	}
+
+	// Commands are worked out against a copy, since where panels land depends
+	// on the ones removed and added before them
+	preview := copyProject(p)
+	var cmds compound
+
+	// A fresh project's placeholder panels make way for the script
+	if allBlank(p.Panels) {
+		for i := len(p.Panels) - 1; i >= 0; i-- {
+			cmds = append(cmds, &removePanel{index: i, panel: copyPanel(p.Panels[i])})
+		}
+	}
+
+	for i, char := range imp.characters {
+		cmds = append(cmds, &insertCharacter{index: len(p.Characters) + i, character: char})
+	}
+
+	cmds = append(cmds, &setScenes{
+		beforeScenes:    append([]models.Scene{}, p.Scenes...),
+		afterScenes:     append(append([]models.Scene{}, p.Scenes...), imp.scenes...),
+		beforeSequences: append([]models.Sequence{}, p.Sequences...),
+		afterSequences:  append(append([]models.Sequence{}, p.Sequences...), imp.sequences...),
+	})
+
+	cmds.apply(preview)
+	for _, panel := range imp.panels {
+		insert := &insertPanel{index: len(preview.Panels), panel: panel}
+		insert.apply(preview)
+		cmds = append(cmds, insert)
+	}
+
+	// Keep scenes contiguous in case the project already had unassigned panels
+	cmds = append(cmds, &permutePanels{before: panelIDs(preview.Panels), after: groupedPanelIDs(preview)})
+
+	s.exec("Import script", "", cmds)
+
+	return ImportResult{
+		Panels:     len(imp.panels),
+		Scenes:     len(imp.scenes),
+		Sequences:  len(imp.sequences),
+		Characters: len(imp.characters),
+	}, true
+}
+
+// build converts screenplay elements into panels, scenes and characters
+func (imp *scriptImport) build(elements []script.Element) {
+	speaker := ""
+	speakerName := ""
+	parenthetical := ""
+	// The last speech panel and its speaker, until dual dialogue names them
+	speech, speechName := -1, ""
+
+	for _, el := range elements {
+		switch el.Type {
+		case script.Section:
+			seq := models.NewSequence(el.Text, len(imp.project.Sequences)+len(imp.sequences))
+			imp.sequences = append(imp.sequences, *seq)
+			imp.sequenceID = seq.ID
+
+		case script.SceneHeading:
+			scene := models.NewScene(len(imp.project.Scenes)+len(imp.scenes), script.ParseSceneHeading(el.Text))
+			scene.Number = el.SceneNumber
+			scene.SequenceID = imp.sequenceID
+			imp.scenes = append(imp.scenes, *scene)
+			imp.sceneID = scene.ID
+			imp.sceneNum = el.SceneNumber
+
+		case script.Synopsis:
+			if n := len(imp.scenes); n > 0 {
+				imp.scenes[n-1].Notes = joinLines(imp.scenes[n-1].Notes, el.Text)
+			}
+
+		case script.Action:
+			speaker = ""
+			line := el.Line
+			for _, text := range strings.Split(el.Text, "\n") {
+				if text = strings.TrimSpace(text); text != "" {
+					panel := imp.newPanel(line, el.Revision)
+					panel.ActionNotes = joinLines(panel.ActionNotes, text)
+				}
+				line++
+			}
+
+		case script.Character:
+			speaker = imp.characterID(el.Text)
+			speakerName = el.Text
+			parenthetical = ""
+			if el.Dual && len(imp.panels) > 0 {
+				// Dual dialogue shares the previous speaker's panel
+				speaker = "dual:" + speaker
+			}
+
+		case script.Parenthetical:
+			parenthetical = el.Text
+
+		case script.Dialogue:
+			if strings.HasPrefix(speaker, "dual:") {
+				id := strings.TrimPrefix(speaker, "dual:")
+				panel := &imp.panels[len(imp.panels)-1]
+				// Name every speaker of a shared panel, the first one included
+				if speech == len(imp.panels)-1 {
+					panel.Dialogue = speechName + ": " + panel.Dialogue
+					speech = -1
+				}
+				panel.Dialogue = joinLines(panel.Dialogue, speakerName+": "+el.Text)
+				if !containsID(panel.CharacterIDs, id) {
+					panel.CharacterIDs = append(panel.CharacterIDs, id)
+				}
+				continue
+			}
+
+			panel := imp.newPanel(el.Line, el.Revision)
+			panel.Dialogue = el.Text
+			panel.Duration = speechDuration(el.Text)
+			speech, speechName = len(imp.panels)-1, speakerName
+			if parenthetical != "" {
+				panel.ActionNotes = joinLines(panel.ActionNotes, parenthetical)
+				parenthetical = ""
+			}
+			if speaker != "" {
+				panel.CharacterIDs = append(panel.CharacterIDs, speaker)
+			}
+
+		case script.Transition:
+			if n := len(imp.panels); n > 0 {
+				imp.panels[n-1].ActionNotes = joinLines(imp.panels[n-1].ActionNotes, el.Text)
+			}
+
+		case script.Note:
+			// Inline notes belong to the panel made from the same line
+			if n := len(imp.panels); n > 0 && imp.panels[n-1].Source.Line == el.Line {
+				imp.panels[n-1].ActionNotes = joinLines(imp.panels[n-1].ActionNotes, "[Note] "+el.Text)
+				continue
+			}
+			imp.pending = append(imp.pending, "[Note] "+el.Text)
+		}
+	}
+
+	// Notes after the last panel still belong somewhere
+	if n := len(imp.panels); n > 0 && len(imp.pending) > 0 {
+		for _, note := range imp.pending {
+			imp.panels[n-1].ActionNotes = joinLines(imp.panels[n-1].ActionNotes, note)
+		}
+	}
+}
+
+// newPanel appends a panel for the current scene and returns it.
+// The scene number and revision color are kept as panel metadata.
+func (imp *scriptImport) newPanel(line int, revision string) *models.Panel {
+	panel := models.NewPanel(0)
+	panel.SceneID = imp.sceneID
+	panel.Source = &models.ScriptRef{File: imp.source, Line: line}
+	if imp.sceneNum != "" || revision != "" {
+		panel.Metadata = map[string]string{}
+		if imp.sceneNum != "" {
+			panel.Metadata[models.MetaSceneNumber] = imp.sceneNum
+		}
+		if revision != "" {
+			panel.Metadata[models.MetaRevisionColor] = revision
+		}
+	}
+	for _, note := range imp.pending {
+		panel.ActionNotes = joinLines(panel.ActionNotes, note)
+	}
+	imp.pending = nil
+
+	imp.panels = append(imp.panels, *panel)
+	return &imp.panels[len(imp.panels)-1]
+}
+
+// characterID returns the ID of a named character, creating it if needed
+func (imp *scriptImport) characterID(name string) string {
+	key := strings.ToUpper(strings.TrimSpace(name))
+	if id, ok := imp.byName[key]; ok {
+		return id
+	}
+
+	char := models.NewCharacter(name, "")
+	imp.characters = append(imp.characters, *char)
+	imp.byName[key] = char.ID
+	return char.ID
+}
+
+// speechDuration estimates how long a line of dialogue takes to deliver,
+// at roughly 2.5 words per second, rounded to half seconds
+func speechDuration(text string) float64 {
+	secs := float64(len(strings.Fields(text))) / 2.5
+	secs = math.Ceil(secs*2) / 2
+	return math.Max(2, math.Min(secs, 12))
+}
+
+// allBlank reports whether none of the panels carry any content
+func allBlank(panels []models.Panel) bool {
+	for _, panel := range panels {
+		if panel.ImageData != "" || panel.ActionNotes != "" || panel.Dialogue != "" ||
+			len(panel.CharacterIDs) > 0 || panel.SceneID != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// joinLines appends line to text on a new line
+func joinLines(text, line string) string {
+	if text == "" {
+		return line
+	}
+	return text + "\n" + line
+}
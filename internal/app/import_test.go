@@ -0,0 +1,148 @@
+package app
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"storyboard_flow/internal/models"
+	"storyboard_flow/internal/script"
+)
+
+// buildImport runs build over elements for an empty project
+func buildImport(elements []script.Element) *scriptImport {
+	imp := &scriptImport{
+		source:  "test.fountain",
+		project: &models.Project{},
+		byName:  make(map[string]string),
+	}
+	imp.build(elements)
+	return imp
+}
+
+func TestImportNotes(t *testing.T) {
+	imp := buildImport([]script.Element{
+		{Type: script.Note, Text: "before", Line: 1},
+		{Type: script.SceneHeading, Text: "INT. HOUSE - DAY", Line: 2},
+		{Type: script.Action, Text: "He runs.", Line: 4},
+		{Type: script.Note, Text: "inline", Line: 4},
+		{Type: script.Note, Text: "between", Line: 5},
+		{Type: script.Action, Text: "She waits.", Line: 6},
+		{Type: script.Note, Text: "after", Line: 8},
+	})
+
+	want := []string{
+		"[Note] before\nHe runs.\n[Note] inline",
+		"[Note] between\nShe waits.\n[Note] after",
+	}
+	if len(imp.panels) != len(want) {
+		t.Fatalf("got %d panels, want %d", len(imp.panels), len(want))
+	}
+	for i, notes := range want {
+		if imp.panels[i].ActionNotes != notes {
+			t.Errorf("panel %d notes = %q, want %q", i, imp.panels[i].ActionNotes, notes)
+		}
+		if imp.panels[i].SceneID != imp.scenes[0].ID {
+			t.Errorf("panel %d is not in the scene", i)
+		}
+	}
+}
+
+func TestImportDualDialogue(t *testing.T) {
+	imp := buildImport([]script.Element{
+		{Type: script.Character, Text: "BRICK", Line: 1},
+		{Type: script.Dialogue, Text: "Screw retirement.", Line: 2},
+		{Type: script.Character, Text: "STEEL", Line: 4, Dual: true},
+		{Type: script.Dialogue, Text: "Screw retirement.", Line: 5},
+		{Type: script.Character, Text: "brick", Line: 7},
+		{Type: script.Dialogue, Text: "Again.", Line: 8},
+	})
+
+	if len(imp.characters) != 2 {
+		t.Fatalf("got %d characters, want 2", len(imp.characters))
+	}
+	brick, steel := imp.characters[0].ID, imp.characters[1].ID
+
+	if len(imp.panels) != 2 {
+		t.Fatalf("got %d panels, want 2", len(imp.panels))
+	}
+	shared := imp.panels[0]
+	if shared.Dialogue != "BRICK: Screw retirement.\nSTEEL: Screw retirement." {
+		t.Errorf("dual dialogue = %q", shared.Dialogue)
+	}
+	if strings.Join(shared.CharacterIDs, ",") != brick+","+steel {
+		t.Errorf("dual dialogue characters = %v, want both speakers", shared.CharacterIDs)
+	}
+	if ids := imp.panels[1].CharacterIDs; len(ids) != 1 || ids[0] != brick {
+		t.Errorf("speaker matched by name = %v, want %s", ids, brick)
+	}
+	if imp.panels[1].Dialogue != "Again." {
+		t.Errorf("single speech = %q, want it without a name", imp.panels[1].Dialogue)
+	}
+}
+
+func TestImportReplacesBlankPanels(t *testing.T) {
+	doc := &script.Document{Elements: []script.Element{
+		{Type: script.SceneHeading, Text: "EXT. PARK - DAY", Line: 1},
+		{Type: script.Action, Text: "Birds sing.", Line: 3},
+	}}
+
+	s := NewState()
+	s.NewProject("fresh")
+	if _, ok := s.ImportScript(doc, "test.fountain"); !ok {
+		t.Fatal("import failed")
+	}
+	if panels := s.GetPanels(); len(panels) != 1 || panels[0].ActionNotes != "Birds sing." {
+		t.Fatalf("blank panels were not replaced: %d panels", len(panels))
+	}
+
+	// Panels with content are kept alongside the script
+	s = NewState()
+	s.NewProject("drafted")
+	first := s.GetPanels()[0].ID
+	s.UpdatePanel(first, func(p *models.Panel) { p.Dialogue = "keep me" })
+	blank := len(s.GetPanels())
+	if _, ok := s.ImportScript(doc, "test.fountain"); !ok {
+		t.Fatal("import failed")
+	}
+	panels := s.GetPanels()
+	if len(panels) != blank+1 || !containsID(panelIDs(panels), first) {
+		t.Fatalf("existing panels were not kept: %d panels", len(panels))
+	}
+}
+
+func TestImportIsOneUndoStep(t *testing.T) {
+	doc := &script.Document{Elements: []script.Element{
+		{Type: script.SceneHeading, Text: "EXT. PARK - DAY", Line: 1},
+		{Type: script.Action, Text: "Birds sing.", Line: 3},
+		{Type: script.Character, Text: "ADA", Line: 5},
+		{Type: script.Dialogue, Text: "Lovely.", Line: 6},
+	}}
+
+	s := NewState()
+	s.NewProject("undo")
+	before := s.GetProject()
+	s.MarkClean()
+
+	if _, ok := s.ImportScript(doc, "test.fountain"); !ok {
+		t.Fatal("import failed")
+	}
+	if !s.Snapshot().Dirty {
+		t.Error("import left the project clean")
+	}
+	if undo := s.GetHistory().Undo; len(undo) != 1 || undo[0] != "Import script" {
+		t.Fatalf("undo stack = %v, want one import step", undo)
+	}
+
+	s.Undo()
+	after := s.GetProject()
+	if !reflect.DeepEqual(panelIDs(after.Panels), panelIDs(before.Panels)) ||
+		len(after.Characters) != 0 || len(after.Scenes) != 0 {
+		t.Errorf("undo left %d panels, %d characters, %d scenes", len(after.Panels), len(after.Characters), len(after.Scenes))
+	}
+
+	s.Redo()
+	if panels := s.GetPanels(); len(panels) != 2 || panels[1].Dialogue != "Lovely." {
+		t.Errorf("redo restored %d panels", len(panels))
+	}
+}
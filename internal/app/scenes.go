@@ -0,0 +1,368 @@
+package app
+
+import (
+	"storyboard_flow/internal/models"
+)
+
+// SceneSummary describes a scene and its place on the timeline
+type SceneSummary struct {
+	Scene    *models.Scene `json:"scene"` // nil for panels without a scene
+	Heading  string        `json:"heading"`
+	PanelIDs []string      `json:"panel_ids"`
+	Start    float64       `json:"start"`   // seconds from the start of the board
+	Runtime  float64       `json:"runtime"` // sum of panel play durations in seconds, as exported
+}
+
+// sceneEdit is a working copy of the scene structure handed to restructure
+type sceneEdit struct {
+	scenes    []models.Scene
+	sequences []models.Sequence
+	sceneOf   map[string]string // panel ID -> scene ID
+}
+
+// indexOf returns the position of a scene in edit.scenes, or -1
+func (e *sceneEdit) indexOf(sceneID string) int {
+	for i := range e.scenes {
+		if e.scenes[i].ID == sceneID {
+			return i
+		}
+	}
+	return -1
+}
+
+// sequenceOf returns the sequence a scene plays in, or "" for scenes outside
+// any existing sequence, which play after all sequences
+func (e *sceneEdit) sequenceOf(scene models.Scene) string {
+	if hasSequence(e.sequences, scene.SequenceID) {
+		return scene.SequenceID
+	}
+	return ""
+}
+
+// restructure runs mutate on a copy of the scene structure and records the
+// result as one undo step: scene list changes, panel reassignments and the
+// panel reordering needed to keep every scene's panels contiguous.
+// mutate returns false to abort without changes. Callers must hold the write lock.
+func (s *State) restructure(label string, mutate func(e *sceneEdit) bool) bool {
+	p := s.CurrentProject
+
+	edit := &sceneEdit{
+		scenes:    p.SortedScenes(),
+		sequences: append([]models.Sequence{}, p.Sequences...),
+		sceneOf:   make(map[string]string, len(p.Panels)),
+	}
+	for _, panel := range p.Panels {
+		edit.sceneOf[panel.ID] = panel.SceneID
+	}
+
+	if !mutate(edit) {
+		return false
+	}
+
+	// Normalize orders so they match positions
+	for i := range edit.sequences {
+		edit.sequences[i].Order = i
+	}
+	for i := range edit.scenes {
+		edit.scenes[i].Order = i
+	}
+
+	var cmds compound
+	for i, panel := range p.Panels {
+		if sceneID := edit.sceneOf[panel.ID]; sceneID != panel.SceneID {
+			after := copyPanel(panel)
+			after.SceneID = sceneID
+			cmds = append(cmds, &setPanel{index: i, before: copyPanel(panel), after: after})
+		}
+	}
+
+	cmds = append(cmds, &setScenes{
+		beforeScenes:    append([]models.Scene{}, p.Scenes...),
+		afterScenes:     edit.scenes,
+		beforeSequences: append([]models.Sequence{}, p.Sequences...),
+		afterSequences:  edit.sequences,
+	})
+
+	// Work out the grouped panel order against the edited structure
+	preview := models.Project{Scenes: edit.scenes, Sequences: edit.sequences}
+	for _, panel := range p.Panels {
+		panel.SceneID = edit.sceneOf[panel.ID]
+		preview.Panels = append(preview.Panels, panel)
+	}
+	cmds = append(cmds, &permutePanels{before: panelIDs(p.Panels), after: groupedPanelIDs(&preview)})
+
+	s.exec(label, "", cmds)
+	return true
+}
+
+// AddSequence appends a new sequence to the current project
+func (s *State) AddSequence(name string) *models.Sequence {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.CurrentProject == nil {
+		return nil
+	}
+
+	var seq *models.Sequence
+	s.restructure("Add sequence", func(e *sceneEdit) bool {
+		seq = models.NewSequence(name, len(e.sequences))
+		e.sequences = append(e.sequences, *seq)
+		return true
+	})
+
+	return seq
+}
+
+// AddScene appends a new scene, optionally inside a sequence
+func (s *State) AddScene(heading models.SceneHeading, sequenceID string) *models.Scene {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.CurrentProject == nil {
+		return nil
+	}
+
+	var scene *models.Scene
+	ok := s.restructure("Add scene", func(e *sceneEdit) bool {
+		if sequenceID != "" && !hasSequence(e.sequences, sequenceID) {
+			return false
+		}
+		scene = models.NewScene(len(e.scenes), heading)
+		scene.SequenceID = sequenceID
+		e.scenes = append(e.scenes, *scene)
+		return true
+	})
+	if !ok {
+		return nil
+	}
+
+	return scene
+}
+
+// UpdateScene updates a scene's heading, number, notes or sequence
+func (s *State) UpdateScene(sceneID string, updater func(*models.Scene)) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.CurrentProject == nil {
+		return false
+	}
+
+	return s.restructure("Edit scene", func(e *sceneEdit) bool {
+		i := e.indexOf(sceneID)
+		if i == -1 {
+			return false
+		}
+		updater(&e.scenes[i])
+		e.scenes[i].ID = sceneID
+		if e.scenes[i].SequenceID != "" && !hasSequence(e.sequences, e.scenes[i].SequenceID) {
+			return false
+		}
+		return true
+	})
+}
+
+// AssignPanelsToScene moves panels into a scene. An empty sceneID unassigns them.
+// Panels are placed after the scene's existing panels, keeping their relative order.
+func (s *State) AssignPanelsToScene(sceneID string, panelIDs []string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.CurrentProject == nil {
+		return false
+	}
+
+	return s.restructure("Assign panels to scene", func(e *sceneEdit) bool {
+		if sceneID != "" && e.indexOf(sceneID) == -1 {
+			return false
+		}
+		for _, id := range panelIDs {
+			if _, ok := e.sceneOf[id]; !ok {
+				return false
+			}
+			e.sceneOf[id] = sceneID
+		}
+		return true
+	})
+}
+
+// ReorderScene moves a scene (and its panels) to a new index in playback order.
+// Scenes play grouped by sequence, so the index must be held by a scene of the
+// same sequence; UpdateScene moves a scene to another sequence.
+func (s *State) ReorderScene(sceneID string, newIndex int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.CurrentProject == nil {
+		return false
+	}
+
+	return s.restructure("Move scene", func(e *sceneEdit) bool {
+		i := e.indexOf(sceneID)
+		if i == -1 || newIndex < 0 || newIndex >= len(e.scenes) {
+			return false
+		}
+		if e.sequenceOf(e.scenes[newIndex]) != e.sequenceOf(e.scenes[i]) {
+			return false
+		}
+		moveItem(e.scenes, i, newIndex)
+		return true
+	})
+}
+
+// MergeScenes moves all panels of source into target and removes source
+func (s *State) MergeScenes(targetID, sourceID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.CurrentProject == nil || targetID == sourceID {
+		return false
+	}
+
+	// Source panels follow the target's own panels
+	var moved []string
+	for _, group := range s.CurrentProject.PanelsByScene() {
+		if group.Scene != nil && group.Scene.ID == sourceID {
+			moved = panelIDs(group.Panels)
+		}
+	}
+
+	return s.restructure("Merge scenes", func(e *sceneEdit) bool {
+		if e.indexOf(targetID) == -1 {
+			return false
+		}
+		i := e.indexOf(sourceID)
+		if i == -1 {
+			return false
+		}
+		for _, id := range moved {
+			e.sceneOf[id] = targetID
+		}
+		e.scenes = append(e.scenes[:i], e.scenes[i+1:]...)
+		return true
+	})
+}
+
+// SplitScene splits a scene in two at the given panel. The panel and every
+// panel after it in the scene move to a new scene inserted directly after,
+// which copies the original heading.
+func (s *State) SplitScene(sceneID, atPanelID string) *models.Scene {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.CurrentProject == nil {
+		return nil
+	}
+
+	var tail []string
+	for _, group := range s.CurrentProject.PanelsByScene() {
+		if group.Scene == nil || group.Scene.ID != sceneID {
+			continue
+		}
+		for i, panel := range group.Panels {
+			// Splitting at the first panel would leave an empty scene
+			if panel.ID == atPanelID && i > 0 {
+				tail = panelIDs(group.Panels[i:])
+			}
+		}
+	}
+	if len(tail) == 0 {
+		return nil
+	}
+
+	var scene *models.Scene
+	s.restructure("Split scene", func(e *sceneEdit) bool {
+		i := e.indexOf(sceneID)
+		orig := e.scenes[i]
+		scene = models.NewScene(i+1, orig.Heading)
+		scene.SequenceID = orig.SequenceID
+
+		e.scenes = append(e.scenes, models.Scene{})
+		copy(e.scenes[i+2:], e.scenes[i+1:])
+		e.scenes[i+1] = *scene
+
+		for _, id := range tail {
+			e.sceneOf[id] = scene.ID
+		}
+		return true
+	})
+
+	return scene
+}
+
+// GetScenes returns all scenes in playback order (read-only copy)
+func (s *State) GetScenes() []models.Scene {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.CurrentProject == nil {
+		return []models.Scene{}
+	}
+
+	return s.CurrentProject.SortedScenes()
+}
+
+// GetSequences returns all sequences (read-only copy)
+func (s *State) GetSequences() []models.Sequence {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.CurrentProject == nil {
+		return []models.Sequence{}
+	}
+
+	sequences := make([]models.Sequence, len(s.CurrentProject.Sequences))
+	copy(sequences, s.CurrentProject.Sequences)
+	return sequences
+}
+
+// GetSceneSummaries returns the timeline runtime of every scene in playback order.
+// Panels without a scene are reported last under a nil Scene.
+func (s *State) GetSceneSummaries() []SceneSummary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	summaries := []SceneSummary{}
+	if s.CurrentProject == nil {
+		return summaries
+	}
+
+	start := 0.0
+	for _, group := range s.CurrentProject.PanelsByScene() {
+		summary := SceneSummary{
+			Scene:    group.Scene,
+			PanelIDs: panelIDs(group.Panels),
+			Start:    start,
+		}
+		if group.Scene != nil {
+			summary.Heading = group.Scene.Heading.String()
+		}
+		for _, panel := range group.Panels {
+			summary.Runtime += panel.PlayDuration()
+		}
+		start += summary.Runtime
+		summaries = append(summaries, summary)
+	}
+
+	return summaries
+}
+
+// groupedPanelIDs returns panel IDs in scene-grouped playback order
+func groupedPanelIDs(p *models.Project) []string {
+	var ids []string
+	for _, group := range p.PanelsByScene() {
+		ids = append(ids, panelIDs(group.Panels)...)
+	}
+	return ids
+}
+
+// hasSequence reports whether sequences contains a sequence with the given ID
+func hasSequence(sequences []models.Sequence, id string) bool {
+	for _, seq := range sequences {
+		if seq.ID == id {
+			return true
+		}
+	}
+	return false
+}
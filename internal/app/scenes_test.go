@@ -0,0 +1,178 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	"storyboard_flow/internal/models"
+)
+
+// newSceneState returns a state whose six panels form three scenes of two
+// panels each, and the panel and scene IDs in board order
+func newSceneState(t *testing.T) (*State, []string, []string) {
+	t.Helper()
+	s := NewState()
+	s.NewProject("scenes")
+	ids := panelIDs(s.GetPanels())
+	if len(ids) != 6 {
+		t.Fatalf("new project has %d panels, want 6", len(ids))
+	}
+
+	var scenes []string
+	for i, location := range []string{"KITCHEN", "GARDEN", "STREET"} {
+		scene := s.AddScene(models.SceneHeading{Setting: "INT", Location: location}, "")
+		if scene == nil || !s.AssignPanelsToScene(scene.ID, ids[2*i:2*i+2]) {
+			t.Fatal("failed to set up scenes")
+		}
+		scenes = append(scenes, scene.ID)
+	}
+	return s, ids, scenes
+}
+
+// sceneOf returns the scene of every panel in board order
+func sceneOf(s *State) map[string]string {
+	scenes := make(map[string]string)
+	for _, panel := range s.GetPanels() {
+		scenes[panel.ID] = panel.SceneID
+	}
+	return scenes
+}
+
+func TestReorderPanelAcrossScenes(t *testing.T) {
+	tests := []struct {
+		name  string
+		panel int // index into the panel IDs
+		to    int
+		order []int
+		scene int // scene the panel ends up in
+	}{
+		{"next to its own scene it stays", 0, 1, []int{1, 0, 2, 3, 4, 5}, 0},
+		{"at a boundary it joins the following scene", 0, 3, []int{1, 2, 3, 0, 4, 5}, 2},
+		{"to the first position of its own scene", 3, 2, []int{0, 1, 3, 2, 4, 5}, 1},
+		{"inside a scene it joins that scene", 0, 4, []int{1, 2, 3, 4, 0, 5}, 2},
+		{"to the start it joins the first scene", 5, 0, []int{5, 0, 1, 2, 3, 4}, 0},
+		{"to the end it joins the last scene", 1, 5, []int{0, 2, 3, 4, 5, 1}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, ids, scenes := newSceneState(t)
+			if !s.ReorderPanel(ids[tt.panel], tt.to) {
+				t.Fatal("reorder failed")
+			}
+
+			var want []string
+			for _, i := range tt.order {
+				want = append(want, ids[i])
+			}
+			if got := panelIDs(s.GetPanels()); !reflect.DeepEqual(got, want) {
+				t.Errorf("order = %v, want %v", got, want)
+			}
+			if got := sceneOf(s)[ids[tt.panel]]; got != scenes[tt.scene] {
+				t.Errorf("panel is in scene %s, want %s", got, scenes[tt.scene])
+			}
+
+			// Every scene is still one contiguous block
+			seen := make(map[string]bool)
+			last := ""
+			for _, panel := range s.GetPanels() {
+				if panel.SceneID != last && seen[panel.SceneID] {
+					t.Errorf("scene %s is split", panel.SceneID)
+				}
+				seen[panel.SceneID] = true
+				last = panel.SceneID
+			}
+		})
+	}
+}
+
+func TestReorderPanelWithinSceneKeepsScene(t *testing.T) {
+	s, ids, scenes := newSceneState(t)
+	if !s.ReorderPanel(ids[2], 3) {
+		t.Fatal("reorder failed")
+	}
+	if got := sceneOf(s)[ids[2]]; got != scenes[1] {
+		t.Errorf("panel moved to the end of its scene changed scene to %s", got)
+	}
+}
+
+func TestReorderSceneStaysInSequence(t *testing.T) {
+	s, _, scenes := newSceneState(t)
+	first := s.AddSequence("Act 1")
+	second := s.AddSequence("Act 2")
+	s.UpdateScene(scenes[0], func(sc *models.Scene) { sc.SequenceID = first.ID })
+	s.UpdateScene(scenes[1], func(sc *models.Scene) { sc.SequenceID = second.ID })
+	s.UpdateScene(scenes[2], func(sc *models.Scene) { sc.SequenceID = second.ID })
+	steps := len(s.GetHistory().Undo)
+
+	if s.ReorderScene(scenes[0], 2) {
+		t.Error("moving a scene into another sequence succeeded")
+	}
+	if s.ReorderScene(scenes[2], 0) {
+		t.Error("moving a scene into another sequence succeeded")
+	}
+	if n := len(s.GetHistory().Undo); n != steps {
+		t.Errorf("rejected moves recorded %d undo steps", n-steps)
+	}
+
+	if !s.ReorderScene(scenes[2], 1) {
+		t.Fatal("moving a scene within its sequence failed")
+	}
+	var got []string
+	for _, scene := range s.GetScenes() {
+		got = append(got, scene.ID)
+	}
+	if want := []string{scenes[0], scenes[2], scenes[1]}; !reflect.DeepEqual(got, want) {
+		t.Errorf("scene order = %v, want %v", got, want)
+	}
+
+	if s.ReorderScene(scenes[0], -1) || s.ReorderScene(scenes[0], 3) || s.ReorderScene("missing", 0) {
+		t.Error("an invalid scene move succeeded")
+	}
+}
+
+func TestSceneSummariesUseDefaultDuration(t *testing.T) {
+	s, ids, scenes := newSceneState(t)
+	s.UpdatePanel(ids[0], func(p *models.Panel) { p.Duration = 0 })
+	s.UpdatePanel(ids[1], func(p *models.Panel) { p.Duration = 1.5 })
+
+	summaries := s.GetSceneSummaries()
+	if len(summaries) != 3 {
+		t.Fatalf("got %d summaries, want 3", len(summaries))
+	}
+	if summaries[0].Scene.ID != scenes[0] || summaries[0].Runtime != models.DefaultDuration+1.5 {
+		t.Errorf("first scene runtime = %v, want %v", summaries[0].Runtime, models.DefaultDuration+1.5)
+	}
+	if summaries[1].Start != summaries[0].Runtime {
+		t.Errorf("second scene starts at %v, want %v", summaries[1].Start, summaries[0].Runtime)
+	}
+}
+
+func TestMergeAndSplitScenes(t *testing.T) {
+	s, ids, scenes := newSceneState(t)
+
+	if !s.MergeScenes(scenes[0], scenes[1]) {
+		t.Fatal("merge failed")
+	}
+	if n := len(s.GetScenes()); n != 2 {
+		t.Errorf("%d scenes after merge, want 2", n)
+	}
+	for _, i := range []int{0, 1, 2, 3} {
+		if got := sceneOf(s)[ids[i]]; got != scenes[0] {
+			t.Errorf("panel %d is in %s after merge, want %s", i, got, scenes[0])
+		}
+	}
+
+	split := s.SplitScene(scenes[0], ids[2])
+	if split == nil {
+		t.Fatal("split failed")
+	}
+	for i, want := range []string{scenes[0], scenes[0], split.ID, split.ID, scenes[2], scenes[2]} {
+		if got := sceneOf(s)[ids[i]]; got != want {
+			t.Errorf("panel %d is in %s after split, want %s", i, got, want)
+		}
+	}
+	if s.SplitScene(scenes[0], ids[0]) != nil {
+		t.Error("splitting at the first panel left an empty scene")
+	}
+}
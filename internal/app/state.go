@@ -1,6 +1,8 @@
 package app
 
 import (
+	"math"
+	"reflect"
 	"sync"
 	"time"
 
@@ -13,6 +15,12 @@ type State struct {
 	CurrentProject *models.Project
 	ProjectPath    string
 	IsDirty        bool // true if project has unsaved changes
+	history        history
+
+	subscribers    map[int]func(Event)
+	nextSubscriber int
+	revision       int // counts changes, so autosave can tell what it has written
+	baseRevision   int // revision when the project was created or opened
 }
 
 // NewState creates a new application state
@@ -31,7 +39,90 @@ func (s *State) NewProject(name string) {
 
 	s.CurrentProject = models.NewProject(name)
 	s.ProjectPath = ""
-	s.IsDirty = true
+	s.history.reset()
+	s.setDirty(true)
+	s.changed("New project", &changeSet{loaded: true})
+	s.baseRevision = s.revision
+}
+
+// changed counts a change and publishes the events describing it, followed
+// by EventChanged with label. Callers must hold the write lock.
+func (s *State) changed(label string, cs *changeSet) {
+	s.revision++
+	s.publish(cs.events(s.CurrentProject)...)
+	s.publish(Event{Type: EventChanged, Label: label})
+}
+
+// exec applies a command to the current project and records it for undo.
+// Commands sharing a non-empty key within the coalescing window merge into
+// one undo step. Callers must hold the write lock.
+func (s *State) exec(label, key string, cmd command) {
+	cmd.apply(s.CurrentProject)
+	s.history.push(label, key, cmd)
+	s.touch()
+
+	var cs changeSet
+	cmd.record(&cs, false)
+	s.changed(label, &cs)
+}
+
+// touch marks the project as modified. Callers must hold the write lock.
+func (s *State) touch() {
+	s.CurrentProject.ModifiedAt = time.Now()
+	s.setDirty(true)
+}
+
+// Undo reverts the most recent change and returns its label
+func (s *State) Undo() (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.CurrentProject == nil {
+		return "", false
+	}
+
+	entry, ok := s.history.popUndo()
+	if !ok {
+		return "", false
+	}
+
+	entry.cmd.revert(s.CurrentProject)
+	s.touch()
+
+	var cs changeSet
+	entry.cmd.record(&cs, true)
+	s.changed("Undo "+entry.label, &cs)
+	return entry.label, true
+}
+
+// Redo re-applies the most recently undone change and returns its label
+func (s *State) Redo() (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.CurrentProject == nil {
+		return "", false
+	}
+
+	entry, ok := s.history.popRedo()
+	if !ok {
+		return "", false
+	}
+
+	entry.cmd.apply(s.CurrentProject)
+	s.touch()
+
+	var cs changeSet
+	entry.cmd.record(&cs, false)
+	s.changed("Redo "+entry.label, &cs)
+	return entry.label, true
+}
+
+// GetHistory describes the undo/redo stacks
+func (s *State) GetHistory() HistoryInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.history.info()
 }
 
 // AddPanel adds a new panel to the current project
@@ -45,9 +136,7 @@ func (s *State) AddPanel() *models.Panel {
 
 	order := len(s.CurrentProject.Panels)
 	panel := models.NewPanel(order)
-	s.CurrentProject.Panels = append(s.CurrentProject.Panels, *panel)
-	s.CurrentProject.ModifiedAt = time.Now()
-	s.IsDirty = true
+	s.exec("Add panel", "", &insertPanel{index: order, panel: *panel})
 
 	return panel
 }
@@ -73,8 +162,17 @@ func (s *State) DuplicatePanel(panelID string) *models.Panel {
 		return nil
 	}
 
-	// Create a new panel and copy fields (use NewPanel to get a valid ID)
-	newPanel := models.NewPanel(len(s.CurrentProject.Panels))
+	newPanel := duplicateOf(*src, len(s.CurrentProject.Panels))
+	s.exec("Duplicate panel", "", &insertPanel{index: newPanel.Order, panel: newPanel})
+
+	return &newPanel
+}
+
+// duplicateOf returns a copy of src with a new ID, numbered order. Only the
+// drawing, notes, shot fields, duration and characters are copied.
+func duplicateOf(src models.Panel, order int) models.Panel {
+	// Use NewPanel to get a valid ID
+	newPanel := models.NewPanel(order)
 	newPanel.ImageData = src.ImageData
 	newPanel.ActionNotes = src.ActionNotes
 	newPanel.Dialogue = src.Dialogue
@@ -87,15 +185,169 @@ func (s *State) DuplicatePanel(panelID string) *models.Panel {
 		newPanel.CharacterIDs = make([]string, len(src.CharacterIDs))
 		copy(newPanel.CharacterIDs, src.CharacterIDs)
 	}
+	return *newPanel
+}
 
-	s.CurrentProject.Panels = append(s.CurrentProject.Panels, *newPanel)
-	s.CurrentProject.ModifiedAt = time.Now()
-	s.IsDirty = true
+// indexOfPanel returns the position of a panel, or -1. Callers must hold the
+// lock.
+func (s *State) indexOfPanel(panelID string) int {
+	if s.CurrentProject == nil {
+		return -1
+	}
+	for i, panel := range s.CurrentProject.Panels {
+		if panel.ID == panelID {
+			return i
+		}
+	}
+	return -1
+}
+
+// InsertPanelBefore adds a new panel in front of an existing one
+func (s *State) InsertPanelBefore(panelID string) *models.Panel {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.insertPanelNextTo(panelID, 0)
+}
+
+// InsertPanelAfter adds a new panel right after an existing one
+func (s *State) InsertPanelAfter(panelID string) *models.Panel {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.insertPanelNextTo(panelID, 1)
+}
 
-	return newPanel
+// insertPanelNextTo inserts a new panel at the position of panelID plus
+// offset. The new panel joins the scene of panelID so scenes stay
+// contiguous; later panels are renumbered as by DeletePanel. Callers must
+// hold the write lock.
+func (s *State) insertPanelNextTo(panelID string, offset int) *models.Panel {
+	i := s.indexOfPanel(panelID)
+	if i == -1 {
+		return nil
+	}
+
+	panel := models.NewPanel(i + offset)
+	panel.SceneID = s.CurrentProject.Panels[i].SceneID
+	s.exec("Insert panel", "", &insertPanel{index: panel.Order, panel: *panel})
+
+	return panel
 }
 
-// UpdatePanel updates an existing panel
+// DuplicatePanelInPlace inserts a copy of a panel right after it, in the same
+// scene
+func (s *State) DuplicatePanelInPlace(panelID string) *models.Panel {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOfPanel(panelID)
+	if i == -1 {
+		return nil
+	}
+
+	src := s.CurrentProject.Panels[i]
+	newPanel := duplicateOf(src, i+1)
+	newPanel.SceneID = src.SceneID
+	s.exec("Duplicate panel", "", &insertPanel{index: newPanel.Order, panel: newPanel})
+
+	return &newPanel
+}
+
+// MaxSplitParts is the most panels SplitPanel divides a panel into
+const MaxSplitParts = 100
+
+// SplitPanel divides a panel into parts panels of equal duration, between 2
+// and MaxSplitParts. The panel's play duration, which is DefaultDuration if
+// it has none, is split in whole milliseconds, the first parts taking one
+// more millisecond each until the remainder is used up, so the parts always
+// add up to the original; a panel too short to give every part a millisecond
+// is not split. The panel itself becomes the first part; the others copy its
+// drawing, shot fields, framing and transition and are inserted after it. A
+// fade to black plays at the end, so only the last part keeps it. Audio clips
+// move to the part they start in. It returns all the parts in order.
+func (s *State) SplitPanel(panelID string, parts int) []models.Panel {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOfPanel(panelID)
+	if i == -1 || parts < 2 || parts > MaxSplitParts {
+		return nil
+	}
+
+	src := s.CurrentProject.Panels[i]
+	total := int64(math.Round(src.PlayDuration() * 1000))
+	if total < int64(parts) {
+		return nil
+	}
+	starts := make([]int64, parts) // in milliseconds
+	lengths := make([]int64, parts)
+
+	children := make([]models.Panel, parts)
+	var start int64
+	for k := range children {
+		if k == 0 {
+			children[k] = copyPanel(src)
+			children[k].AudioClips = nil
+		} else {
+			children[k] = duplicateOf(src, i+k)
+			children[k].SceneID = src.SceneID
+			children[k].Fit = src.Fit
+			if src.Focus != nil {
+				focus := *src.Focus
+				children[k].Focus = &focus
+			}
+			if src.Motion != nil {
+				motion := *src.Motion
+				children[k].Motion = &motion
+			}
+			if src.Transition != nil {
+				transition := *src.Transition
+				children[k].Transition = &transition
+			}
+		}
+		if t := children[k].Transition; t != nil && t.Type == models.TransitionFadeToBlack && k < parts-1 {
+			children[k].Transition = nil
+		}
+
+		lengths[k] = total / int64(parts)
+		if int64(k) < total%int64(parts) {
+			lengths[k]++
+		}
+		starts[k] = start
+		start += lengths[k]
+		children[k].Duration = float64(lengths[k]) / 1000
+	}
+
+	for _, clip := range src.AudioClips {
+		offset := int64(math.Round(clip.Offset * 1000))
+		k := 0
+		for j := 1; j < parts; j++ {
+			if lengths[j] > 0 && starts[j] <= offset {
+				k = j
+			}
+		}
+		if k > 0 {
+			clip.Offset = float64(offset-starts[k]) / 1000
+		}
+		children[k].AudioClips = append(children[k].AudioClips, clip)
+	}
+
+	cmds := compound{&setPanel{index: i, before: copyPanel(src), after: children[0]}}
+	for k := 1; k < parts; k++ {
+		cmds = append(cmds, &insertPanel{index: i + k, panel: children[k]})
+	}
+	s.exec("Split panel", "", cmds)
+
+	result := make([]models.Panel, parts)
+	for k, child := range children {
+		result[k] = copyPanel(child)
+	}
+	return result
+}
+
+// UpdatePanel updates an existing panel.
+// The updater works on a copy; consecutive updates of the same panel are
+// coalesced into a single undo step, and updates that change nothing are
+// not recorded.
 func (s *State) UpdatePanel(panelID string, updater func(*models.Panel)) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -106,9 +358,13 @@ func (s *State) UpdatePanel(panelID string, updater func(*models.Panel)) bool {
 
 	for i := range s.CurrentProject.Panels {
 		if s.CurrentProject.Panels[i].ID == panelID {
-			updater(&s.CurrentProject.Panels[i])
-			s.CurrentProject.ModifiedAt = time.Now()
-			s.IsDirty = true
+			before := copyPanel(s.CurrentProject.Panels[i])
+			after := copyPanel(before)
+			updater(&after)
+			// An update that changes nothing is not worth an undo step
+			if !reflect.DeepEqual(before, after) {
+				s.exec("Edit panel", "update:"+panelID, &setPanel{index: i, before: before, after: after})
+			}
 			return true
 		}
 	}
@@ -116,6 +372,38 @@ func (s *State) UpdatePanel(panelID string, updater func(*models.Panel)) bool {
 	return false
 }
 
+// PatchPanel validates patch and applies it to a panel as one edit, coalesced
+// like UpdatePanel. It returns the updated panel, or the field errors and
+// leaves the panel unchanged. Both are nil if the panel does not exist.
+func (s *State) PatchPanel(panelID string, patch models.PanelPatch) (*models.Panel, models.FieldErrors) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.CurrentProject == nil {
+		return nil, nil
+	}
+
+	for i := range s.CurrentProject.Panels {
+		if s.CurrentProject.Panels[i].ID == panelID {
+			// Character IDs are checked under the same lock that applies them
+			if errs := patch.Validate(s.CurrentProject); len(errs) > 0 {
+				return nil, errs
+			}
+			before := copyPanel(s.CurrentProject.Panels[i])
+			after := copyPanel(before)
+			patch.Apply(&after)
+			if !reflect.DeepEqual(before, after) {
+				s.exec("Edit panel", "update:"+panelID, &setPanel{index: i, before: before, after: after})
+			}
+
+			result := copyPanel(after)
+			return &result, nil
+		}
+	}
+
+	return nil, nil
+}
+
 // DeletePanel removes a panel from the current project
 func (s *State) DeletePanel(panelID string) bool {
 	s.mu.Lock()
@@ -127,19 +415,8 @@ func (s *State) DeletePanel(panelID string) bool {
 
 	for i, panel := range s.CurrentProject.Panels {
 		if panel.ID == panelID {
-			// Remove panel
-			s.CurrentProject.Panels = append(
-				s.CurrentProject.Panels[:i],
-				s.CurrentProject.Panels[i+1:]...,
-			)
-
-			// Reorder remaining panels
-			for j := i; j < len(s.CurrentProject.Panels); j++ {
-				s.CurrentProject.Panels[j].Order = j
-			}
-
-			s.CurrentProject.ModifiedAt = time.Now()
-			s.IsDirty = true
+			// Remove panel; remaining panels are renumbered
+			s.exec("Delete panel", "", &removePanel{index: i, panel: copyPanel(panel)})
 			return true
 		}
 	}
@@ -147,7 +424,7 @@ func (s *State) DeletePanel(panelID string) bool {
 	return false
 }
 
-// GetPanels returns all panels (read-only copy)
+// GetPanels returns a deep copy of all panels
 func (s *State) GetPanels() []models.Panel {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -156,9 +433,11 @@ func (s *State) GetPanels() []models.Panel {
 		return []models.Panel{}
 	}
 
-	// Return a copy to prevent external modification
+	// Copy to prevent external modification
 	panels := make([]models.Panel, len(s.CurrentProject.Panels))
-	copy(panels, s.CurrentProject.Panels)
+	for i, panel := range s.CurrentProject.Panels {
+		panels[i] = copyPanel(panel)
+	}
 	return panels
 }
 
@@ -173,9 +452,7 @@ func (s *State) AddCharacter(name, description, imagePath string) *models.Charac
 
 	character := models.NewCharacter(name, description)
 	character.ImagePath = imagePath
-	s.CurrentProject.Characters = append(s.CurrentProject.Characters, *character)
-	s.CurrentProject.ModifiedAt = time.Now()
-	s.IsDirty = true
+	s.exec("Add character", "", &insertCharacter{index: len(s.CurrentProject.Characters), character: *character})
 
 	return character
 }
@@ -194,7 +471,9 @@ func (s *State) GetCharacters() []models.Character {
 	return characters
 }
 
-// GetProject returns a copy of the current project (thread-safe)
+// GetProject returns a deep copy of the current project, or nil. The copy
+// shares no slices, maps or pointers with the state, so callers may read or
+// change it without holding any lock.
 func (s *State) GetProject() *models.Project {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -202,9 +481,35 @@ func (s *State) GetProject() *models.Project {
 	if s.CurrentProject == nil {
 		return nil
 	}
+	return copyProject(s.CurrentProject)
+}
 
-	// Return a pointer to the project (caller should not modify)
-	return s.CurrentProject
+// Snapshot is a consistent view of the state at one revision, for work such
+// as saving and exporting that runs while editing continues
+type Snapshot struct {
+	Project  *models.Project // deep copy; nil when no project is loaded
+	Path     string
+	Revision int
+	Dirty    bool // has unsaved changes
+	Edited   bool // changed since it was created or opened
+}
+
+// Snapshot returns a deep copy of the project together with its path and
+// revision, all taken under one lock
+func (s *State) Snapshot() Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snap := Snapshot{
+		Path:     s.ProjectPath,
+		Revision: s.revision,
+		Dirty:    s.IsDirty,
+		Edited:   s.revision != s.baseRevision,
+	}
+	if s.CurrentProject != nil {
+		snap.Project = copyProject(s.CurrentProject)
+	}
+	return snap
 }
 
 // GetProjectPath returns the current project path
@@ -227,17 +532,40 @@ func (s *State) SetProject(project *models.Project, path string) {
 	defer s.mu.Unlock()
 	s.CurrentProject = project
 	s.ProjectPath = path
-	s.IsDirty = false
+	s.history.reset()
+	s.setDirty(false)
+	s.changed("Open project", &changeSet{loaded: true})
+	s.baseRevision = s.revision
 }
 
 // MarkClean marks the project as saved
 func (s *State) MarkClean() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.IsDirty = false
+	s.setDirty(false)
+}
+
+// MarkSaved marks the project as saved if it is still at revision, the
+// revision of the snapshot that was written. Edits made during the save keep
+// the project dirty.
+func (s *State) MarkSaved(revision int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.revision == revision {
+		s.setDirty(false)
+	}
+}
+
+// MarkDirty marks the project as having unsaved changes, e.g. after
+// restoring an autosave
+func (s *State) MarkDirty() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setDirty(true)
 }
 
-// ReorderPanel moves a panel to a new index
+// ReorderPanel moves a panel to a new index. A panel moved next to its own
+// scene stays in it; otherwise it joins the scene it lands in, see blockScene.
 func (s *State) ReorderPanel(panelID string, newIndex int) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -246,56 +574,13 @@ func (s *State) ReorderPanel(panelID string, newIndex int) bool {
 		return false
 	}
 
-	// Find the current index of the panel
-	currentIndex := -1
 	for i, panel := range s.CurrentProject.Panels {
 		if panel.ID == panelID {
-			currentIndex = i
-			break
+			return s.movePanels("Move panel", []int{i}, newIndex)
 		}
 	}
 
-	if currentIndex == -1 {
-		return false // Panel not found
-	}
-
-	// Validate new index
-	if newIndex < 0 || newIndex >= len(s.CurrentProject.Panels) {
-		return false
-	}
-
-	if currentIndex == newIndex {
-		return true // No change
-	}
-
-	// Move the panel
-	panel := s.CurrentProject.Panels[currentIndex]
-	
-	// Remove from old position
-	s.CurrentProject.Panels = append(
-		s.CurrentProject.Panels[:currentIndex],
-		s.CurrentProject.Panels[currentIndex+1:]...,
-	)
-
-	// Insert at new position
-	// If newIndex is basically extending (handling bounds for insert)
-	if newIndex >= len(s.CurrentProject.Panels) {
-		s.CurrentProject.Panels = append(s.CurrentProject.Panels, panel)
-	} else {
-		s.CurrentProject.Panels = append(
-			s.CurrentProject.Panels[:newIndex],
-			append([]models.Panel{panel}, s.CurrentProject.Panels[newIndex:]...)...,
-		)
-	}
-
-	// Update Order fields for all panels
-	for i := range s.CurrentProject.Panels {
-		s.CurrentProject.Panels[i].Order = i
-	}
-
-	s.CurrentProject.ModifiedAt = time.Now()
-	s.IsDirty = true
-	return true
+	return false // Panel not found
 }
 
 // DeleteCharacter removes a character from the project and cleans up references
@@ -307,43 +592,53 @@ func (s *State) DeleteCharacter(characterID string) bool {
 		return false
 	}
 
-	// Find and remove character
-	found := false
+	// Find character
+	index := -1
 	for i, char := range s.CurrentProject.Characters {
 		if char.ID == characterID {
-			s.CurrentProject.Characters = append(
-				s.CurrentProject.Characters[:i],
-				s.CurrentProject.Characters[i+1:]...,
-			)
-			found = true
+			index = i
 			break
 		}
 	}
 
-	if !found {
+	if index == -1 {
 		return false
 	}
 
-	// Remove character ID from all panels
-	for i := range s.CurrentProject.Panels {
-		panel := &s.CurrentProject.Panels[i]
-		if len(panel.CharacterIDs) > 0 {
-			// Filter out the deleted character ID
-			newIDs := make([]string, 0, len(panel.CharacterIDs))
-			for _, id := range panel.CharacterIDs {
-				if id != characterID {
-					newIDs = append(newIDs, id)
-				}
+	// Remove character ID from all panels, then the character itself, as one step
+	var cmds compound
+	for i, panel := range s.CurrentProject.Panels {
+		if !containsID(panel.CharacterIDs, characterID) {
+			continue
+		}
+
+		// Filter out the deleted character ID
+		after := copyPanel(panel)
+		newIDs := make([]string, 0, len(panel.CharacterIDs))
+		for _, id := range panel.CharacterIDs {
+			if id != characterID {
+				newIDs = append(newIDs, id)
 			}
-			panel.CharacterIDs = newIDs
 		}
+		after.CharacterIDs = newIDs
+		cmds = append(cmds, &setPanel{index: i, before: copyPanel(panel), after: after})
 	}
+	cmds = append(cmds, &removeCharacter{index: index, character: s.CurrentProject.Characters[index]})
 
-	s.CurrentProject.ModifiedAt = time.Now()
-	s.IsDirty = true
+	s.exec("Delete character", "", cmds)
 	return true
 }
 
+// containsID reports whether ids contains id
+func containsID(ids []string, id string) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
 // RenameProject renames the current project
 func (s *State) RenameProject(newName string) bool {
 	s.mu.Lock()
@@ -353,8 +648,25 @@ func (s *State) RenameProject(newName string) bool {
 		return false
 	}
 
-	s.CurrentProject.Name = newName
-	s.CurrentProject.ModifiedAt = time.Now()
-	s.IsDirty = true
+	s.exec("Rename project", "", &renameProject{before: s.CurrentProject.Name, after: newName})
+	return true
+}
+
+// SetProjectFormat sets the aspect ratio exports are sized to and the color of
+// the bars around images of a different shape
+func (s *State) SetProjectFormat(aspectRatio, matteColor string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.CurrentProject == nil {
+		return false
+	}
+
+	s.exec("Change aspect ratio", "", &setProjectFormat{
+		beforeAspect: s.CurrentProject.AspectRatio,
+		afterAspect:  aspectRatio,
+		beforeMatte:  s.CurrentProject.MatteColor,
+		afterMatte:   matteColor,
+	})
 	return true
 }
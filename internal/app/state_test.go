@@ -0,0 +1,278 @@
+package app
+
+import (
+	"encoding/json"
+	"math"
+	"sync"
+	"testing"
+
+	"storyboard_flow/internal/models"
+)
+
+// newTestState returns a state with a new project whose first panel has
+// every kind of nested field set
+func newTestState(t *testing.T) (*State, string) {
+	t.Helper()
+	s := NewState()
+	s.NewProject("test")
+	char := s.AddCharacter("Ada", "", "")
+	id := s.GetPanels()[0].ID
+	s.UpdatePanel(id, func(p *models.Panel) {
+		p.CharacterIDs = []string{char.ID}
+		p.Metadata = map[string]string{"scene": "1"}
+		p.Motion = &models.CameraMotion{}
+		p.AudioClips = []models.AudioClip{{ID: "clip", Name: "line"}}
+	})
+	return s, id
+}
+
+func TestGetProjectIsDeepCopy(t *testing.T) {
+	s, id := newTestState(t)
+
+	snap := s.GetProject()
+	snap.Name = "changed"
+	snap.Panels[0].CharacterIDs[0] = "changed"
+	snap.Panels[0].Metadata["scene"] = "changed"
+	snap.Panels[0].AudioClips[0].Name = "changed"
+	snap.Characters[0].Name = "changed"
+	snap.Panels = append(snap.Panels[:0], snap.Panels[1:]...)
+
+	live := s.GetProject()
+	if live.Name != "test" || len(live.Panels) != 6 || live.Characters[0].Name != "Ada" {
+		t.Fatalf("changing a copy changed the project: %q, %d panels, character %q", live.Name, len(live.Panels), live.Characters[0].Name)
+	}
+	panel := live.Panels[0]
+	if panel.ID != id || panel.CharacterIDs[0] == "changed" || panel.Metadata["scene"] != "1" || panel.AudioClips[0].Name != "line" {
+		t.Fatalf("changing a copy changed panel %+v", panel)
+	}
+}
+
+func TestSnapshotUnaffectedByEdits(t *testing.T) {
+	s, id := newTestState(t)
+
+	snap := s.Snapshot()
+	before, err := json.Marshal(snap.Project)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.UpdatePanel(id, func(p *models.Panel) {
+		p.Dialogue = "edited"
+		p.CharacterIDs = append(p.CharacterIDs, "other")
+		p.Metadata["scene"] = "2"
+	})
+	s.DeletePanel(id)
+	s.RenameProject("renamed")
+
+	after, err := json.Marshal(snap.Project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(before) != string(after) {
+		t.Fatal("snapshot changed after editing the project")
+	}
+	if snap.Revision == s.Snapshot().Revision {
+		t.Fatal("revision did not advance")
+	}
+}
+
+func TestMarkSavedKeepsLaterEditsDirty(t *testing.T) {
+	s, id := newTestState(t)
+
+	snap := s.Snapshot()
+	s.UpdatePanel(id, func(p *models.Panel) { p.Dialogue = "typed during save" })
+	s.MarkSaved(snap.Revision)
+	if !s.Snapshot().Dirty {
+		t.Fatal("edit made during the save was marked as saved")
+	}
+
+	s.MarkSaved(s.Snapshot().Revision)
+	if s.Snapshot().Dirty {
+		t.Fatal("project still dirty after saving its current revision")
+	}
+}
+
+// TestConcurrentEditsAndSnapshots edits the project from several goroutines
+// while others take snapshots and encode them the way saving does. Run with
+// -race.
+func TestConcurrentEditsAndSnapshots(t *testing.T) {
+	s, _ := newTestState(t)
+	events := 0
+	s.Subscribe(func(Event) { events++ }) // called under the state lock
+
+	const workers, rounds = 6, 100
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				panels := s.GetPanels()
+				if len(panels) == 0 {
+					s.AddPanel()
+					continue
+				}
+				target := panels[(w+i)%len(panels)].ID
+				switch (w + i) % 6 {
+				case 0:
+					s.AddPanel()
+				case 1:
+					s.DuplicatePanel(target)
+				case 2:
+					s.UpdatePanel(target, func(p *models.Panel) {
+						p.Dialogue += "x"
+						p.CharacterIDs = append(p.CharacterIDs, "c")
+					})
+				case 3:
+					s.ReorderPanel(target, i%len(panels))
+				case 4:
+					if len(panels) > 3 {
+						s.DeletePanel(target)
+					}
+				case 5:
+					if i%2 == 0 {
+						s.Undo()
+					} else {
+						s.Redo()
+					}
+				}
+			}
+		}(w)
+	}
+
+	for r := 0; r < 2; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				snap := s.Snapshot()
+				if _, err := json.Marshal(snap.Project); err != nil {
+					t.Error(err)
+					return
+				}
+				for j, panel := range snap.Project.Panels {
+					if panel.Order != j {
+						t.Errorf("snapshot at revision %d has panel %d numbered %d", snap.Revision, j, panel.Order)
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	project := s.GetProject()
+	seen := make(map[string]bool)
+	for i, panel := range project.Panels {
+		if panel.Order != i || seen[panel.ID] {
+			t.Fatalf("panel %d (%s) has order %d or a duplicate ID", i, panel.ID, panel.Order)
+		}
+		seen[panel.ID] = true
+	}
+	if events == 0 {
+		t.Fatal("no events were published")
+	}
+}
+
+func TestSplitPanelDurations(t *testing.T) {
+	tests := []struct {
+		duration float64
+		parts    int
+		want     []float64
+	}{
+		{10, 4, []float64{2.5, 2.5, 2.5, 2.5}},
+		{1, 3, []float64{0.334, 0.333, 0.333}},
+		{0.006, 4, []float64{0.002, 0.002, 0.001, 0.001}},
+		{0, 2, []float64{models.DefaultDuration / 2, models.DefaultDuration / 2}}, // the default duration is split
+	}
+
+	for _, tt := range tests {
+		s, id := newTestState(t)
+		s.UpdatePanel(id, func(p *models.Panel) {
+			p.Duration = tt.duration
+			p.AudioClips = nil
+		})
+
+		parts := s.SplitPanel(id, tt.parts)
+		if len(parts) != tt.parts {
+			t.Fatalf("%v split %d ways: got %d parts", tt.duration, tt.parts, len(parts))
+		}
+		var total int64
+		for k, part := range parts {
+			if part.Duration != tt.want[k] {
+				t.Errorf("%v split %d ways: part %d lasts %v, want %v", tt.duration, tt.parts, k, part.Duration, tt.want[k])
+			}
+			total += int64(math.Round(part.Duration * 1000))
+		}
+		if total != int64(math.Round(models.Panel{Duration: tt.duration}.PlayDuration()*1000)) {
+			t.Errorf("%v split %d ways: parts add up to %dms", tt.duration, tt.parts, total)
+		}
+	}
+}
+
+func TestSplitPanelAudioAndLimits(t *testing.T) {
+	s, id := newTestState(t)
+	s.UpdatePanel(id, func(p *models.Panel) {
+		p.Duration = 3
+		p.AudioClips = []models.AudioClip{{ID: "a", Offset: 0.5}, {ID: "b", Offset: 2.25}}
+	})
+
+	if s.SplitPanel(id, 1) != nil || s.SplitPanel(id, MaxSplitParts+1) != nil {
+		t.Fatal("split outside 2 to MaxSplitParts parts")
+	}
+	short := s.GetPanels()[1].ID
+	s.UpdatePanel(short, func(p *models.Panel) { p.Duration = 0.002 })
+	if s.SplitPanel(short, 3) != nil {
+		t.Error("split into parts shorter than a millisecond")
+	}
+
+	parts := s.SplitPanel(id, 3)
+	if clips := parts[0].AudioClips; len(clips) != 1 || clips[0].ID != "a" || clips[0].Offset != 0.5 {
+		t.Errorf("first part clips = %+v", clips)
+	}
+	if clips := parts[2].AudioClips; len(clips) != 1 || clips[0].ID != "b" || clips[0].Offset != 0.25 {
+		t.Errorf("last part clips = %+v", clips)
+	}
+}
+
+func TestSplitPanelCopiesFramingAndTransition(t *testing.T) {
+	motion := &models.CameraMotion{
+		Start:  models.Framing{X: 0.2, Y: 0.5, Scale: 1.5},
+		End:    models.Framing{X: 0.8, Y: 0.5, Scale: 1.5},
+		Easing: models.EaseLinear,
+	}
+	tests := []struct {
+		transition string
+		want       []bool // whether each part has the transition
+	}{
+		{models.TransitionDissolve, []bool{true, true, true}},
+		{models.TransitionFadeToBlack, []bool{false, false, true}},
+	}
+	for _, tt := range tests {
+		s, id := newTestState(t)
+		s.UpdatePanel(id, func(p *models.Panel) {
+			p.Motion = motion
+			p.Transition = &models.Transition{Type: tt.transition, Duration: 0.5}
+			p.Fit = models.FitFill
+		})
+
+		parts := s.SplitPanel(id, 3)
+		if len(parts) != 3 {
+			t.Fatalf("got %d parts, want 3", len(parts))
+		}
+		for k, part := range parts {
+			if part.Motion == nil || *part.Motion != *motion || part.Fit != models.FitFill {
+				t.Errorf("%s: part %d framing = %+v, %q; want the panel's", tt.transition, k, part.Motion, part.Fit)
+			}
+			if has := part.Transition != nil && part.Transition.Type == tt.transition; has != tt.want[k] {
+				t.Errorf("%s: part %d transition = %+v", tt.transition, k, part.Transition)
+			}
+		}
+
+		// The parts do not share the panel's motion
+		parts[1].Motion.Start.X = 0
+		if got := s.GetPanels()[1].Motion.Start.X; got != motion.Start.X {
+			t.Errorf("changing a returned part changed the state to %v", got)
+		}
+	}
+}
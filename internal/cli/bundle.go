@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"storyboard_flow/internal/storage"
+)
+
+// packOutput is printed by pack
+type packOutput struct {
+	Bundle  string   `json:"bundle"`
+	Files   int      `json:"files"`
+	Missing []string `json:"missing"`
+}
+
+// unpackOutput is printed by unpack
+type unpackOutput struct {
+	Project string `json:"project"`
+}
+
+// pack runs "pack <project.json>"
+func (e *env) pack(args []string) (int, error) {
+	fs := e.newFlagSet("pack")
+	output := fs.String("o", "", "bundle to write (default: the project path with "+storage.BundleExt+")")
+	strict := fs.Bool("strict", false, "fail if referenced files are missing")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return 0, err
+	}
+	if len(positional) != 1 {
+		return 0, usageError{"pack needs exactly one project file"}
+	}
+	projectPath := positional[0]
+
+	bundlePath := *output
+	if bundlePath == "" {
+		bundlePath = strings.TrimSuffix(projectPath, filepath.Ext(projectPath)) + storage.BundleExt
+	}
+
+	manifest, err := storage.PackBundle(projectPath, bundlePath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to pack project: %w", err)
+	}
+
+	out := packOutput{Bundle: bundlePath, Files: len(manifest.Files), Missing: manifest.Missing}
+	if out.Missing == nil {
+		out.Missing = []string{}
+	}
+	code := ExitOK
+	if *strict && len(out.Missing) > 0 {
+		code = ExitFailure
+	}
+	return code, e.print(out)
+}
+
+// unpack runs "unpack <project.sbf>"
+func (e *env) unpack(args []string) (int, error) {
+	fs := e.newFlagSet("unpack")
+	output := fs.String("o", "", "folder to extract into (default: the bundle path without extension)")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return 0, err
+	}
+	if len(positional) != 1 {
+		return 0, usageError{"unpack needs exactly one bundle"}
+	}
+	bundlePath := positional[0]
+
+	dir := *output
+	if dir == "" {
+		dir = strings.TrimSuffix(bundlePath, filepath.Ext(bundlePath))
+	}
+
+	projectPath, err := storage.UnpackBundle(bundlePath, dir)
+	if err != nil {
+		return 0, err
+	}
+	return ExitOK, e.print(unpackOutput{Project: projectPath})
+}
@@ -0,0 +1,186 @@
+// Package cli implements the headless command line interface used by render
+// farms and CI. Every command prints JSON to stdout and exits non-zero on
+// failure, with the error as JSON on stderr.
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"storyboard_flow/internal/app"
+	"storyboard_flow/internal/storage"
+)
+
+// Exit codes
+const (
+	ExitOK      = 0
+	ExitFailure = 1 // the command ran and failed, or found problems
+	ExitUsage   = 2 // bad arguments
+)
+
+const usage = `Usage: storyboard_flow <command> [arguments]
+
+Commands:
+  export mp4|pdf|edl|fcpxml <project.json> [-o output] [options]
+  validate <project.json>
+  stats <project.json>
+  import fountain|fdx <script> [-o project.json] [--into project.json]
+  pack <project.json> [-o project.sbf] [--strict]
+  unpack <project.sbf> [-o folder]
+  serve [project.json] [--addr 127.0.0.1:8765] [--token token] [--allow-remote]
+
+Run "storyboard_flow <command> -h" for the options of a command.
+Without arguments the desktop app starts.
+`
+
+// usageError is returned for bad arguments; it exits with ExitUsage
+type usageError struct{ msg string }
+
+func (e usageError) Error() string { return e.msg }
+
+// env carries the output streams of a command
+type env struct {
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// Run executes the command in args (without the program name) and returns the
+// process exit code
+func Run(args []string, stdout, stderr io.Writer) int {
+	e := &env{stdout: stdout, stderr: stderr}
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprint(stdout, usage)
+		return ExitOK
+	}
+
+	var code int
+	var err error
+	switch args[0] {
+	case "export":
+		code, err = e.export(args[1:])
+	case "validate":
+		code, err = e.validate(args[1:])
+	case "stats":
+		code, err = e.stats(args[1:])
+	case "import":
+		code, err = e.importScript(args[1:])
+	case "pack":
+		code, err = e.pack(args[1:])
+	case "unpack":
+		code, err = e.unpack(args[1:])
+	case "serve":
+		code, err = e.serve(args[1:])
+	default:
+		err = usageError{fmt.Sprintf("unknown command %q", args[0])}
+	}
+
+	if err != nil {
+		if err == flag.ErrHelp {
+			return ExitOK
+		}
+		e.fail(err)
+		if _, ok := err.(usageError); ok {
+			return ExitUsage
+		}
+		return ExitFailure
+	}
+	return code
+}
+
+// print writes v as indented JSON to stdout
+func (e *env) print(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(e.stdout, string(data))
+	return err
+}
+
+// fail writes an error as JSON to stderr
+func (e *env) fail(err error) {
+	data, _ := json.Marshal(map[string]string{"error": err.Error()})
+	fmt.Fprintln(e.stderr, string(data))
+}
+
+// newFlagSet returns a flag set that reports problems instead of exiting
+func (e *env) newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	return fs
+}
+
+// parseArgs parses flags that may appear before, between or after the
+// positional arguments and returns the positional ones
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if err == flag.ErrHelp {
+				return nil, err
+			}
+			return nil, usageError{err.Error()}
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// loadProject reads a project file into a State, the same way the app opens it
+func loadProject(path string) (*app.State, error) {
+	project, err := storage.LoadProject(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load project: %w", err)
+	}
+
+	state := app.NewState()
+	state.SetProject(project, path)
+	if project.NeedsMigration {
+		state.MarkDirty()
+	}
+	return state, nil
+}
+
+// readProject reads a project file into a State for commands that only
+// inspect or export it; nothing on disk is changed. It also returns the
+// folder relative paths resolve against. Call cleanup when done.
+func readProject(path string) (state *app.State, dir string, cleanup func(), err error) {
+	cleanup = func() {}
+	scratch := ""
+	if storage.IsBundle(path) {
+		// Leave the bundle's working folder alone, the app may have it open
+		if scratch, err = os.MkdirTemp("", "storyboard_flow-"); err != nil {
+			return nil, "", nil, err
+		}
+		cleanup = func() { os.RemoveAll(scratch) }
+	}
+
+	project, dir, err := storage.ReadProject(path, scratch)
+	if err != nil {
+		cleanup()
+		return nil, "", nil, fmt.Errorf("failed to load project: %w", err)
+	}
+
+	state = app.NewState()
+	state.SetProject(project, path)
+	return state, dir, cleanup, nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
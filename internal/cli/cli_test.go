@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"storyboard_flow/internal/storage"
+)
+
+// legacyProject is a schema 0 project that still embeds its panel image
+const legacyProject = `{
+  "name": "legacy",
+  "panels": [
+    {"id": "p1", "order": 0, "duration": 2, "image_data": "data:image/png;base64,iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAAAAAA6fptVAAAACklEQVR4nGNgAAAAAgABSK+kcQAAAABJRU5ErkJggg=="}
+  ]
+}`
+
+func TestReadOnlyCommandsLeaveFilesAlone(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+
+	jsonPath := filepath.Join(dir, "project", "legacy.json")
+	if err := os.MkdirAll(filepath.Dir(jsonPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(jsonPath, []byte(legacyProject), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	source := filepath.Join(dir, "bundle", "board.json")
+	if err := os.MkdirAll(filepath.Dir(source), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(source, []byte(legacyProject), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bundlePath := filepath.Join(dir, "board"+storage.BundleExt)
+	if _, err := storage.PackBundle(source, bundlePath); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{jsonPath, bundlePath} {
+		for _, command := range []string{"validate", "stats"} {
+			t.Run(command+" "+filepath.Base(path), func(t *testing.T) {
+				// Backdate the file so a rewrite shows up in its mtime
+				past := time.Now().Add(-time.Hour).Truncate(time.Second)
+				if err := os.Chtimes(path, past, past); err != nil {
+					t.Fatal(err)
+				}
+				before, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				entries, err := os.ReadDir(filepath.Dir(path))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				var stdout, stderr bytes.Buffer
+				if code := Run([]string{command, path}, &stdout, &stderr); code != ExitOK {
+					t.Fatalf("exit code %d: %s%s", code, stdout.String(), stderr.String())
+				}
+
+				after, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(before, after) {
+					t.Error("file contents changed")
+				}
+				info, err := os.Stat(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !info.ModTime().Equal(past) {
+					t.Errorf("mtime changed from %v to %v", past, info.ModTime())
+				}
+				if now, _ := os.ReadDir(filepath.Dir(path)); len(now) != len(entries) {
+					t.Errorf("files were added next to the project: %d entries, was %d", len(now), len(entries))
+				}
+			})
+		}
+	}
+
+	// Bundles are read without touching their working folder in the cache
+	if _, err := os.Stat(filepath.Join(dir, "cache", "storyboard_flow", "bundles")); !os.IsNotExist(err) {
+		t.Errorf("bundle working folder was created: %v", err)
+	}
+}
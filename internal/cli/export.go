@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"storyboard_flow/internal/app/exporter"
+	"storyboard_flow/internal/models"
+)
+
+// export runs "export <format> <project>"
+func (e *env) export(args []string) (int, error) {
+	if len(args) == 0 {
+		return 0, usageError{"export needs a format: mp4, pdf, edl or fcpxml"}
+	}
+	format := strings.ToLower(args[0])
+	switch format {
+	case "mp4", "pdf", "edl", "fcpxml":
+	default:
+		return 0, usageError{fmt.Sprintf("unknown export format %q", args[0])}
+	}
+
+	fs := e.newFlagSet("export " + format)
+	output := fs.String("o", "", "output file (default: the project path with the format's extension)")
+	scenes := fs.String("scenes", "", "comma-separated scene IDs to export (default: all)")
+	strict := fs.Bool("strict", false, "fail instead of exporting blank frames for missing images")
+
+	// Video and edit list options
+	width := fs.Int("width", 0, "frame width (default: from the aspect ratio)")
+	height := fs.Int("height", 0, "frame height (default: from the aspect ratio)")
+	fps := fs.Int("fps", 0, "frames per second (default: the project frame rate)")
+	bitrate := fs.Int("bitrate", 2000, "video bitrate in kbit/s (mp4)")
+	mute := fs.Bool("mute", false, "leave out audio (mp4)")
+	burnIns := fs.String("burn-ins", "", "overlays to burn in (mp4): timecode,panel,shot_info,subtitles,watermark,safe_area")
+	progress := fs.Bool("progress", false, "write progress as JSON lines to stderr (mp4)")
+
+	// PDF options
+	perPage := fs.Int("per-page", 6, "panels per page: 1, 3 or 6 (pdf)")
+	landscape := fs.Bool("landscape", false, "landscape pages (pdf)")
+	paper := fs.String("paper", "letter", "paper size: letter or a4 (pdf)")
+
+	positional, err := parseArgs(fs, args[1:])
+	if err != nil {
+		return 0, err
+	}
+	if len(positional) != 1 {
+		return 0, usageError{"export needs exactly one project file"}
+	}
+	projectPath := positional[0]
+
+	state, dir, cleanup, err := readProject(projectPath)
+	if err != nil {
+		return 0, err
+	}
+	defer cleanup()
+	project := state.GetProject()
+
+	outPath := *output
+	if outPath == "" {
+		outPath = strings.TrimSuffix(projectPath, filepath.Ext(projectPath)) + "." + format
+	}
+
+	var report *exporter.ExportReport
+	if format == "pdf" {
+		report, err = exporter.ExportProjectToPDF(project, outPath, exporter.PDFOptions{
+			PerPage:   *perPage,
+			Landscape: *landscape,
+			PaperSize: *paper,
+			BaseDir:   dir,
+			Strict:    *strict,
+			SceneIDs:  splitList(*scenes),
+		})
+	} else {
+		opts := exporter.ExportOptions{
+			FPS:         project.FrameRate,
+			Bitrate:     *bitrate,
+			DefaultSecs: models.DefaultDuration,
+			BaseDir:     dir,
+			Strict:      *strict,
+			SceneIDs:    splitList(*scenes),
+			Mute:        *mute,
+		}
+		opts.Width, opts.Height = exporter.FrameSize(project.Aspect(), *width, *height)
+		if *fps > 0 {
+			opts.FPS = *fps
+		}
+		if opts.FPS == 0 {
+			opts.FPS = 24
+		}
+		if opts.Overlays, err = parseOverlays(*burnIns); err != nil {
+			return 0, err
+		}
+
+		switch format {
+		case "mp4":
+			if *progress {
+				opts.Progress = e.progressWriter()
+			}
+			// Ctrl-C stops the render and removes the partial file
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			report, err = exporter.ExportProjectToMP4(ctx, project, outPath, opts)
+		case "edl":
+			report, err = exporter.ExportProjectToEDL(project, outPath, opts)
+		case "fcpxml":
+			report, err = exporter.ExportProjectToFCPXML(project, outPath, opts)
+		}
+	}
+
+	if err != nil {
+		// Strict failures come with a report listing what was missing
+		if report != nil {
+			e.print(report)
+		}
+		return 0, err
+	}
+	return ExitOK, e.print(report)
+}
+
+// parseOverlays reads a --burn-ins list
+func parseOverlays(value string) (exporter.Overlays, error) {
+	var o exporter.Overlays
+	for _, name := range splitList(value) {
+		switch strings.ToLower(name) {
+		case "timecode":
+			o.Timecode = true
+		case "panel":
+			o.Panel = true
+		case "shot_info":
+			o.ShotInfo = true
+		case "subtitles":
+			o.Subtitles = true
+		case "watermark":
+			o.Watermark = true
+		case "safe_area":
+			o.SafeArea = true
+		default:
+			return o, usageError{fmt.Sprintf("unknown burn-in %q", name)}
+		}
+	}
+	return o, nil
+}
+
+// progressWriter returns an export progress callback that writes a JSON line
+// to stderr each time another whole percent is done
+func (e *env) progressWriter() func(done, total int) {
+	last := -1
+	return func(done, total int) {
+		percent := 0
+		if total > 0 {
+			percent = done * 100 / total
+		}
+		if percent == last {
+			return
+		}
+		last = percent
+		data, _ := json.Marshal(map[string]int{"done": done, "total": total, "percent": percent})
+		fmt.Fprintln(e.stderr, string(data))
+	}
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"storyboard_flow/internal/app"
+	"storyboard_flow/internal/script"
+	"storyboard_flow/internal/storage"
+)
+
+// importOutput is printed by import
+type importOutput struct {
+	Project string           `json:"project"`
+	Result  app.ImportResult `json:"result"`
+}
+
+// importScript runs "import fountain|fdx <script>"
+func (e *env) importScript(args []string) (int, error) {
+	if len(args) == 0 {
+		return 0, usageError{"import needs a format: fountain or fdx"}
+	}
+	format := strings.ToLower(args[0])
+	var parse func(f *os.File) (*script.Document, error)
+	switch format {
+	case "fountain":
+		parse = func(f *os.File) (*script.Document, error) { return script.ParseFountain(f) }
+	case "fdx":
+		parse = func(f *os.File) (*script.Document, error) { return script.ParseFDX(f) }
+	default:
+		return 0, usageError{fmt.Sprintf("unknown script format %q", args[0])}
+	}
+
+	fs := e.newFlagSet("import " + format)
+	output := fs.String("o", "", "project file to write (default: the script path with .json, or --into)")
+	into := fs.String("into", "", "existing project to import into instead of a new one")
+
+	positional, err := parseArgs(fs, args[1:])
+	if err != nil {
+		return 0, err
+	}
+	if len(positional) != 1 {
+		return 0, usageError{"import needs exactly one script file"}
+	}
+	scriptPath := positional[0]
+
+	f, err := os.Open(scriptPath)
+	if err != nil {
+		return 0, err
+	}
+	doc, err := parse(f)
+	f.Close()
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse script: %w", err)
+	}
+
+	var state *app.State
+	outPath := *output
+	if *into != "" {
+		if state, err = loadProject(*into); err != nil {
+			return 0, err
+		}
+		if outPath == "" {
+			outPath = *into
+		}
+	} else {
+		name := strings.TrimSuffix(filepath.Base(scriptPath), filepath.Ext(scriptPath))
+		if title := strings.TrimSpace(strings.SplitN(doc.Title["title"], "\n", 2)[0]); title != "" {
+			name = title
+		}
+		state = app.NewState()
+		state.NewProject(name)
+		if outPath == "" {
+			outPath = strings.TrimSuffix(scriptPath, filepath.Ext(scriptPath)) + ".json"
+		}
+	}
+
+	result, ok := state.ImportScript(doc, filepath.Base(scriptPath))
+	if !ok {
+		return 0, fmt.Errorf("script contains no panels to import")
+	}
+
+	if err := storage.SaveProject(state.GetProject(), outPath); err != nil {
+		return 0, fmt.Errorf("failed to save project: %w", err)
+	}
+
+	return ExitOK, e.print(importOutput{Project: outPath, Result: result})
+}
@@ -0,0 +1,236 @@
+package cli
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"storyboard_flow/internal/app/exporter"
+	"storyboard_flow/internal/models"
+)
+
+// defaultPanelSecs matches the duration exports give panels without one
+const defaultPanelSecs = 3.0
+
+// Issue is one problem found by validate
+type Issue struct {
+	PanelID string `json:"panel_id,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationReport is the output of validate
+type ValidationReport struct {
+	Project  string  `json:"project"`
+	Valid    bool    `json:"valid"`
+	Errors   []Issue `json:"errors"`
+	Warnings []Issue `json:"warnings"`
+}
+
+// Stats is the output of stats
+type Stats struct {
+	Name               string         `json:"name"`
+	AspectRatio        string         `json:"aspect_ratio"`
+	FrameRate          int            `json:"frame_rate"`
+	Panels             int            `json:"panels"`
+	PanelsWithoutImage int            `json:"panels_without_image"`
+	Scenes             int            `json:"scenes"`
+	Sequences          int            `json:"sequences"`
+	Characters         int            `json:"characters"`
+	AudioTracks        int            `json:"audio_tracks"`
+	PanelAudioClips    int            `json:"panel_audio_clips"`
+	DurationSecs       float64        `json:"duration_secs"`
+	DialogueWords      int            `json:"dialogue_words"`
+	ShotTypes          map[string]int `json:"shot_types"`
+	SceneDurations     []SceneStats   `json:"scene_durations"`
+}
+
+// SceneStats is the length of one scene
+type SceneStats struct {
+	SceneID      string  `json:"scene_id"` // empty for panels without a scene
+	Heading      string  `json:"heading"`
+	Panels       int     `json:"panels"`
+	DurationSecs float64 `json:"duration_secs"`
+}
+
+// validate runs "validate <project>"
+func (e *env) validate(args []string) (int, error) {
+	fs := e.newFlagSet("validate")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return 0, err
+	}
+	if len(positional) != 1 {
+		return 0, usageError{"validate needs exactly one project file"}
+	}
+	projectPath := positional[0]
+
+	state, dir, cleanup, err := readProject(projectPath)
+	if err != nil {
+		return 0, err
+	}
+	defer cleanup()
+
+	report := validateProject(state.GetProject(), dir)
+	report.Project = projectPath
+	if err := e.print(report); err != nil {
+		return 0, err
+	}
+	if !report.Valid {
+		return ExitFailure, nil
+	}
+	return ExitOK, nil
+}
+
+// validateProject checks references between panels, scenes, characters and
+// files on disk
+func validateProject(p *models.Project, baseDir string) ValidationReport {
+	report := ValidationReport{Errors: []Issue{}, Warnings: []Issue{}}
+	fail := func(panelID, format string, a ...interface{}) {
+		report.Errors = append(report.Errors, Issue{PanelID: panelID, Message: fmt.Sprintf(format, a...)})
+	}
+	warn := func(panelID, format string, a ...interface{}) {
+		report.Warnings = append(report.Warnings, Issue{PanelID: panelID, Message: fmt.Sprintf(format, a...)})
+	}
+
+	if _, err := models.ParseAspectRatio(p.AspectRatio); err != nil {
+		fail("", "%v", err)
+	}
+	if p.MatteColor != "" {
+		if _, _, _, err := models.ParseHexColor(p.MatteColor); err != nil {
+			fail("", "matte: %v", err)
+		}
+	}
+	if p.FrameRate <= 0 {
+		warn("", "frame rate is not set; exports use 24 fps")
+	}
+
+	sequences := make(map[string]bool)
+	for _, seq := range p.Sequences {
+		sequences[seq.ID] = true
+	}
+	scenes := make(map[string]bool)
+	for _, scene := range p.Scenes {
+		scenes[scene.ID] = true
+		if scene.SequenceID != "" && !sequences[scene.SequenceID] {
+			fail("", "scene %q belongs to missing sequence %s", scene.Heading.String(), scene.SequenceID)
+		}
+	}
+	characters := make(map[string]bool)
+	for _, char := range p.Characters {
+		characters[char.ID] = true
+	}
+
+	seen := make(map[string]bool)
+	for i, panel := range p.Panels {
+		if seen[panel.ID] {
+			fail(panel.ID, "duplicate panel ID")
+		}
+		seen[panel.ID] = true
+
+		if panel.Order != i {
+			warn(panel.ID, "panel order %d does not match its position %d", panel.Order, i)
+		}
+		if panel.ImageData == "" {
+			warn(panel.ID, "panel %d has no image", i+1)
+		} else if err := exporter.CheckPanelImage(panel.ImageData, baseDir); err != nil {
+			fail(panel.ID, "panel %d image: %v", i+1, err)
+		}
+		if panel.Duration <= 0 {
+			warn(panel.ID, "panel %d has no duration; exports use %gs", i+1, defaultPanelSecs)
+		}
+		if panel.SceneID != "" && !scenes[panel.SceneID] {
+			fail(panel.ID, "panel %d belongs to missing scene %s", i+1, panel.SceneID)
+		}
+		for _, id := range panel.CharacterIDs {
+			if !characters[id] {
+				fail(panel.ID, "panel %d lists missing character %s", i+1, id)
+			}
+		}
+		for _, clip := range panel.AudioClips {
+			if _, err := exporter.ResolveAudioPath(clip.Source, baseDir); err != nil {
+				fail(panel.ID, "panel %d audio %q: %v", i+1, clip.Name, err)
+			}
+		}
+	}
+
+	for _, track := range p.AudioTracks {
+		if _, err := exporter.ResolveAudioPath(track.Source, baseDir); err != nil {
+			fail("", "audio track %q: %v", track.Name, err)
+		}
+	}
+
+	report.Valid = len(report.Errors) == 0
+	return report
+}
+
+// stats runs "stats <project>"
+func (e *env) stats(args []string) (int, error) {
+	fs := e.newFlagSet("stats")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return 0, err
+	}
+	if len(positional) != 1 {
+		return 0, usageError{"stats needs exactly one project file"}
+	}
+
+	state, _, cleanup, err := readProject(positional[0])
+	if err != nil {
+		return 0, err
+	}
+	defer cleanup()
+	return ExitOK, e.print(projectStats(state.GetProject()))
+}
+
+// projectStats counts what a project contains and how long it runs
+func projectStats(p *models.Project) Stats {
+	s := Stats{
+		Name:           p.Name,
+		AspectRatio:    p.AspectRatio,
+		FrameRate:      p.FrameRate,
+		Panels:         len(p.Panels),
+		Scenes:         len(p.Scenes),
+		Sequences:      len(p.Sequences),
+		Characters:     len(p.Characters),
+		AudioTracks:    len(p.AudioTracks),
+		ShotTypes:      make(map[string]int),
+		SceneDurations: []SceneStats{},
+	}
+
+	for _, group := range p.PanelsByScene() {
+		scene := SceneStats{Panels: len(group.Panels)}
+		if group.Scene != nil {
+			scene.SceneID = group.Scene.ID
+			scene.Heading = group.Scene.Heading.String()
+		}
+
+		for _, panel := range group.Panels {
+			secs := panel.Duration
+			if secs <= 0 {
+				secs = defaultPanelSecs
+			}
+			scene.DurationSecs += secs
+
+			if panel.ImageData == "" {
+				s.PanelsWithoutImage++
+			}
+			if panel.ShotType != "" {
+				s.ShotTypes[panel.ShotType]++
+			}
+			s.PanelAudioClips += len(panel.AudioClips)
+			s.DialogueWords += len(strings.Fields(panel.Dialogue))
+		}
+
+		scene.DurationSecs = roundMillis(scene.DurationSecs)
+		s.DurationSecs += scene.DurationSecs
+		s.SceneDurations = append(s.SceneDurations, scene)
+	}
+	s.DurationSecs = roundMillis(s.DurationSecs)
+
+	return s
+}
+
+// roundMillis rounds seconds to milliseconds so sums print cleanly
+func roundMillis(secs float64) float64 {
+	return math.Round(secs*1000) / 1000
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	"storyboard_flow/internal/api"
+	"storyboard_flow/internal/app"
+	"storyboard_flow/internal/ui"
+)
+
+// serveOutput is printed by serve once it is listening
+type serveOutput struct {
+	Addr  string `json:"addr"`
+	Token string `json:"token"`
+}
+
+// serve runs "serve [project.json]", the HTTP API without a window
+func (e *env) serve(args []string) (int, error) {
+	fs := e.newFlagSet("serve")
+	addr := fs.String("addr", api.DefaultAddr, "address to listen on")
+	token := fs.String("token", "", "API token (default: a random one, printed on start)")
+	allowRemote := fs.Bool("allow-remote", false, "allow an address other machines can reach")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return 0, err
+	}
+	if len(positional) > 1 {
+		return 0, usageError{"serve takes at most one project file"}
+	}
+	if err := api.CheckAddr(*addr, *allowRemote); err != nil {
+		return 0, usageError{fmt.Sprintf("%v; pass --allow-remote to serve it there", err)}
+	}
+
+	state := app.NewState()
+	if len(positional) == 1 {
+		if state, err = loadProject(positional[0]); err != nil {
+			return 0, err
+		}
+	}
+
+	if *token == "" {
+		if *token, err = api.NewToken(); err != nil {
+			return 0, err
+		}
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return 0, err
+	}
+	handlers := ui.NewHandlers(state)
+	stopAutosave := handlers.StartAutosave(ui.AutosaveInterval)
+	defer stopAutosave()
+	server := api.NewServer(handlers, *token)
+
+	if err := e.print(serveOutput{Addr: listener.Addr().String(), Token: *token}); err != nil {
+		return 0, err
+	}
+	if err := http.Serve(listener, server); err != nil {
+		return 0, fmt.Errorf("API server stopped: %w", err)
+	}
+	return ExitOK, nil
+}
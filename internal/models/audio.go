@@ -0,0 +1,35 @@
+package models
+
+// Audio clip kinds
+const (
+	AudioMusic     = "music"
+	AudioTempScore = "temp_score"
+	AudioDialogue  = "dialogue"
+	AudioSFX       = "sfx"
+)
+
+// AudioKinds lists every audio clip kind
+var AudioKinds = []string{AudioMusic, AudioTempScore, AudioDialogue, AudioSFX}
+
+// AudioClip is a sound file placed on the timeline. Project tracks are
+// offset from the start of the board, panel clips from the start of their panel.
+type AudioClip struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	Kind     string  `json:"kind"`     // music, temp_score, dialogue, sfx
+	Source   string  `json:"source"`   // "asset:<hash>" audio reference or file path
+	Offset   float64 `json:"offset"`   // in seconds
+	Gain     float64 `json:"gain"`     // in dB, 0 plays the file as-is
+	Duration float64 `json:"duration"` // length of the file in seconds
+}
+
+// NewAudioClip creates a new audio clip at offset 0 and unity gain
+func NewAudioClip(name, kind, source string, duration float64) *AudioClip {
+	return &AudioClip{
+		ID:       generateID(),
+		Name:     name,
+		Kind:     kind,
+		Source:   source,
+		Duration: duration,
+	}
+}
@@ -5,7 +5,7 @@ type Character struct {
 	ID          string `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
-	ImagePath   string `json:"image_path"` // relative path to character reference image
+	ImagePath   string `json:"image_path"` // reference image: asset reference or path relative to the project
 	ColorPalette string `json:"color_palette,omitempty"` // optional hex colors
 }
 
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// How a panel image is placed in a frame of a different shape
+const (
+	FitContain = "fit"  // whole image visible, matte bars fill the rest
+	FitFill    = "fill" // image covers the frame, overflow cropped around the focus
+	FitCrop    = "crop" // image at its own size, cropped around the focus
+)
+
+// DefaultAspectRatio is used when a project's ratio is missing or invalid
+const DefaultAspectRatio = 16.0 / 9.0
+
+// FocalPoint is the part of an image kept in view when it is cropped, in
+// image-relative coordinates (0,0 top left, 1,1 bottom right)
+type FocalPoint struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// ParseAspectRatio reads ratios such as "16:9", "2.39:1" or "1.85"
+func ParseAspectRatio(s string) (float64, error) {
+	parts := strings.SplitN(strings.TrimSpace(s), ":", 2)
+	ratio, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err == nil && len(parts) == 2 {
+		var h float64
+		h, err = strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if !validRatio(h) {
+			h = math.NaN() // a negative height would cancel a negative width
+		}
+		ratio /= h
+	}
+	if err != nil || !validRatio(ratio) {
+		return 0, fmt.Errorf("invalid aspect ratio %q", s)
+	}
+	return ratio, nil
+}
+
+// validRatio reports whether v is a positive, finite number
+func validRatio(v float64) bool {
+	return v > 0 && !math.IsInf(v, 0) && !math.IsNaN(v)
+}
+
+// Aspect returns the project's aspect ratio as width/height, defaulting to 16:9
+func (p *Project) Aspect() float64 {
+	ratio, err := ParseAspectRatio(p.AspectRatio)
+	if err != nil {
+		return DefaultAspectRatio
+	}
+	return ratio
+}
+
+// ParseHexColor reads "#rgb" or "#rrggbb" colors into their components
+func ParseHexColor(s string) (r, g, b uint8, err error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid color %q", s)
+	}
+	return uint8(v >> 16), uint8(v >> 8), uint8(v), nil
+}
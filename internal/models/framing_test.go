@@ -0,0 +1,30 @@
+package models
+
+import "testing"
+
+func TestParseAspectRatio(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+	}{
+		{"16:9", 16.0 / 9.0},
+		{" 2.39 : 1 ", 2.39},
+		{"1.85", 1.85},
+		{"4:3", 4.0 / 3.0},
+	}
+	for _, tt := range tests {
+		if got, err := ParseAspectRatio(tt.in); err != nil || got != tt.want {
+			t.Errorf("ParseAspectRatio(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{
+		"", "wide", "0", "-1.85", "16:0", "16:-9", "-16:-9", "0:9",
+		"NaN", "nan:1", "16:NaN", "Inf", "+Inf:1", "16:Inf", "-Inf:-1",
+		"1e308:1e-308",
+	} {
+		if got, err := ParseAspectRatio(in); err == nil {
+			t.Errorf("ParseAspectRatio(%q) = %v, want an error", in, got)
+		}
+	}
+}
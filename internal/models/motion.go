@@ -0,0 +1,78 @@
+package models
+
+// Camera move easing curves
+const (
+	EaseLinear    = "linear"
+	EaseIn        = "ease_in"
+	EaseOut       = "ease_out"
+	EaseInOut     = "ease_in_out"
+	DefaultEasing = EaseInOut
+)
+
+// Transition types. A transition plays at the start of its panel, except
+// fade_to_black which plays at the end.
+const (
+	TransitionCut           = "cut"
+	TransitionDissolve      = "dissolve"
+	TransitionFadeFromBlack = "fade_from_black"
+	TransitionFadeToBlack   = "fade_to_black"
+	TransitionWipe          = "wipe"
+)
+
+// MaxScale is the largest magnification a framing may use
+const MaxScale = 10.0
+
+// Framing is a crop window over a panel image
+type Framing struct {
+	X     float64 `json:"x"`     // window center, 0 = left edge, 1 = right edge
+	Y     float64 `json:"y"`     // window center, 0 = top edge, 1 = bottom edge
+	Scale float64 `json:"scale"` // magnification, 1 shows the whole frame
+}
+
+// CameraMotion animates the framing of a panel over its duration
+type CameraMotion struct {
+	Start  Framing `json:"start"`
+	End    Framing `json:"end"`
+	Easing string  `json:"easing"` // linear, ease_in, ease_out, ease_in_out
+}
+
+// Transition describes how a panel is cut to
+type Transition struct {
+	Type     string  `json:"type"`     // cut, dissolve, fade_from_black, fade_to_black, wipe
+	Duration float64 `json:"duration"` // in seconds
+}
+
+// DefaultMotion returns the framing used for a Panel.CameraMove value when the
+// panel has no explicit motion. Static (and unknown moves) return nil.
+func DefaultMotion(move string) *CameraMotion {
+	center := Framing{X: 0.5, Y: 0.5, Scale: 1}
+	m := &CameraMotion{Start: center, End: center, Easing: DefaultEasing}
+
+	switch move {
+	case "Pan":
+		m.Start = Framing{X: 0.4, Y: 0.5, Scale: 1.25}
+		m.End = Framing{X: 0.6, Y: 0.5, Scale: 1.25}
+	case "Tilt":
+		m.Start = Framing{X: 0.5, Y: 0.4, Scale: 1.25}
+		m.End = Framing{X: 0.5, Y: 0.6, Scale: 1.25}
+	case "Truck":
+		m.Start = Framing{X: 0.35, Y: 0.5, Scale: 1.4}
+		m.End = Framing{X: 0.65, Y: 0.5, Scale: 1.4}
+	case "Zoom":
+		m.End.Scale = 1.3
+	case "Dolly":
+		m.End.Scale = 1.6
+	default:
+		return nil
+	}
+	return m
+}
+
+// EffectiveMotion returns the panel's explicit motion, or the default for
+// its CameraMove. Nil means the panel holds still.
+func (p Panel) EffectiveMotion() *CameraMotion {
+	if p.Motion != nil {
+		return p.Motion
+	}
+	return DefaultMotion(p.CameraMove)
+}
@@ -2,16 +2,48 @@ package models
 
 // Panel represents a single storyboard panel/frame
 type Panel struct {
-	ID           string   `json:"id"`
-	Order        int      `json:"order"`
-	ImageData    string   `json:"image_data"`     // base64 encoded image or file path
-	ActionNotes  string   `json:"action_notes"`
-	Dialogue     string   `json:"dialogue"`
-	ShotType     string   `json:"shot_type"`      // Wide, Medium, Close-up, Extreme Close-up
-	CameraAngle  string   `json:"camera_angle"`   // Eye-level, Low, High, Dutch
-	CameraMove   string   `json:"camera_move"`    // Static, Pan, Tilt, Zoom, Dolly, Truck
-	Duration     float64  `json:"duration"`       // in seconds
-	CharacterIDs []string `json:"character_ids"`  // IDs of characters in this panel
+	ID           string            `json:"id"`
+	Order        int               `json:"order"`
+	ImageData    string            `json:"image_data"` // base64 encoded image, file path or "asset:<hash>" reference
+	ActionNotes  string            `json:"action_notes"`
+	Dialogue     string            `json:"dialogue"`
+	ShotType     string            `json:"shot_type"`             // Wide, Medium, Close-up, Extreme Close-up
+	CameraAngle  string            `json:"camera_angle"`          // Eye-level, Low, High, Dutch
+	CameraMove   string            `json:"camera_move"`           // Static, Pan, Tilt, Zoom, Dolly, Truck
+	Duration     float64           `json:"duration"`              // in seconds
+	CharacterIDs []string          `json:"character_ids"`         // IDs of characters in this panel
+	SceneID      string            `json:"scene_id,omitempty"`    // scene this panel belongs to, empty if unassigned
+	Source       *ScriptRef        `json:"source,omitempty"`      // script line this panel was generated from
+	Metadata     map[string]string `json:"metadata,omitempty"`    // free-form tags such as imported scene numbers
+	AudioClips   []AudioClip       `json:"audio_clips,omitempty"` // dialogue and SFX, offset from the panel start
+	Motion       *CameraMotion     `json:"motion,omitempty"`      // explicit camera framing; nil uses the CameraMove default
+	Transition   *Transition       `json:"transition,omitempty"`  // how this panel is cut to; nil is a cut
+	Fit          string            `json:"fit,omitempty"`         // fit, fill or crop when the image shape differs; empty is fit
+	Focus        *FocalPoint       `json:"focus,omitempty"`       // point kept in view by fill and crop; nil is the center
+}
+
+// DefaultDuration is how long a panel without a positive Duration plays, in seconds
+const DefaultDuration = 3.0
+
+// PlayDuration returns how long the panel plays in seconds: its Duration, or
+// DefaultDuration when that is not positive
+func (p Panel) PlayDuration() float64 {
+	if p.Duration <= 0 {
+		return DefaultDuration
+	}
+	return p.Duration
+}
+
+// Well-known Panel.Metadata keys
+const (
+	MetaSceneNumber   = "scene_number"   // script scene number, e.g. "12A"
+	MetaRevisionColor = "revision_color" // screenplay revision color, e.g. "Blue"
+)
+
+// ScriptRef points back at the screenplay line a panel was imported from
+type ScriptRef struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
 }
 
 // NewPanel creates a new panel with the given order
@@ -25,7 +57,7 @@ func NewPanel(order int) *Panel {
 		ShotType:     "Medium",
 		CameraAngle:  "Eye-level",
 		CameraMove:   "Static",
-		Duration:     3.0,
+		Duration:     DefaultDuration,
 		CharacterIDs: []string{},
 	}
 }
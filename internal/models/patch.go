@@ -0,0 +1,263 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Values the panel editor offers for the shot fields
+var (
+	ShotTypes    = []string{"Wide", "Medium", "Close-up", "Extreme Close-up"}
+	CameraAngles = []string{"Eye-level", "Low", "High", "Dutch"}
+	CameraMoves  = []string{"Static", "Pan", "Tilt", "Zoom", "Dolly", "Truck"}
+)
+
+// PanelPatch is a partial update of a panel. Nil fields are left unchanged.
+// Motion, transition and focus have no "unset" value of their own, so the
+// Clear flags reset them to their defaults; in JSON that is a null value.
+type PanelPatch struct {
+	ActionNotes  *string       `json:"action_notes,omitempty"`
+	Dialogue     *string       `json:"dialogue,omitempty"`
+	ShotType     *string       `json:"shot_type,omitempty"`
+	CameraAngle  *string       `json:"camera_angle,omitempty"`
+	CameraMove   *string       `json:"camera_move,omitempty"`
+	Duration     *float64      `json:"duration,omitempty"`
+	ImageData    *string       `json:"image_data,omitempty"`
+	CharacterIDs *[]string     `json:"character_ids,omitempty"`
+	Motion       *CameraMotion `json:"motion,omitempty"`
+	Transition   *Transition   `json:"transition,omitempty"`
+	Fit          *string       `json:"fit,omitempty"`
+	Focus        *FocalPoint   `json:"focus,omitempty"`
+
+	ClearMotion     bool `json:"-"`
+	ClearTransition bool `json:"-"`
+	ClearFocus      bool `json:"-"`
+}
+
+// FieldError reports an invalid value for one field of a patch
+type FieldError struct {
+	Field   string `json:"field"` // JSON name of the field, empty for the patch as a whole
+	Message string `json:"message"`
+}
+
+// FieldErrors lists every problem found in a patch
+type FieldErrors []FieldError
+
+func (e FieldErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		if fe.Field == "" {
+			msgs[i] = fe.Message
+		} else {
+			msgs[i] = fe.Field + ": " + fe.Message
+		}
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e *FieldErrors) add(field, format string, args ...interface{}) {
+	*e = append(*e, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// DecodePanelPatch reads a patch from a JSON object of panel fields. Unknown
+// fields and values of the wrong type are reported rather than ignored.
+func DecodePanelPatch(data []byte) (PanelPatch, FieldErrors) {
+	var patch PanelPatch
+	var errs FieldErrors
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil || fields == nil {
+		errs.add("", "patch must be a JSON object of panel fields")
+		return patch, errs
+	}
+
+	// Report fields in a stable order
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		raw := fields[name]
+		null := string(raw) == "null"
+		var ok bool
+		switch name {
+		case "action_notes":
+			ok = decodeField(raw, &patch.ActionNotes)
+		case "dialogue":
+			ok = decodeField(raw, &patch.Dialogue)
+		case "shot_type":
+			ok = decodeField(raw, &patch.ShotType)
+		case "camera_angle":
+			ok = decodeField(raw, &patch.CameraAngle)
+		case "camera_move":
+			ok = decodeField(raw, &patch.CameraMove)
+		case "duration":
+			ok = decodeField(raw, &patch.Duration)
+		case "image_data":
+			ok = decodeField(raw, &patch.ImageData)
+		case "character_ids":
+			ok = decodeField(raw, &patch.CharacterIDs)
+		case "fit":
+			ok = decodeField(raw, &patch.Fit)
+		case "motion":
+			patch.ClearMotion = null
+			ok = null || decodeField(raw, &patch.Motion)
+		case "transition":
+			patch.ClearTransition = null
+			ok = null || decodeField(raw, &patch.Transition)
+		case "focus":
+			patch.ClearFocus = null
+			ok = null || decodeField(raw, &patch.Focus)
+		default:
+			errs.add(name, "unknown field")
+			continue
+		}
+		if !ok {
+			errs.add(name, "must be %s", fieldKinds[name])
+		}
+	}
+	return patch, errs
+}
+
+// fieldKinds describes the JSON value each patch field takes
+var fieldKinds = map[string]string{
+	"action_notes":  "a string",
+	"dialogue":      "a string",
+	"shot_type":     "a string",
+	"camera_angle":  "a string",
+	"camera_move":   "a string",
+	"duration":      "a number",
+	"image_data":    "a string",
+	"character_ids": "a list of character IDs",
+	"fit":           "a string",
+	"motion":        "a camera motion or null",
+	"transition":    "a transition or null",
+	"focus":         "a focal point or null",
+}
+
+// decodeField decodes one non-null field value into target
+func decodeField[T any](raw json.RawMessage, target **T) bool {
+	if string(raw) == "null" {
+		return false
+	}
+	var v T
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return false
+	}
+	*target = &v
+	return true
+}
+
+// Validate checks the patch values against project, which supplies the
+// characters a panel may reference
+func (p PanelPatch) Validate(project *Project) FieldErrors {
+	var errs FieldErrors
+	oneOf := func(field string, value *string, allowed []string) {
+		if value != nil && !slices.Contains(allowed, *value) {
+			errs.add(field, "must be one of %s", strings.Join(allowed, ", "))
+		}
+	}
+	oneOf("shot_type", p.ShotType, ShotTypes)
+	oneOf("camera_angle", p.CameraAngle, CameraAngles)
+	oneOf("camera_move", p.CameraMove, CameraMoves)
+	oneOf("fit", p.Fit, []string{"", FitContain, FitFill, FitCrop})
+
+	if p.Duration != nil && *p.Duration < 0 {
+		errs.add("duration", "must not be negative")
+	}
+
+	if p.CharacterIDs != nil {
+		known := make(map[string]bool, len(project.Characters))
+		for _, char := range project.Characters {
+			known[char.ID] = true
+		}
+		var unknown []string
+		for _, id := range *p.CharacterIDs {
+			if !known[id] {
+				unknown = append(unknown, id)
+			}
+		}
+		if len(unknown) > 0 {
+			errs.add("character_ids", "unknown character %s", strings.Join(unknown, ", "))
+		}
+	}
+
+	if m := p.Motion; m != nil {
+		easings := []string{EaseLinear, EaseIn, EaseOut, EaseInOut}
+		if m.Easing != "" && !slices.Contains(easings, m.Easing) {
+			errs.add("motion", "easing must be one of %s", strings.Join(easings, ", "))
+		}
+		for _, f := range []struct {
+			name string
+			Framing
+		}{{"start", m.Start}, {"end", m.End}} {
+			// Written to reject NaN as well
+			if !(f.X >= 0 && f.X <= 1 && f.Y >= 0 && f.Y <= 1) {
+				errs.add("motion", "%s must lie within the image (0 to 1)", f.name)
+			}
+			if !(f.Scale >= 1 && f.Scale <= MaxScale) {
+				errs.add("motion", "%s scale must be from 1 to %g", f.name, MaxScale)
+			}
+		}
+	}
+
+	if t := p.Transition; t != nil {
+		types := []string{TransitionCut, TransitionDissolve, TransitionFadeFromBlack, TransitionFadeToBlack, TransitionWipe}
+		if !slices.Contains(types, t.Type) {
+			errs.add("transition", "type must be one of %s", strings.Join(types, ", "))
+		}
+		if t.Duration < 0 {
+			errs.add("transition", "duration must not be negative")
+		}
+	}
+
+	if f := p.Focus; f != nil && (f.X < 0 || f.X > 1 || f.Y < 0 || f.Y > 1) {
+		errs.add("focus", "must lie within the image (0 to 1)")
+	}
+	return errs
+}
+
+// Apply sets the fields present in the patch on panel
+func (p PanelPatch) Apply(panel *Panel) {
+	set := func(dst *string, src *string) {
+		if src != nil {
+			*dst = *src
+		}
+	}
+	set(&panel.ActionNotes, p.ActionNotes)
+	set(&panel.Dialogue, p.Dialogue)
+	set(&panel.ShotType, p.ShotType)
+	set(&panel.CameraAngle, p.CameraAngle)
+	set(&panel.CameraMove, p.CameraMove)
+	set(&panel.ImageData, p.ImageData)
+	set(&panel.Fit, p.Fit)
+	if p.Duration != nil {
+		panel.Duration = *p.Duration
+	}
+	if p.CharacterIDs != nil {
+		panel.CharacterIDs = append([]string{}, *p.CharacterIDs...)
+	}
+
+	if p.Motion != nil || p.ClearMotion {
+		panel.Motion = clonePtr(p.Motion)
+	}
+	if p.Transition != nil || p.ClearTransition {
+		panel.Transition = clonePtr(p.Transition)
+	}
+	if p.Focus != nil || p.ClearFocus {
+		panel.Focus = clonePtr(p.Focus)
+	}
+}
+
+func clonePtr[T any](v *T) *T {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	return &c
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestDecodePanelPatch(t *testing.T) {
+	patch, errs := DecodePanelPatch([]byte(`{"dialogue": "Hi", "duration": 2.5, "character_ids": ["c1"], "motion": null}`))
+	if errs != nil {
+		t.Fatalf("errors = %v", errs)
+	}
+	if patch.Dialogue == nil || *patch.Dialogue != "Hi" || patch.Duration == nil || *patch.Duration != 2.5 {
+		t.Errorf("patch = %+v, want the dialogue and duration set", patch)
+	}
+	if patch.CharacterIDs == nil || !reflect.DeepEqual(*patch.CharacterIDs, []string{"c1"}) {
+		t.Errorf("character IDs = %v", patch.CharacterIDs)
+	}
+	if !patch.ClearMotion || patch.Motion != nil || patch.ClearTransition || patch.ShotType != nil {
+		t.Errorf("patch = %+v, want only motion cleared besides the set fields", patch)
+	}
+
+	tests := []struct {
+		name   string
+		in     string
+		fields []string // fields reported, in order
+	}{
+		{"not an object", `[1, 2]`, []string{""}},
+		{"null", `null`, []string{""}},
+		{"malformed", `{"dialogue":`, []string{""}},
+		{"unknown field", `{"colour": "red"}`, []string{"colour"}},
+		{"wrong type", `{"duration": "2"}`, []string{"duration"}},
+		{"null string", `{"dialogue": null}`, []string{"dialogue"}},
+		{"several, sorted", `{"shot_type": 1, "action_notes": 2, "zoom": 3}`, []string{"action_notes", "shot_type", "zoom"}},
+		{"bad motion", `{"motion": {"start": "left"}}`, []string{"motion"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errs := DecodePanelPatch([]byte(tt.in))
+			var fields []string
+			for _, e := range errs {
+				fields = append(fields, e.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("errors = %v, want fields %q", errs, tt.fields)
+			}
+		})
+	}
+}
+
+func TestPanelPatchValidate(t *testing.T) {
+	project := NewProject("patch")
+	project.Characters = []Character{{ID: "c1", Name: "Ada"}}
+
+	str := func(s string) *string { return &s }
+	num := func(f float64) *float64 { return &f }
+	ids := func(ids ...string) *[]string { return &ids }
+	center := Framing{X: 0.5, Y: 0.5, Scale: 1}
+	motion := func(start, end Framing) *CameraMotion {
+		return &CameraMotion{Start: start, End: end, Easing: EaseLinear}
+	}
+
+	tests := []struct {
+		name  string
+		patch PanelPatch
+		field string // empty for a valid patch
+	}{
+		{"empty", PanelPatch{}, ""},
+		{"known values", PanelPatch{ShotType: str("Wide"), CameraAngle: str("Low"), CameraMove: str("Pan"), Fit: str(FitFill)}, ""},
+		{"unknown shot type", PanelPatch{ShotType: str("Tight")}, "shot_type"},
+		{"unknown camera angle", PanelPatch{CameraAngle: str("Side")}, "camera_angle"},
+		{"unknown camera move", PanelPatch{CameraMove: str("Crane")}, "camera_move"},
+		{"unknown fit", PanelPatch{Fit: str("stretch")}, "fit"},
+		{"zero duration", PanelPatch{Duration: num(0)}, ""},
+		{"negative duration", PanelPatch{Duration: num(-1)}, "duration"},
+		{"known character", PanelPatch{CharacterIDs: ids("c1")}, ""},
+		{"unknown character", PanelPatch{CharacterIDs: ids("c1", "c2")}, "character_ids"},
+		{"motion", PanelPatch{Motion: motion(Framing{X: 0, Y: 1, Scale: 1}, Framing{X: 1, Y: 0, Scale: MaxScale})}, ""},
+		{"motion easing", PanelPatch{Motion: &CameraMotion{Start: center, End: center, Easing: "bounce"}}, "motion"},
+		{"motion start left of the image", PanelPatch{Motion: motion(Framing{X: -0.1, Y: 0.5, Scale: 1}, center)}, "motion"},
+		{"motion end below the image", PanelPatch{Motion: motion(center, Framing{X: 0.5, Y: 1.5, Scale: 1})}, "motion"},
+		{"motion position NaN", PanelPatch{Motion: motion(Framing{X: math.NaN(), Y: 0.5, Scale: 1}, center)}, "motion"},
+		{"motion scale below 1", PanelPatch{Motion: motion(Framing{X: 0.5, Y: 0.5, Scale: 0.5}, center)}, "motion"},
+		{"motion scale zero", PanelPatch{Motion: motion(center, Framing{X: 0.5, Y: 0.5})}, "motion"},
+		{"motion scale too large", PanelPatch{Motion: motion(center, Framing{X: 0.5, Y: 0.5, Scale: MaxScale + 1})}, "motion"},
+		{"motion scale infinite", PanelPatch{Motion: motion(center, Framing{X: 0.5, Y: 0.5, Scale: math.Inf(1)})}, "motion"},
+		{"transition", PanelPatch{Transition: &Transition{Type: TransitionDissolve, Duration: 1}}, ""},
+		{"unknown transition", PanelPatch{Transition: &Transition{Type: "iris"}}, "transition"},
+		{"negative transition", PanelPatch{Transition: &Transition{Type: TransitionWipe, Duration: -1}}, "transition"},
+		{"focus", PanelPatch{Focus: &FocalPoint{X: 1, Y: 0}}, ""},
+		{"focus outside the image", PanelPatch{Focus: &FocalPoint{X: 1.2, Y: 0.5}}, "focus"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.patch.Validate(project)
+			if tt.field == "" {
+				if errs != nil {
+					t.Errorf("errors = %v, want none", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Field != tt.field {
+				t.Errorf("errors = %v, want one for %s", errs, tt.field)
+			}
+		})
+	}
+}
@@ -2,15 +2,29 @@ package models
 
 import "time"
 
+// SchemaVersion is the project file format written by this build. Bump it
+// together with a migration in the storage package whenever a change to the
+// models would misread older files.
+const SchemaVersion = 1
+
 // Project represents a storyboard project
 type Project struct {
-	Name         string      `json:"name"`
-	CreatedAt    time.Time   `json:"created_at"`
-	ModifiedAt   time.Time   `json:"modified_at"`
-	AspectRatio  string      `json:"aspect_ratio"`  // e.g., "16:9", "4:3"
-	FrameRate    int         `json:"frame_rate"`    // frames per second
-	Panels       []Panel     `json:"panels"`
-	Characters   []Character `json:"characters"`
+	SchemaVersion int         `json:"schema_version"` // file format version, see SchemaVersion
+	Name          string      `json:"name"`
+	CreatedAt     time.Time   `json:"created_at"`
+	ModifiedAt    time.Time   `json:"modified_at"`
+	AspectRatio   string      `json:"aspect_ratio"` // e.g., "16:9", "4:3"
+	FrameRate     int         `json:"frame_rate"`   // frames per second
+	Panels        []Panel     `json:"panels"`
+	Characters    []Character `json:"characters"`
+	Sequences     []Sequence  `json:"sequences"`
+	Scenes        []Scene     `json:"scenes"`
+	AudioTracks   []AudioClip `json:"audio_tracks"`          // music and temp score under the whole board
+	MatteColor    string      `json:"matte_color,omitempty"` // letterbox/pillarbox bars, "#rrggbb"; empty is black
+
+	// NeedsMigration is set when the file was loaded from an older format.
+	// It is upgraded in memory only; the next save writes the new format.
+	NeedsMigration bool `json:"-"`
 }
 
 // NewProject creates a new project with default settings
@@ -25,12 +39,16 @@ func NewProject(name string) *Project {
 	}
 
 	return &Project{
-		Name:        name,
-		CreatedAt:   now,
-		ModifiedAt:  now,
-		AspectRatio: "16:9",
-		FrameRate:   24,
-		Panels:      defaultPanels,
-		Characters:  []Character{},
+		SchemaVersion: SchemaVersion,
+		Name:          name,
+		CreatedAt:     now,
+		ModifiedAt:    now,
+		AspectRatio:   "16:9",
+		FrameRate:     24,
+		Panels:        defaultPanels,
+		Characters:    []Character{},
+		Sequences:     []Sequence{},
+		Scenes:        []Scene{},
+		AudioTracks:   []AudioClip{},
 	}
 }
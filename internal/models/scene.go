@@ -0,0 +1,129 @@
+package models
+
+import (
+	"sort"
+	"strings"
+)
+
+// Sequence groups consecutive scenes, e.g. an act or a reel
+type Sequence struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Order int    `json:"order"`
+}
+
+// SceneHeading is the slugline of a scene, e.g. "INT. KITCHEN - NIGHT"
+type SceneHeading struct {
+	Setting   string `json:"setting"`     // INT, EXT, INT/EXT
+	Location  string `json:"location"`    // e.g. KITCHEN
+	TimeOfDay string `json:"time_of_day"` // DAY, NIGHT, CONTINUOUS, ...
+}
+
+// Scene is a group of panels sharing one location and time
+type Scene struct {
+	ID         string       `json:"id"`
+	SequenceID string       `json:"sequence_id,omitempty"` // empty when not part of a sequence
+	Number     string       `json:"number"`                // script scene number, e.g. "12A"
+	Heading    SceneHeading `json:"heading"`
+	Order      int          `json:"order"`
+	Notes      string       `json:"notes,omitempty"`
+}
+
+// SceneGroup is a scene together with its panels in playback order.
+// Scene is nil for the group of panels not assigned to any scene.
+type SceneGroup struct {
+	Scene  *Scene
+	Panels []Panel
+}
+
+// NewSequence creates a new sequence with the given order
+func NewSequence(name string, order int) *Sequence {
+	return &Sequence{
+		ID:    generateID(),
+		Name:  name,
+		Order: order,
+	}
+}
+
+// NewScene creates a new scene with the given order
+func NewScene(order int, heading SceneHeading) *Scene {
+	return &Scene{
+		ID:      generateID(),
+		Heading: heading,
+		Order:   order,
+	}
+}
+
+// String formats the heading as a screenplay slugline
+func (h SceneHeading) String() string {
+	var b strings.Builder
+	if h.Setting != "" {
+		b.WriteString(h.Setting)
+		b.WriteString(". ")
+	}
+	b.WriteString(h.Location)
+	if h.TimeOfDay != "" {
+		if h.Location != "" {
+			b.WriteString(" - ")
+		}
+		b.WriteString(h.TimeOfDay)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// SortedScenes returns the project's scenes in playback order:
+// by sequence order, then scene order. Scenes outside any sequence come last.
+func (p *Project) SortedScenes() []Scene {
+	seqRank := make(map[string]int, len(p.Sequences))
+	for _, seq := range p.Sequences {
+		seqRank[seq.ID] = seq.Order
+	}
+	rank := func(s Scene) int {
+		if r, ok := seqRank[s.SequenceID]; ok {
+			return r
+		}
+		return len(p.Sequences)
+	}
+
+	scenes := make([]Scene, len(p.Scenes))
+	copy(scenes, p.Scenes)
+	sort.SliceStable(scenes, func(i, j int) bool {
+		ri, rj := rank(scenes[i]), rank(scenes[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return scenes[i].Order < scenes[j].Order
+	})
+	return scenes
+}
+
+// PanelsByScene groups the project's panels by scene in playback order.
+// Panels keep their relative Order inside a scene; panels without a scene
+// (or pointing at a deleted one) form a trailing group with a nil Scene.
+func (p *Project) PanelsByScene() []SceneGroup {
+	panels := make([]Panel, len(p.Panels))
+	copy(panels, p.Panels)
+	sort.SliceStable(panels, func(i, j int) bool { return panels[i].Order < panels[j].Order })
+
+	scenes := p.SortedScenes()
+	groups := make([]SceneGroup, len(scenes))
+	index := make(map[string]int, len(scenes))
+	for i := range scenes {
+		groups[i].Scene = &scenes[i]
+		index[scenes[i].ID] = i
+	}
+
+	var unassigned []Panel
+	for _, panel := range panels {
+		if i, ok := index[panel.SceneID]; ok {
+			groups[i].Panels = append(groups[i].Panels, panel)
+		} else {
+			unassigned = append(unassigned, panel)
+		}
+	}
+
+	if len(unassigned) > 0 {
+		groups = append(groups, SceneGroup{Panels: unassigned})
+	}
+	return groups
+}
@@ -0,0 +1,145 @@
+package script
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// fdxDocument mirrors the parts of a Final Draft XML file we read
+type fdxDocument struct {
+	XMLName    xml.Name       `xml:"FinalDraft"`
+	Paragraphs []fdxParagraph `xml:"Content>Paragraph"`
+	Revisions  []fdxRevision  `xml:"Revisions>Revision"`
+	TitlePage  []fdxParagraph `xml:"TitlePage>Content>Paragraph"`
+}
+
+type fdxParagraph struct {
+	Type   string         `xml:"Type,attr"`
+	Number string         `xml:"Number,attr"`
+	Texts  []fdxText      `xml:"Text"`
+	Dual   []fdxParagraph `xml:"DualDialogue>Paragraph"`
+}
+
+type fdxText struct {
+	RevisionID string `xml:"RevisionID,attr"`
+	Value      string `xml:",chardata"`
+}
+
+type fdxRevision struct {
+	ID    string `xml:"ID,attr"`
+	Color string `xml:"Color,attr"`
+	Name  string `xml:"Name,attr"`
+}
+
+// fdxTypes maps Final Draft paragraph types to element types.
+// Unknown types (General, Shot, Cast List, ...) are treated as action.
+var fdxTypes = map[string]ElementType{
+	"Scene Heading": SceneHeading,
+	"Action":        Action,
+	"Character":     Character,
+	"Dialogue":      Dialogue,
+	"Parenthetical": Parenthetical,
+	"Transition":    Transition,
+}
+
+// ParseFDX parses a Final Draft (.fdx) screenplay.
+// FDX has no source lines, so Element.Line holds the 1-based paragraph number.
+// Scene numbers come from the Number attribute of scene headings and each
+// element's Revision is the color of the newest revision touching its text.
+func ParseFDX(r io.Reader) (*Document, error) {
+	var fdx fdxDocument
+	if err := xml.NewDecoder(r).Decode(&fdx); err != nil {
+		return nil, fmt.Errorf("invalid FDX file: %w", err)
+	}
+
+	colors := make(map[string]string, len(fdx.Revisions))
+	for _, rev := range fdx.Revisions {
+		color := rev.Color
+		if color == "" {
+			color = rev.Name
+		}
+		colors[rev.ID] = color
+	}
+
+	doc := &Document{Title: map[string]string{}}
+
+	// Title page paragraphs have no keys; keep the first one as the title
+	for _, para := range fdx.TitlePage {
+		if text := strings.TrimSpace(para.text()); text != "" {
+			doc.Title["title"] = text
+			break
+		}
+	}
+
+	n := 0
+	var add func(para fdxParagraph, dual bool)
+	add = func(para fdxParagraph, dual bool) {
+		n++
+
+		// Dual dialogue wraps its own character/dialogue paragraphs;
+		// everything after the first speaker is marked dual
+		if len(para.Dual) > 0 {
+			for i, inner := range para.Dual {
+				add(inner, i > 0 || dual)
+			}
+			return
+		}
+
+		text := strings.TrimSpace(para.text())
+		if text == "" {
+			return
+		}
+
+		el := Element{
+			Type:     Action,
+			Text:     text,
+			Line:     n,
+			Revision: colors[para.revisionID()],
+		}
+		if t, ok := fdxTypes[para.Type]; ok {
+			el.Type = t
+		}
+
+		switch el.Type {
+		case SceneHeading:
+			el.SceneNumber = para.Number
+		case Character:
+			el.Dual = dual
+			if open := strings.Index(text, "("); open >= 0 {
+				el.Text = strings.TrimSpace(text[:open])
+				el.Extension = strings.Trim(strings.TrimSpace(text[open:]), "()")
+			}
+		}
+
+		doc.Elements = append(doc.Elements, el)
+	}
+
+	for _, para := range fdx.Paragraphs {
+		add(para, false)
+	}
+
+	return doc, nil
+}
+
+// text joins a paragraph's styled text runs
+func (p fdxParagraph) text() string {
+	var b strings.Builder
+	for _, t := range p.Texts {
+		b.WriteString(t.Value)
+	}
+	return b.String()
+}
+
+// revisionID returns the highest revision ID among the paragraph's text runs
+func (p fdxParagraph) revisionID() string {
+	best, bestID := -1, ""
+	for _, t := range p.Texts {
+		if id, err := strconv.Atoi(t.RevisionID); err == nil && id > best {
+			best, bestID = id, t.RevisionID
+		}
+	}
+	return bestID
+}
@@ -0,0 +1,101 @@
+package script
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFDX(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		title    string
+		elements []Element
+	}{
+		{
+			name: "scene numbers and extensions",
+			input: `<FinalDraft>
+<TitlePage><Content>
+  <Paragraph><Text></Text></Paragraph>
+  <Paragraph><Text>Big Night</Text></Paragraph>
+</Content></TitlePage>
+<Content>
+  <Paragraph Type="Scene Heading" Number="12A"><Text>INT. LAB - NIGHT</Text></Paragraph>
+  <Paragraph Type="Shot"><Text>ON THE DOOR</Text></Paragraph>
+  <Paragraph Type="Character"><Text>ADA (O.S.)</Text></Paragraph>
+  <Paragraph Type="Parenthetical"><Text>(softly)</Text></Paragraph>
+  <Paragraph Type="Dialogue"><Text>Who's </Text><Text>there?</Text></Paragraph>
+</Content>
+</FinalDraft>`,
+			title: "Big Night",
+			elements: []Element{
+				{Type: SceneHeading, Text: "INT. LAB - NIGHT", Line: 1, SceneNumber: "12A"},
+				{Type: Action, Text: "ON THE DOOR", Line: 2},
+				{Type: Character, Text: "ADA", Line: 3, Extension: "O.S."},
+				{Type: Parenthetical, Text: "(softly)", Line: 4},
+				{Type: Dialogue, Text: "Who's there?", Line: 5},
+			},
+		},
+		{
+			name: "dual dialogue",
+			input: `<FinalDraft><Content>
+  <Paragraph><DualDialogue>
+    <Paragraph Type="Character"><Text>ADA</Text></Paragraph>
+    <Paragraph Type="Dialogue"><Text>Now!</Text></Paragraph>
+    <Paragraph Type="Character"><Text>BOB</Text></Paragraph>
+    <Paragraph Type="Dialogue"><Text>Not now!</Text></Paragraph>
+  </DualDialogue></Paragraph>
+  <Paragraph Type="Character"><Text>ADA</Text></Paragraph>
+  <Paragraph Type="Dialogue"><Text>Fine.</Text></Paragraph>
+</Content></FinalDraft>`,
+			elements: []Element{
+				{Type: Character, Text: "ADA", Line: 2},
+				{Type: Dialogue, Text: "Now!", Line: 3},
+				{Type: Character, Text: "BOB", Line: 4, Dual: true},
+				{Type: Dialogue, Text: "Not now!", Line: 5},
+				{Type: Character, Text: "ADA", Line: 6},
+				{Type: Dialogue, Text: "Fine.", Line: 7},
+			},
+		},
+		{
+			name: "revision colors",
+			input: `<FinalDraft>
+<Revisions>
+  <Revision ID="1" Color="Blue" Name="First"/>
+  <Revision ID="2" Name="Pink"/>
+</Revisions>
+<Content>
+  <Paragraph Type="Action"><Text RevisionID="1">She runs.</Text></Paragraph>
+  <Paragraph Type="Action"><Text>  </Text></Paragraph>
+  <Paragraph Type="Action"><Text RevisionID="2">He </Text><Text RevisionID="1">follows.</Text></Paragraph>
+</Content>
+</FinalDraft>`,
+			elements: []Element{
+				{Type: Action, Text: "She runs.", Line: 1, Revision: "Blue"},
+				{Type: Action, Text: "He follows.", Line: 3, Revision: "Pink"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := ParseFDX(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if doc.Title["title"] != tt.title {
+				t.Errorf("title = %q, want %q", doc.Title["title"], tt.title)
+			}
+			if !reflect.DeepEqual(doc.Elements, tt.elements) {
+				t.Errorf("elements:\n got %+v\nwant %+v", doc.Elements, tt.elements)
+			}
+		})
+	}
+}
+
+func TestParseFDXInvalid(t *testing.T) {
+	if _, err := ParseFDX(strings.NewReader("<Screenplay></Screenplay>")); err == nil {
+		t.Fatal("expected an error for a document that is not Final Draft")
+	}
+}
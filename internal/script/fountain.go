@@ -0,0 +1,253 @@
+package script
+
+import (
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var (
+	fountainSceneHeading = regexp.MustCompile(`(?i)^(INT|EXT|EST|INT\.?/EXT|I/E)[. ]`)
+	fountainSceneNumber  = regexp.MustCompile(`\s*#([^#\s]+)#\s*$`)
+	fountainTitleKey     = regexp.MustCompile(`^([A-Za-z][A-Za-z ]*):(.*)$`)
+	fountainPageBreak    = regexp.MustCompile(`^={3,}$`)
+	fountainBoneyard     = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	fountainNote         = regexp.MustCompile(`(?s)\[\[(.*?)\]\]`)
+)
+
+// ParseFountain parses a Fountain screenplay (https://fountain.io/syntax).
+// Boneyard sections are dropped, notes become Note elements and every
+// element keeps the line number it starts on.
+func ParseFountain(r io.Reader) (*Document, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	text := strings.ReplaceAll(string(raw), "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	text = strings.TrimPrefix(text, "\ufeff")
+
+	// Blank out boneyard and notes while keeping line breaks so line numbers stay true
+	text = fountainBoneyard.ReplaceAllStringFunc(text, keepNewlines)
+	var notes []Element
+	for _, m := range fountainNote.FindAllStringSubmatchIndex(text, -1) {
+		notes = append(notes, Element{
+			Type: Note,
+			Text: strings.TrimSpace(text[m[2]:m[3]]),
+			Line: strings.Count(text[:m[0]], "\n") + 1,
+		})
+	}
+	text = fountainNote.ReplaceAllStringFunc(text, keepNewlines)
+
+	p := &fountainParser{
+		lines: strings.Split(text, "\n"),
+		doc:   &Document{Title: map[string]string{}},
+	}
+	p.parseTitlePage()
+	p.parseBody()
+
+	// Merge notes in by line, ahead of elements starting on the same line
+	elements := append(notes, p.doc.Elements...)
+	sort.SliceStable(elements, func(i, j int) bool { return elements[i].Line < elements[j].Line })
+	p.doc.Elements = elements
+
+	return p.doc, nil
+}
+
+// keepNewlines replaces a match with just its line breaks
+func keepNewlines(s string) string {
+	return strings.Repeat("\n", strings.Count(s, "\n"))
+}
+
+type fountainParser struct {
+	lines []string
+	pos   int
+	doc   *Document
+}
+
+// blank reports whether line i is blank (or outside the file)
+func (p *fountainParser) blank(i int) bool {
+	return i < 0 || i >= len(p.lines) || strings.TrimSpace(p.lines[i]) == ""
+}
+
+func (p *fountainParser) add(t ElementType, text string, line int) *Element {
+	p.doc.Elements = append(p.doc.Elements, Element{Type: t, Text: text, Line: line + 1})
+	return &p.doc.Elements[len(p.doc.Elements)-1]
+}
+
+// parseTitlePage reads "Key: value" pairs at the top of the file
+func (p *fountainParser) parseTitlePage() {
+	i := 0
+	for i < len(p.lines) && p.blank(i) {
+		i++
+	}
+	if i >= len(p.lines) || !fountainTitleKey.MatchString(p.lines[i]) {
+		return
+	}
+
+	key := ""
+	for ; i < len(p.lines) && !p.blank(i); i++ {
+		line := p.lines[i]
+		if m := fountainTitleKey.FindStringSubmatch(line); m != nil && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			key = strings.ToLower(strings.TrimSpace(m[1]))
+			p.doc.Title[key] = strings.TrimSpace(m[2])
+			continue
+		}
+		// Indented continuation of a multi-line value
+		if key != "" {
+			value := strings.TrimSpace(line)
+			if p.doc.Title[key] != "" {
+				value = p.doc.Title[key] + "\n" + value
+			}
+			p.doc.Title[key] = value
+		}
+	}
+	p.pos = i
+}
+
+func (p *fountainParser) parseBody() {
+	for p.pos < len(p.lines) {
+		i := p.pos
+		line := strings.TrimRight(p.lines[i], " \t")
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			p.pos++
+
+		case fountainPageBreak.MatchString(trimmed):
+			p.add(PageBreak, "", i)
+			p.pos++
+
+		case strings.HasPrefix(trimmed, "#"):
+			p.add(Section, strings.TrimSpace(strings.TrimLeft(trimmed, "#")), i)
+			p.pos++
+
+		case strings.HasPrefix(trimmed, "="):
+			p.add(Synopsis, strings.TrimSpace(trimmed[1:]), i)
+			p.pos++
+
+		case strings.HasPrefix(trimmed, "!"):
+			p.parseAction(i, true)
+
+		case strings.HasPrefix(trimmed, ".") && !strings.HasPrefix(trimmed, "..") && len(trimmed) > 1:
+			p.parseSceneHeading(trimmed[1:], i)
+
+		case p.blank(i-1) && fountainSceneHeading.MatchString(trimmed):
+			p.parseSceneHeading(trimmed, i)
+
+		case strings.HasPrefix(trimmed, ">") && !strings.HasSuffix(trimmed, "<"):
+			p.add(Transition, strings.TrimSpace(trimmed[1:]), i)
+			p.pos++
+
+		case p.blank(i-1) && p.blank(i+1) && isUpper(trimmed) && strings.HasSuffix(trimmed, "TO:"):
+			p.add(Transition, trimmed, i)
+			p.pos++
+
+		case strings.HasPrefix(trimmed, "@") || (p.blank(i-1) && !p.blank(i+1) && isCharacterCue(trimmed)):
+			p.parseDialogue(trimmed, i)
+
+		default:
+			p.parseAction(i, false)
+		}
+	}
+}
+
+func (p *fountainParser) parseSceneHeading(text string, i int) {
+	number := ""
+	if m := fountainSceneNumber.FindStringSubmatch(text); m != nil {
+		number = m[1]
+		text = text[:len(text)-len(m[0])]
+	}
+	el := p.add(SceneHeading, strings.TrimSpace(text), i)
+	el.SceneNumber = number
+	p.pos++
+}
+
+// parseAction collects an action paragraph up to the next blank line
+func (p *fountainParser) parseAction(start int, forced bool) {
+	var lines []string
+	for p.pos < len(p.lines) && !p.blank(p.pos) {
+		line := strings.TrimRight(p.lines[p.pos], " \t")
+		if p.pos == start && forced {
+			line = strings.TrimPrefix(strings.TrimSpace(line), "!")
+		}
+		// Centered text: > THE END <
+		if t := strings.TrimSpace(line); strings.HasPrefix(t, ">") && strings.HasSuffix(t, "<") {
+			line = strings.TrimSpace(t[1 : len(t)-1])
+		}
+		lines = append(lines, line)
+		p.pos++
+	}
+	p.add(Action, strings.Join(lines, "\n"), start)
+}
+
+// parseDialogue reads a character cue followed by parentheticals and dialogue
+func (p *fountainParser) parseDialogue(cue string, i int) {
+	cue = strings.TrimPrefix(cue, "@")
+	dual := strings.HasSuffix(cue, "^")
+	cue = strings.TrimSpace(strings.TrimSuffix(cue, "^"))
+
+	name, ext := cue, ""
+	if open := strings.Index(cue, "("); open >= 0 {
+		name = strings.TrimSpace(cue[:open])
+		ext = strings.Trim(strings.TrimSpace(cue[open:]), "()")
+	}
+
+	el := p.add(Character, name, i)
+	el.Extension = ext
+	el.Dual = dual
+	p.pos++
+
+	var speech []string
+	speechStart := 0
+	flush := func() {
+		if len(speech) > 0 {
+			p.add(Dialogue, strings.Join(speech, "\n"), speechStart)
+			speech = nil
+		}
+	}
+
+	// A line holding exactly two spaces keeps the dialogue block going
+	for p.pos < len(p.lines) && (!p.blank(p.pos) || p.lines[p.pos] == "  ") {
+		line := strings.TrimSpace(p.lines[p.pos])
+		if strings.HasPrefix(line, "(") && strings.HasSuffix(line, ")") {
+			flush()
+			p.add(Parenthetical, line, p.pos)
+		} else {
+			if len(speech) == 0 {
+				speechStart = p.pos
+			}
+			speech = append(speech, strings.TrimPrefix(line, "~"))
+		}
+		p.pos++
+	}
+	flush()
+}
+
+// isCharacterCue reports whether a line looks like a character name in caps
+func isCharacterCue(line string) bool {
+	name := line
+	if open := strings.Index(line, "("); open >= 0 {
+		name = line[:open]
+	}
+	name = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(name), "^"))
+	return name != "" && isUpper(name)
+}
+
+// isUpper reports whether s has at least one letter and no lower-case letters
+func isUpper(s string) bool {
+	letters := false
+	for _, r := range s {
+		if unicode.IsLower(r) {
+			return false
+		}
+		if unicode.IsLetter(r) {
+			letters = true
+		}
+	}
+	return letters
+}
@@ -0,0 +1,123 @@
+package script
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFountain(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		title    map[string]string
+		elements []Element
+	}{
+		{
+			name: "title page continuation",
+			input: "Title: Big Night\n" +
+				"Credit: Written by\n" +
+				"Author:\n" +
+				"    Ann Lee\n" +
+				"    Bob Ray\n" +
+				"\n" +
+				"EXT. PARK - DAY\n",
+			title: map[string]string{
+				"title":  "Big Night",
+				"credit": "Written by",
+				"author": "Ann Lee\nBob Ray",
+			},
+			elements: []Element{
+				{Type: SceneHeading, Text: "EXT. PARK - DAY", Line: 7},
+			},
+		},
+		{
+			name: "boneyard and notes keep line numbers",
+			input: "INT. HOUSE - DAY\n" +
+				"\n" +
+				"/* An old\n" +
+				"scene */\n" +
+				"He runs. [[faster]]\n" +
+				"\n" +
+				"[[check\n" +
+				"this]]\n" +
+				"She waits.\n",
+			elements: []Element{
+				{Type: SceneHeading, Text: "INT. HOUSE - DAY", Line: 1},
+				{Type: Note, Text: "faster", Line: 5},
+				{Type: Action, Text: "He runs.", Line: 5},
+				{Type: Note, Text: "check\nthis", Line: 7},
+				{Type: Action, Text: "She waits.", Line: 9},
+			},
+		},
+		{
+			name: "forced elements",
+			input: ".FLASHBACK\n" +
+				"\n" +
+				"!LOUD NOISES\n" +
+				"\n" +
+				"@McCLANE\n" +
+				"Yippee.\n" +
+				"\n" +
+				"> BURN TO WHITE.\n" +
+				"\n" +
+				"> THE END <\n",
+			elements: []Element{
+				{Type: SceneHeading, Text: "FLASHBACK", Line: 1},
+				{Type: Action, Text: "LOUD NOISES", Line: 3},
+				{Type: Character, Text: "McCLANE", Line: 5},
+				{Type: Dialogue, Text: "Yippee.", Line: 6},
+				{Type: Transition, Text: "BURN TO WHITE.", Line: 8},
+				{Type: Action, Text: "THE END", Line: 10},
+			},
+		},
+		{
+			name: "dual dialogue",
+			input: "BRICK (V.O.)\n" +
+				"Screw retirement.\n" +
+				"\n" +
+				"STEEL ^\n" +
+				"(quietly)\n" +
+				"Screw retirement.\n",
+			elements: []Element{
+				{Type: Character, Text: "BRICK", Line: 1, Extension: "V.O."},
+				{Type: Dialogue, Text: "Screw retirement.", Line: 2},
+				{Type: Character, Text: "STEEL", Line: 4, Dual: true},
+				{Type: Parenthetical, Text: "(quietly)", Line: 5},
+				{Type: Dialogue, Text: "Screw retirement.", Line: 6},
+			},
+		},
+		{
+			name: "scene numbers",
+			input: "INT. HOUSE - DAY #1A#\n" +
+				"\n" +
+				".DREAM #I-1#\n" +
+				"\n" +
+				"EXT. ROAD - NIGHT\n",
+			elements: []Element{
+				{Type: SceneHeading, Text: "INT. HOUSE - DAY", Line: 1, SceneNumber: "1A"},
+				{Type: SceneHeading, Text: "DREAM", Line: 3, SceneNumber: "I-1"},
+				{Type: SceneHeading, Text: "EXT. ROAD - NIGHT", Line: 5},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := ParseFountain(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			title := tt.title
+			if title == nil {
+				title = map[string]string{}
+			}
+			if !reflect.DeepEqual(doc.Title, title) {
+				t.Errorf("title = %q, want %q", doc.Title, title)
+			}
+			if !reflect.DeepEqual(doc.Elements, tt.elements) {
+				t.Errorf("elements:\n got %+v\nwant %+v", doc.Elements, tt.elements)
+			}
+		})
+	}
+}
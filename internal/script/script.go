@@ -0,0 +1,69 @@
+// Package script parses screenplay formats into a flat list of elements
+// that the app turns into scenes, panels and characters.
+package script
+
+import (
+	"strings"
+
+	"storyboard_flow/internal/models"
+)
+
+// ElementType identifies a screenplay element
+type ElementType string
+
+const (
+	SceneHeading  ElementType = "scene_heading"
+	Action        ElementType = "action"
+	Character     ElementType = "character"
+	Dialogue      ElementType = "dialogue"
+	Parenthetical ElementType = "parenthetical"
+	Transition    ElementType = "transition"
+	Note          ElementType = "note"
+	Section       ElementType = "section"
+	Synopsis      ElementType = "synopsis"
+	PageBreak     ElementType = "page_break"
+)
+
+// Element is one paragraph of a screenplay
+type Element struct {
+	Type        ElementType
+	Text        string
+	Line        int    // 1-based source line where the element starts (paragraph number for FDX)
+	SceneNumber string // scene headings only, e.g. "12A"
+	Extension   string // character cues only, e.g. "V.O."
+	Dual        bool   // character cues only, dual dialogue
+	Revision    string // revision color, when the format records one
+}
+
+// Document is a parsed screenplay
+type Document struct {
+	Title    map[string]string // title page fields, keys lower-cased
+	Elements []Element
+}
+
+// ParseSceneHeading splits a slugline such as "INT. KITCHEN - NIGHT" into its parts
+func ParseSceneHeading(line string) models.SceneHeading {
+	line = strings.TrimSpace(line)
+	var heading models.SceneHeading
+
+	upper := strings.ToUpper(line)
+	for _, prefix := range []string{"INT./EXT.", "INT/EXT.", "INT/EXT", "I/E.", "I/E", "INT.", "INT", "EXT.", "EXT", "EST.", "EST"} {
+		if strings.HasPrefix(upper, prefix+" ") || strings.HasPrefix(upper, prefix+".") || upper == prefix {
+			heading.Setting = strings.TrimSuffix(prefix, ".")
+			if heading.Setting == "I/E" || heading.Setting == "INT./EXT" {
+				heading.Setting = "INT/EXT"
+			}
+			line = strings.TrimLeft(line[len(prefix):], ". ")
+			break
+		}
+	}
+
+	if i := strings.LastIndex(line, " - "); i >= 0 {
+		heading.Location = strings.TrimSpace(line[:i])
+		heading.TimeOfDay = strings.TrimSpace(line[i+3:])
+	} else {
+		heading.Location = line
+	}
+
+	return heading
+}
@@ -0,0 +1,220 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// AssetRefPrefix marks a Panel.ImageData value that points into the asset store
+const AssetRefPrefix = "asset:"
+
+// AssetStore keeps panel images as content-addressed files next to a project.
+// Files are named after the SHA-256 of their contents, so identical images
+// (e.g. duplicated panels) are only stored once.
+type AssetStore struct {
+	Dir string
+}
+
+// NewAssetStore returns the asset store for a project living in projectDir
+func NewAssetStore(projectDir string) *AssetStore {
+	return (&Resolver{Dir: projectDir}).Store(AssetPanel)
+}
+
+// NewAudioStore returns the store for audio clips of a project living in projectDir.
+// Audio uses the same reference format as panel images.
+func NewAudioStore(projectDir string) *AssetStore {
+	return (&Resolver{Dir: projectDir}).Store(AssetAudio)
+}
+
+// IsAssetRef reports whether value is an asset store reference
+func IsAssetRef(value string) bool {
+	return strings.HasPrefix(value, AssetRefPrefix)
+}
+
+// Put stores data under its content hash and returns the asset reference.
+// Existing files are left untouched.
+func (s *AssetStore) Put(data []byte, ext string) (string, error) {
+	name := assetName(data, ext)
+	path := filepath.Join(s.Dir, name)
+
+	if _, err := os.Stat(path); err == nil {
+		return AssetRefPrefix + name, nil
+	}
+
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create asset store: %w", err)
+	}
+
+	// Write to a temp name first so a half-written file never carries a valid hash name
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write asset: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to write asset: %w", err)
+	}
+
+	return AssetRefPrefix + name, nil
+}
+
+// PutDataURI stores the payload of a base64 data URI and returns its reference
+func (s *AssetStore) PutDataURI(uri string) (string, error) {
+	mime, data, err := ParseDataURI(uri)
+	if err != nil {
+		return "", err
+	}
+	ext, ok := extensionForMime(mime)
+	if !ok {
+		return "", fmt.Errorf("unsupported media type %q", mime)
+	}
+	return s.Put(data, ext)
+}
+
+// DataURIRef returns the reference PutDataURI stores uri under, without
+// writing anything
+func DataURIRef(uri string) (string, error) {
+	mime, data, err := ParseDataURI(uri)
+	if err != nil {
+		return "", err
+	}
+	ext, ok := extensionForMime(mime)
+	if !ok {
+		return "", fmt.Errorf("unsupported media type %q", mime)
+	}
+	return AssetRefPrefix + assetName(data, ext), nil
+}
+
+// assetName returns the file name data is stored under
+func assetName(data []byte, ext string) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]) + ext
+}
+
+// Path returns the file path of an asset reference
+func (s *AssetStore) Path(ref string) (string, error) {
+	if !IsAssetRef(ref) {
+		return "", fmt.Errorf("not an asset reference: %s", ref)
+	}
+
+	// Names are hashes; reject anything that tries to escape the store
+	name := strings.TrimPrefix(ref, AssetRefPrefix)
+	if name == "" || name != filepath.Base(name) {
+		return "", fmt.Errorf("invalid asset reference: %s", ref)
+	}
+
+	return filepath.Join(s.Dir, name), nil
+}
+
+// Read returns the contents of an asset reference
+func (s *AssetStore) Read(ref string) ([]byte, error) {
+	path, err := s.Path(ref)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+// ReadDataURI returns an asset reference as a base64 data URI for the frontend
+func (s *AssetStore) ReadDataURI(ref string) (string, error) {
+	path, err := s.Path(ref)
+	if err != nil {
+		return "", err
+	}
+	return fileDataURI(path)
+}
+
+// fileDataURI returns the contents of a file as a base64 data URI, typed by
+// its extension
+func fileDataURI(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	mime := mimeForExtension(filepath.Ext(path))
+	return "data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// ParseDataURI splits a base64 data URI into its media type and payload
+// Format is "data:image/png;base64,....."
+func ParseDataURI(uri string) (string, []byte, error) {
+	header, payload, ok := strings.Cut(uri, ",")
+	if !ok || !strings.HasPrefix(header, "data:") || !strings.HasSuffix(header, ";base64") {
+		return "", nil, fmt.Errorf("invalid base64 image data")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to decode base64 data: %w", err)
+	}
+
+	mime := strings.TrimSuffix(strings.TrimPrefix(header, "data:"), ";base64")
+	return mime, data, nil
+}
+
+// extensionForMime returns the file extension assets of a media type are
+// stored with. Unknown image and other types are rejected rather than stored
+// under a misleading extension.
+func extensionForMime(mime string) (string, bool) {
+	switch mime {
+	case "image/png":
+		return ".png", true
+	case "image/jpeg":
+		return ".jpg", true
+	case "image/gif":
+		return ".gif", true
+	case "image/webp":
+		return ".webp", true
+	case "image/bmp":
+		return ".bmp", true
+	case "audio/mpeg", "audio/mp3":
+		return ".mp3", true
+	case "audio/wav", "audio/wave", "audio/x-wav":
+		return ".wav", true
+	case "audio/ogg":
+		return ".ogg", true
+	case "audio/mp4", "audio/x-m4a":
+		return ".m4a", true
+	case "audio/aac":
+		return ".aac", true
+	case "audio/flac", "audio/x-flac":
+		return ".flac", true
+	default:
+		if strings.HasPrefix(mime, "audio/") {
+			return ".audio", true // ffmpeg probes the contents anyway
+		}
+		return "", false
+	}
+}
+
+func mimeForExtension(ext string) string {
+	switch strings.ToLower(ext) {
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".gif":
+		return "image/gif"
+	case ".webp":
+		return "image/webp"
+	case ".bmp":
+		return "image/bmp"
+	case ".mp3":
+		return "audio/mpeg"
+	case ".wav":
+		return "audio/wav"
+	case ".ogg":
+		return "audio/ogg"
+	case ".m4a":
+		return "audio/mp4"
+	case ".aac":
+		return "audio/aac"
+	case ".flac":
+		return "audio/flac"
+	default:
+		return "image/png"
+	}
+}
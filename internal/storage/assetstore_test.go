@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"storyboard_flow/internal/models"
+)
+
+// pixelPNG is a valid 1x1 PNG
+const pixelPNG = "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAAAAAA6fptVAAAACklEQVR4nGNgAAAAAgABSK+kcQAAAABJRU5ErkJggg=="
+
+func TestAssetStorePutDedupes(t *testing.T) {
+	store := NewAssetStore(t.TempDir())
+
+	first, err := store.Put([]byte("same"), ".png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := store.Put([]byte("same"), ".png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := store.Put([]byte("other"), ".png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first != second {
+		t.Errorf("same data stored as %s and %s", first, second)
+	}
+	if first == other {
+		t.Error("different data share a reference")
+	}
+	entries, err := os.ReadDir(store.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("store holds %d files, want 2", len(entries))
+	}
+	if data, err := store.Read(first); err != nil || string(data) != "same" {
+		t.Errorf("Read(%s) = %q, %v", first, data, err)
+	}
+}
+
+func TestAssetStorePathRejectsInvalidRefs(t *testing.T) {
+	store := NewAssetStore(t.TempDir())
+	for _, ref := range []string{
+		"asset:",
+		"asset:../escape.png",
+		"asset:nested/name.png",
+		"shots/a.png",
+		"data:image/png;base64," + pixelPNG,
+	} {
+		if path, err := store.Path(ref); err == nil {
+			t.Errorf("Path(%q) = %s, want an error", ref, path)
+		}
+	}
+
+	path, err := store.Path("asset:abc.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(store.Dir, "abc.png") {
+		t.Errorf("Path = %s, want it inside the store", path)
+	}
+}
+
+func TestAssetStorePutDataURI(t *testing.T) {
+	store := NewAssetStore(t.TempDir())
+
+	uri := "data:image/png;base64," + pixelPNG
+	ref, err := store.PutDataURI(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(ref, ".png") {
+		t.Errorf("ref %s does not keep the png extension", ref)
+	}
+	if want, err := DataURIRef(uri); err != nil || want != ref {
+		t.Errorf("DataURIRef = %s, %v; PutDataURI stored %s", want, err, ref)
+	}
+	if back, err := store.ReadDataURI(ref); err != nil || back != uri {
+		t.Errorf("ReadDataURI = %.40s, %v; want the original data URI", back, err)
+	}
+
+	wav := "data:audio/x-custom;base64," + base64.StdEncoding.EncodeToString([]byte("RIFF"))
+	if ref, err := store.PutDataURI(wav); err != nil || !strings.HasSuffix(ref, ".audio") {
+		t.Errorf("unknown audio type stored as %s, %v", ref, err)
+	}
+
+	svg := "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString([]byte("<svg/>"))
+	if ref, err := store.PutDataURI(svg); err == nil {
+		t.Errorf("unknown image type stored as %s, want an error", ref)
+	}
+	if _, err := store.PutDataURI("data:image/png," + pixelPNG); err == nil {
+		t.Error("data URI without base64 was accepted")
+	}
+}
+
+func TestLoadProjectKeepsAssetRefs(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "board.json")
+	uri := "data:image/png;base64," + pixelPNG
+
+	project := models.NewProject("refs")
+	project.Panels[0].ImageData = uri
+	project.Characters = []models.Character{*models.NewCharacter("Ada", "")}
+	project.Characters[0].ImagePath = uri
+	if err := SaveProject(project, path); err != nil {
+		t.Fatal(err)
+	}
+	if project.Panels[0].ImageData != uri {
+		t.Error("saving changed the in-memory project")
+	}
+
+	loaded, err := LoadProject(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := ImageRefs(project)[uri]
+	if !IsAssetRef(ref) {
+		t.Fatalf("ImageRefs has no reference for the inline image: %q", ref)
+	}
+	if loaded.Panels[0].ImageData != ref || loaded.Characters[0].ImagePath != ref {
+		t.Errorf("loaded images = %q, %q; want the reference %s",
+			loaded.Panels[0].ImageData, loaded.Characters[0].ImagePath, ref)
+	}
+	if loaded.NeedsMigration {
+		t.Error("a project with stored images was flagged for migration")
+	}
+
+	r := NewResolver(path)
+	if back, err := r.DataURI(AssetPanel, ref); err != nil || back != uri {
+		t.Errorf("DataURI = %.40s, %v; want the saved image", back, err)
+	}
+}
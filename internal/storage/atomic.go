@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BackupCount is how many previous saves of a project are kept in its
+// backups folder
+const BackupCount = 5
+
+// writeFileAtomic replaces path with data so that a crash leaves either the
+// old or the new contents, never a partial file. The data is written to a
+// temporary file in the same folder, synced, and renamed over path.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// BackupPath returns where the n-th most recent backup of a project file is
+// kept, counting from 1: backups/<name>.<n>.json next to the project. Asset
+// references resolve against the project folder, so a backup is restored by
+// copying it over the project file.
+func BackupPath(projectPath string, n int) string {
+	dir, base := filepath.Split(projectPath)
+	name := strings.TrimSuffix(base, filepath.Ext(base))
+	return filepath.Join(dir, "backups", fmt.Sprintf("%s.%d%s", name, n, filepath.Ext(base)))
+}
+
+// rotateBackups keeps a copy of the project file at path before it is
+// replaced. Older copies shift down one place and the oldest is dropped.
+func rotateBackups(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil // first save
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(BackupPath(path, 1)), 0755); err != nil {
+		return err
+	}
+	os.Remove(BackupPath(path, BackupCount))
+	for n := BackupCount - 1; n >= 1; n-- {
+		if err := os.Rename(BackupPath(path, n), BackupPath(path, n+1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return writeFileAtomic(BackupPath(path, 1), data, 0644)
+}
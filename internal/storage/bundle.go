@@ -0,0 +1,373 @@
+package storage
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"storyboard_flow/internal/models"
+)
+
+// BundleExt is the file extension of project bundles
+const BundleExt = ".sbf"
+
+// Names inside a bundle
+const (
+	bundleFormat   = "storyboard_flow.bundle"
+	bundleManifest = "manifest.json"
+	bundleProject  = "project.json"
+)
+
+// maxManifestSize caps how much of a bundle's manifest is read
+const maxManifestSize = 16 << 20
+
+// BundleManifest describes the contents of a bundle. It is stored as
+// manifest.json at the root of the archive.
+type BundleManifest struct {
+	Format        string       `json:"format"`
+	SchemaVersion int          `json:"schema_version"` // of the project inside
+	Project       string       `json:"project"`        // path of the project JSON in the archive
+	CreatedAt     time.Time    `json:"created_at"`
+	Files         []BundleFile `json:"files"` // every file except the manifest
+	Missing       []string     `json:"missing,omitempty"`
+}
+
+// BundleFile is one file of a bundle with its checksum
+type BundleFile struct {
+	Path   string `json:"path"` // slash-separated, relative to the archive root
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// IsBundle reports whether path names a project bundle
+func IsBundle(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), BundleExt)
+}
+
+// ProjectDir returns the folder a project's assets are resolved against. For
+// a bundle this is the folder it is unpacked into while open.
+func ProjectDir(projectPath string) string {
+	if IsBundle(projectPath) {
+		if dir, err := bundleWorkDir(projectPath); err == nil {
+			return dir
+		}
+	}
+	return filepath.Dir(projectPath)
+}
+
+// PackBundle writes the project file at projectPath, with every image and
+// audio file it references, into a bundle at bundlePath. Referenced files
+// that cannot be found are left out and listed in the manifest.
+func PackBundle(projectPath, bundlePath string) (*BundleManifest, error) {
+	data, err := os.ReadFile(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	project, err := decodeProject(data)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Resolver{Dir: filepath.Dir(projectPath)}
+	files := make(map[string][]byte)
+	var missing []string
+
+	// Every referenced file ends up in the bundle's asset stores; plain
+	// paths are turned into references
+	add := func(kind AssetKind, ref *string) {
+		if *ref == "" || strings.HasPrefix(*ref, "data:") {
+			return
+		}
+		file, err := r.Resolve(kind, *ref)
+		if err != nil {
+			missing = append(missing, *ref)
+			return
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			missing = append(missing, *ref)
+			return
+		}
+		if !IsAssetRef(*ref) {
+			*ref = bundleAssetRef(content, filepath.Ext(file))
+		}
+		files[path.Join("assets", string(kind), strings.TrimPrefix(*ref, AssetRefPrefix))] = content
+	}
+
+	for i := range project.Panels {
+		panel := &project.Panels[i]
+		add(AssetPanel, &panel.ImageData)
+		for j := range panel.AudioClips {
+			add(AssetAudio, &panel.AudioClips[j].Source)
+		}
+	}
+	for i := range project.AudioTracks {
+		add(AssetAudio, &project.AudioTracks[i].Source)
+	}
+	for i := range project.Characters {
+		add(AssetCharacter, &project.Characters[i].ImagePath)
+	}
+
+	project.SchemaVersion = models.SchemaVersion
+	projectJSON, err := json.MarshalIndent(project, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	files[bundleProject] = projectJSON
+
+	manifest := &BundleManifest{
+		Format:        bundleFormat,
+		SchemaVersion: models.SchemaVersion,
+		Project:       bundleProject,
+		CreatedAt:     time.Now(),
+		Missing:       missing,
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sum := sha256.Sum256(files[name])
+		manifest.Files = append(manifest.Files, BundleFile{
+			Path:   name,
+			Size:   int64(len(files[name])),
+			SHA256: hex.EncodeToString(sum[:]),
+		})
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeZipEntry(zw, bundleManifest, manifestJSON, zip.Deflate); err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		// Images and audio are already compressed
+		method := zip.Store
+		if strings.HasSuffix(name, ".json") {
+			method = zip.Deflate
+		}
+		if err := writeZipEntry(zw, name, files[name], method); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(bundlePath), 0755); err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(bundlePath, buf.Bytes(), 0644); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// UnpackBundle verifies a bundle against its manifest and extracts it into
+// destDir. It returns the path of the extracted project file.
+func UnpackBundle(bundlePath, destDir string) (string, error) {
+	zr, err := zip.OpenReader(bundlePath)
+	if err != nil {
+		return "", fmt.Errorf("not a project bundle: %w", err)
+	}
+	defer zr.Close()
+
+	entries := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		entries[f.Name] = f
+	}
+
+	mf, ok := entries[bundleManifest]
+	if !ok {
+		return "", fmt.Errorf("bundle has no %s", bundleManifest)
+	}
+	manifestJSON, err := readZipEntry(mf, maxManifestSize)
+	if err != nil {
+		return "", err
+	}
+	var manifest BundleManifest
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return "", fmt.Errorf("invalid bundle manifest: %w", err)
+	}
+	if manifest.Format != bundleFormat {
+		return "", fmt.Errorf("unknown bundle format %q", manifest.Format)
+	}
+	if manifest.SchemaVersion > models.SchemaVersion {
+		return "", fmt.Errorf("%w (bundle schema %d, this build reads up to %d)", ErrNewerSchema, manifest.SchemaVersion, models.SchemaVersion)
+	}
+
+	// Check everything before writing anything
+	contents := make(map[string][]byte, len(manifest.Files))
+	for _, file := range manifest.Files {
+		if !validBundlePath(file.Path) {
+			return "", fmt.Errorf("bundle contains an invalid path: %s", file.Path)
+		}
+		f, ok := entries[file.Path]
+		if !ok {
+			return "", fmt.Errorf("bundle is missing %s", file.Path)
+		}
+		// Read no more than the manifest promises, so a forged entry cannot
+		// expand into more than the checksum covers
+		content, err := readZipEntry(f, file.Size)
+		if err != nil {
+			return "", err
+		}
+		sum := sha256.Sum256(content)
+		if int64(len(content)) != file.Size || hex.EncodeToString(sum[:]) != file.SHA256 {
+			return "", fmt.Errorf("bundle is corrupt: checksum mismatch for %s", file.Path)
+		}
+		contents[file.Path] = content
+	}
+	if _, ok := contents[manifest.Project]; !ok {
+		return "", fmt.Errorf("bundle manifest does not list its project file")
+	}
+
+	for _, file := range manifest.Files {
+		dest := filepath.Join(destDir, filepath.FromSlash(file.Path))
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(dest, contents[file.Path], 0644); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(destDir, filepath.FromSlash(manifest.Project)), nil
+}
+
+// saveBundle saves project into the working folder of the bundle at
+// bundlePath and packs the folder into the bundle
+func saveBundle(project *models.Project, bundlePath string) error {
+	if err := backupBeforeUpgrade(bundlePath); err != nil {
+		return err
+	}
+
+	dir, err := bundleWorkDir(bundlePath)
+	if err != nil {
+		return err
+	}
+	projectFile := filepath.Join(dir, bundleProject)
+	if err := writeProject(project, projectFile); err != nil {
+		return err
+	}
+
+	if err := rotateBackups(bundlePath); err != nil {
+		return fmt.Errorf("failed to back up project: %w", err)
+	}
+	_, err = PackBundle(projectFile, bundlePath)
+	return err
+}
+
+// openBundle unpacks a bundle into its working folder, replacing an earlier
+// copy, and returns the path of the project file inside
+func openBundle(bundlePath string) (string, error) {
+	dir, err := bundleWorkDir(bundlePath)
+	if err != nil {
+		return "", err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return "", err
+	}
+	return UnpackBundle(bundlePath, dir)
+}
+
+// bundleVersion returns the schema version in the manifest of the bundle at
+// bundlePath, or -1 if there is no readable bundle there
+func bundleVersion(bundlePath string) int {
+	zr, err := zip.OpenReader(bundlePath)
+	if err != nil {
+		return -1
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		if f.Name != bundleManifest {
+			continue
+		}
+		data, err := readZipEntry(f, maxManifestSize)
+		if err != nil {
+			return -1
+		}
+		var manifest BundleManifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return -1
+		}
+		return manifest.SchemaVersion
+	}
+	return -1
+}
+
+// bundleWorkDir returns the folder an open bundle is unpacked into. It lives
+// in the user cache directory and is named after the bundle's absolute path.
+func bundleWorkDir(bundlePath string) (string, error) {
+	abs, err := filepath.Abs(bundlePath)
+	if err != nil {
+		return "", err
+	}
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("no user cache directory: %w", err)
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(cache, "storyboard_flow", "bundles", hex.EncodeToString(sum[:8])), nil
+}
+
+// bundleAssetRef returns the asset reference content gets in a bundle
+func bundleAssetRef(content []byte, ext string) string {
+	sum := sha256.Sum256(content)
+	return AssetRefPrefix + hex.EncodeToString(sum[:]) + strings.ToLower(ext)
+}
+
+// validBundlePath rejects archive paths that would escape the target folder
+func validBundlePath(name string) bool {
+	if name == "" || strings.Contains(name, "\\") || path.IsAbs(name) {
+		return false
+	}
+	clean := path.Clean(name)
+	return clean == name && clean != ".." && !strings.HasPrefix(clean, "../")
+}
+
+func writeZipEntry(zw *zip.Writer, name string, data []byte, method uint16) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: method, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// readZipEntry returns the contents of an archive entry, failing if it holds
+// more than limit bytes
+func readZipEntry(f *zip.File, limit int64) ([]byte, error) {
+	if limit < 0 {
+		return nil, fmt.Errorf("bundle is corrupt: invalid size for %s", f.Name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("bundle is corrupt: %s is larger than expected", f.Name)
+	}
+	return data, nil
+}
@@ -0,0 +1,238 @@
+package storage
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"storyboard_flow/internal/models"
+)
+
+// writeTestProject writes a project whose panel image and music track are
+// plain files next to it, and whose character image is missing
+func writeTestProject(t *testing.T, dir string) string {
+	t.Helper()
+	files := map[string]string{
+		"shots/a.png": "panel image",
+		"music.wav":   "music",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	project := models.NewProject("bundled")
+	project.SchemaVersion = models.SchemaVersion
+	project.Panels[0].ImageData = "shots/a.png"
+	project.AudioTracks = []models.AudioClip{*models.NewAudioClip("score", models.AudioMusic, "music.wav", 3)}
+	project.Characters = []models.Character{*models.NewCharacter("Ada", "")}
+	project.Characters[0].ImagePath = "missing.png"
+
+	data, err := json.Marshal(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "board.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// rewriteBundle replaces the content of the entries of a bundle for which
+// edit returns new data
+func rewriteBundle(t *testing.T, bundlePath string, edit func(name string, data []byte) []byte) {
+	t.Helper()
+	zr, err := zip.OpenReader(bundlePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range zr.File {
+		data, err := readZipEntry(f, int64(f.UncompressedSize64))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if edited := edit(f.Name, data); edited != nil {
+			data = edited
+		}
+		if err := writeZipEntry(zw, f.Name, data, zip.Deflate); err != nil {
+			t.Fatal(err)
+		}
+	}
+	zr.Close()
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(bundlePath, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	projectPath := writeTestProject(t, filepath.Join(dir, "src"))
+	bundlePath := filepath.Join(dir, "board"+BundleExt)
+
+	manifest, err := PackBundle(projectPath, bundlePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Missing) != 1 || manifest.Missing[0] != "missing.png" {
+		t.Errorf("missing = %v, want [missing.png]", manifest.Missing)
+	}
+	if bundleVersion(bundlePath) != models.SchemaVersion {
+		t.Errorf("bundle schema %d, want %d", bundleVersion(bundlePath), models.SchemaVersion)
+	}
+
+	dest := filepath.Join(dir, "out")
+	unpacked, err := UnpackBundle(bundlePath, dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(unpacked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	project, err := decodeProject(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &Resolver{Dir: dest}
+	for _, ref := range []struct {
+		kind    AssetKind
+		ref     string
+		content string
+	}{
+		{AssetPanel, project.Panels[0].ImageData, "panel image"},
+		{AssetAudio, project.AudioTracks[0].Source, "music"},
+	} {
+		if !IsAssetRef(ref.ref) {
+			t.Errorf("%s was not turned into an asset reference", ref.ref)
+			continue
+		}
+		path, err := r.Resolve(ref.kind, ref.ref)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if content, _ := os.ReadFile(path); string(content) != ref.content {
+			t.Errorf("%s holds %q, want %q", ref.ref, content, ref.content)
+		}
+	}
+	if project.Name != "bundled" || project.Characters[0].ImagePath != "missing.png" {
+		t.Errorf("project changed in the bundle: %q, character image %q", project.Name, project.Characters[0].ImagePath)
+	}
+}
+
+func TestUnpackBundleRejectsCorruptChecksum(t *testing.T) {
+	dir := t.TempDir()
+	projectPath := writeTestProject(t, filepath.Join(dir, "src"))
+	bundlePath := filepath.Join(dir, "board"+BundleExt)
+	if _, err := PackBundle(projectPath, bundlePath); err != nil {
+		t.Fatal(err)
+	}
+
+	rewriteBundle(t, bundlePath, func(name string, data []byte) []byte {
+		if name == bundleProject {
+			return bytes.Replace(data, []byte("bundled"), []byte("BUNDLED"), 1)
+		}
+		return nil
+	})
+
+	dest := filepath.Join(dir, "out")
+	if _, err := UnpackBundle(bundlePath, dest); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("got %v, want a checksum mismatch", err)
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Error("files were extracted from a corrupt bundle")
+	}
+}
+
+func TestUnpackBundleRejectsOversizedEntry(t *testing.T) {
+	dir := t.TempDir()
+	projectPath := writeTestProject(t, filepath.Join(dir, "src"))
+	bundlePath := filepath.Join(dir, "board"+BundleExt)
+	if _, err := PackBundle(projectPath, bundlePath); err != nil {
+		t.Fatal(err)
+	}
+
+	// Highly compressible padding, as in a zip bomb
+	rewriteBundle(t, bundlePath, func(name string, data []byte) []byte {
+		if name == bundleProject {
+			return append(data, bytes.Repeat([]byte(" "), 1<<20)...)
+		}
+		return nil
+	})
+
+	dest := filepath.Join(dir, "out")
+	if _, err := UnpackBundle(bundlePath, dest); err == nil || !strings.Contains(err.Error(), "larger than expected") {
+		t.Fatalf("got %v, want an oversized entry error", err)
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Error("files were extracted from a corrupt bundle")
+	}
+}
+
+func TestSaveBundleBacksUpOlderSchema(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+	projectPath := writeTestProject(t, filepath.Join(dir, "src"))
+	bundlePath := filepath.Join(dir, "board"+BundleExt)
+	if _, err := PackBundle(projectPath, bundlePath); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pretend the bundle was written by a build before the current schema
+	old := models.SchemaVersion - 1
+	rewriteBundle(t, bundlePath, func(name string, data []byte) []byte {
+		if name != bundleManifest {
+			return nil
+		}
+		var manifest BundleManifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			t.Fatal(err)
+		}
+		manifest.SchemaVersion = old
+		data, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	})
+	original, err := os.ReadFile(bundlePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := LoadProject(bundlePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveProject(project, bundlePath); err != nil {
+		t.Fatal(err)
+	}
+
+	backup, err := os.ReadFile(fmt.Sprintf("%s.v%d.bak", bundlePath, old))
+	if err != nil {
+		t.Fatalf("no backup of the older bundle: %v", err)
+	}
+	if !bytes.Equal(backup, original) {
+		t.Error("backup differs from the original bundle")
+	}
+	if bundleVersion(bundlePath) != models.SchemaVersion {
+		t.Errorf("saved bundle has schema %d, want %d", bundleVersion(bundlePath), models.SchemaVersion)
+	}
+}
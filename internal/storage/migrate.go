@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"storyboard_flow/internal/models"
+)
+
+// migration upgrades a decoded project file by one schema version in place.
+// It works on the raw JSON document so it can read fields the current models
+// no longer have.
+type migration func(doc map[string]interface{}) error
+
+// migrations[v] upgrades a file from schema version v to v+1. Files written
+// before versioning have no schema_version and are version 0.
+var migrations = []migration{
+	0: migrateUnversioned,
+}
+
+// ErrNewerSchema is returned for project files written by a newer build
+var ErrNewerSchema = errors.New("project was saved by a newer version of Storyboard Flow")
+
+// decodeProject parses a project file, upgrading older schema versions in
+// memory. Upgraded projects are flagged with NeedsMigration.
+func decodeProject(data []byte) (*models.Project, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	version, err := docVersion(doc)
+	if err != nil {
+		return nil, err
+	}
+	if version > models.SchemaVersion {
+		return nil, fmt.Errorf("%w (file schema %d, this build reads up to %d)", ErrNewerSchema, version, models.SchemaVersion)
+	}
+
+	if version < models.SchemaVersion {
+		for v := version; v < models.SchemaVersion; v++ {
+			if v >= len(migrations) {
+				return nil, fmt.Errorf("no migration from project schema %d", v)
+			}
+			if err := migrations[v](doc); err != nil {
+				return nil, fmt.Errorf("failed to upgrade project from schema %d: %w", v, err)
+			}
+			doc["schema_version"] = v + 1
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	var project models.Project
+	if err := json.Unmarshal(data, &project); err != nil {
+		return nil, err
+	}
+	project.NeedsMigration = version < models.SchemaVersion
+	return &project, nil
+}
+
+// docVersion reads schema_version from a decoded project file
+func docVersion(doc map[string]interface{}) (int, error) {
+	raw, ok := doc["schema_version"]
+	if !ok || raw == nil {
+		return 0, nil
+	}
+	v, ok := raw.(float64)
+	if !ok || v < 0 || v != float64(int(v)) {
+		return 0, fmt.Errorf("invalid schema_version: %v", raw)
+	}
+	return int(v), nil
+}
+
+// fileVersion returns the schema version of the project file or bundle at
+// path, or -1 if there is no readable project there
+func fileVersion(path string) int {
+	if IsBundle(path) {
+		return bundleVersion(path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return -1
+	}
+	return dataVersion(data)
+}
+
+// dataVersion returns the schema version of a project file's contents, or -1
+// if they are not a project
+func dataVersion(data []byte) int {
+	var header struct {
+		SchemaVersion int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return -1
+	}
+	return header.SchemaVersion
+}
+
+// pinLegacyPaths makes the relative paths of a schema 0 project absolute when
+// they only resolve against the working directory, where early builds
+// resolved them. Newer files resolve relative paths against dir alone.
+func pinLegacyPaths(project *models.Project, dir string) {
+	pin := func(ref *string) {
+		if *ref == "" || IsAssetRef(*ref) || strings.HasPrefix(*ref, "data:") || strings.HasPrefix(*ref, "file://") {
+			return
+		}
+		path := filepath.FromSlash(*ref)
+		if filepath.IsAbs(path) {
+			return
+		}
+		if _, err := os.Stat(filepath.Join(dir, path)); err == nil {
+			return
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			if _, err := os.Stat(abs); err == nil {
+				*ref = abs
+			}
+		}
+	}
+
+	for i := range project.Panels {
+		pin(&project.Panels[i].ImageData)
+		for j := range project.Panels[i].AudioClips {
+			pin(&project.Panels[i].AudioClips[j].Source)
+		}
+	}
+	for i := range project.AudioTracks {
+		pin(&project.AudioTracks[i].Source)
+	}
+	for i := range project.Characters {
+		pin(&project.Characters[i].ImagePath)
+	}
+}
+
+// backupBeforeUpgrade copies an older-schema project file or bundle to
+// "<path>.v<version>.bak" before it is overwritten in the current format, so
+// older builds can still open the original. An existing backup is kept.
+func backupBeforeUpgrade(path string) error {
+	version := fileVersion(path)
+	if version < 0 || version >= models.SchemaVersion {
+		return nil
+	}
+
+	backup := fmt.Sprintf("%s.v%d.bak", path, version)
+	if _, err := os.Stat(backup); err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(backup, data, 0644); err != nil {
+		return fmt.Errorf("failed to back up project before upgrading it: %w", err)
+	}
+	return nil
+}
+
+// migrateUnversioned upgrades files written before schema_version existed.
+// Early builds could leave the format fields unset and list fields null.
+func migrateUnversioned(doc map[string]interface{}) error {
+	if s, _ := doc["aspect_ratio"].(string); s == "" {
+		doc["aspect_ratio"] = "16:9"
+	}
+	if n, _ := doc["frame_rate"].(float64); n <= 0 {
+		doc["frame_rate"] = 24
+	}
+	for _, key := range []string{"panels", "characters", "sequences", "scenes", "audio_tracks"} {
+		if doc[key] == nil {
+			doc[key] = []interface{}{}
+		}
+	}
+
+	panels, _ := doc["panels"].([]interface{})
+	for _, p := range panels {
+		panel, ok := p.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("invalid panel: %v", p)
+		}
+		if panel["character_ids"] == nil {
+			panel["character_ids"] = []interface{}{}
+		}
+	}
+	return nil
+}
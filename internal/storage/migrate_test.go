@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"storyboard_flow/internal/models"
+)
+
+func TestDecodeProjectUpgradesOldSchemas(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		aspectRatio string
+		frameRate   int
+	}{
+		{
+			name: "unversioned with null lists",
+			input: `{"name": "old", "aspect_ratio": "", "frame_rate": 0,
+				"characters": null, "scenes": null,
+				"panels": [{"id": "p1", "character_ids": null}]}`,
+			aspectRatio: "16:9",
+			frameRate:   24,
+		},
+		{
+			name: "schema 0 keeps its format",
+			input: `{"schema_version": 0, "name": "old", "aspect_ratio": "4:3", "frame_rate": 30,
+				"panels": [{"id": "p1"}]}`,
+			aspectRatio: "4:3",
+			frameRate:   30,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := decodeProject([]byte(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.SchemaVersion != models.SchemaVersion || !p.NeedsMigration {
+				t.Errorf("schema %d, needs migration %v; want %d, true", p.SchemaVersion, p.NeedsMigration, models.SchemaVersion)
+			}
+			if p.AspectRatio != tt.aspectRatio || p.FrameRate != tt.frameRate {
+				t.Errorf("format %s at %d fps, want %s at %d fps", p.AspectRatio, p.FrameRate, tt.aspectRatio, tt.frameRate)
+			}
+			if p.Characters == nil || p.Sequences == nil || p.Scenes == nil || p.AudioTracks == nil {
+				t.Error("list fields were left null")
+			}
+			if len(p.Panels) != 1 || p.Panels[0].CharacterIDs == nil {
+				t.Errorf("panel character IDs were left null: %+v", p.Panels)
+			}
+		})
+	}
+}
+
+func TestDecodeProjectCurrentSchema(t *testing.T) {
+	input := fmt.Sprintf(`{"schema_version": %d, "name": "new", "aspect_ratio": "2.39:1", "frame_rate": 25}`, models.SchemaVersion)
+	p, err := decodeProject([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.NeedsMigration {
+		t.Error("a current project was flagged for migration")
+	}
+	if p.AspectRatio != "2.39:1" || p.FrameRate != 25 {
+		t.Errorf("format changed to %s at %d fps", p.AspectRatio, p.FrameRate)
+	}
+}
+
+func TestDecodeProjectRejectsNewerAndInvalidSchemas(t *testing.T) {
+	newer := fmt.Sprintf(`{"schema_version": %d, "name": "future"}`, models.SchemaVersion+1)
+	if _, err := decodeProject([]byte(newer)); !errors.Is(err, ErrNewerSchema) {
+		t.Errorf("newer schema: got %v, want ErrNewerSchema", err)
+	}
+
+	for _, input := range []string{`{"schema_version": "1"}`, `{"schema_version": -1}`, `{"schema_version": 1.5}`} {
+		_, err := decodeProject([]byte(input))
+		if err == nil || errors.Is(err, ErrNewerSchema) {
+			t.Errorf("%s: got %v, want an invalid schema_version error", input, err)
+		}
+	}
+}
+
+func TestLoadingOldProjectsLeavesThemAlone(t *testing.T) {
+	dir := t.TempDir()
+	template := filepath.Join(dir, "template.json")
+	old := []byte(`{"name": "template", "panels": [{"id": "p1", "image_data": "data:image/png;base64,iVBORw0KGgo="}]}`)
+	if err := os.WriteFile(template, old, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := LoadProject(template)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.NeedsMigration {
+		t.Error("an old project was not flagged for migration")
+	}
+
+	created := filepath.Join(dir, "new", "board.json")
+	if _, err := CreateFromTemplate(template, created); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(template); !bytes.Equal(data, old) {
+		t.Error("the template was rewritten")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("files were added next to the template: %d entries", len(entries))
+	}
+	if fileVersion(created) != models.SchemaVersion {
+		t.Errorf("new project has schema %d, want %d", fileVersion(created), models.SchemaVersion)
+	}
+}
+
+func TestWorkingDirPathsOnlyResolveForLegacyProjects(t *testing.T) {
+	cwd := t.TempDir()
+	t.Chdir(cwd)
+	if err := os.MkdirAll("images", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("images", "shot.png"), []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	projectDir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		version string
+		want    string // image path after loading
+		found   bool
+	}{
+		{"schema 0 pins the path", "", filepath.Join(cwd, "images", "shot.png"), true},
+		{"schema 1 resolves against the project only", `"schema_version": 1,`, "images/shot.png", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(projectDir, "board.json")
+			data := fmt.Sprintf(`{%s "name": "paths", "panels": [{"id": "p1", "image_data": "images/shot.png"}]}`, tt.version)
+			if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			p, err := LoadProject(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.Panels[0].ImageData; got != tt.want {
+				t.Errorf("image path = %s, want %s", got, tt.want)
+			}
+			_, err = NewResolver(path).Resolve(AssetPanel, p.Panels[0].ImageData)
+			if found := err == nil; found != tt.found {
+				t.Errorf("resolved = %v (%v), want %v", found, err, tt.found)
+			}
+			if err != nil && !errors.Is(err, ErrFileNotFound) {
+				t.Errorf("error %v is not ErrFileNotFound", err)
+			}
+		})
+	}
+
+	// Paths that resolve against the project folder are left relative
+	if err := os.MkdirAll(filepath.Join(projectDir, "images"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "images", "shot.png"), []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(projectDir, "board.json")
+	if err := os.WriteFile(path, []byte(`{"name": "paths", "panels": [{"id": "p1", "image_data": "images/shot.png"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := LoadProject(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Panels[0].ImageData; got != "images/shot.png" {
+		t.Errorf("image path next to the project = %s, want it unchanged", got)
+	}
+}
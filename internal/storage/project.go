@@ -2,43 +2,319 @@ package storage
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"storyboard_flow/internal/models"
 )
 
-// SaveProject saves a project to a JSON file
+// SaveProject saves a project to a JSON file, or to a bundle when filePath
+// ends in BundleExt.
+// The previous file is kept in the backups folder, see BackupCount.
+// Inline panel images are moved into the project's asset store and the file
+// only keeps references to them; the in-memory project is not modified.
 func SaveProject(project *models.Project, filePath string) error {
+	if IsBundle(filePath) {
+		return saveBundle(project, filePath)
+	}
+
+	if err := backupBeforeUpgrade(filePath); err != nil {
+		return err
+	}
+	if err := rotateBackups(filePath); err != nil {
+		return fmt.Errorf("failed to back up project: %w", err)
+	}
+	return writeProject(project, filePath)
+}
+
+// writeProject writes project as JSON to filePath, moving inline images into
+// the asset store next to it
+func writeProject(project *models.Project, filePath string) error {
 	// Ensure directory exists
 	dir := filepath.Dir(filePath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return err
 	}
 
+	out, err := externalizeImages(project, &Resolver{Dir: dir})
+	if err != nil {
+		return err
+	}
+	out.SchemaVersion = models.SchemaVersion
+
 	// Marshal project to JSON
-	data, err := json.MarshalIndent(project, "", "  ")
+	data, err := json.MarshalIndent(out, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	// Write to file
-	return os.WriteFile(filePath, data, 0644)
+	// Replace the file in one step so a crash never leaves half a project
+	return writeFileAtomic(filePath, data, 0644)
 }
 
-// LoadProject loads a project from a JSON file
+// LoadProject loads a project from a JSON file or a bundle. A bundle is
+// unpacked into its working folder (see ProjectDir) and opened from there.
+// Files from older schema versions are upgraded in memory and flagged with
+// NeedsMigration; the file itself is only rewritten, after a backup, when the
+// project is saved. Files from a newer version fail with ErrNewerSchema.
+// Images are kept as asset references and read on demand, see
+// Resolver.DataURI. Projects that still embed their images are flagged too;
+// the next save moves the images into the asset store.
 func LoadProject(filePath string) (*models.Project, error) {
+	if IsBundle(filePath) {
+		var err error
+		if filePath, err = openBundle(filePath); err != nil {
+			return nil, err
+		}
+	}
+	return readProjectFile(filePath)
+}
+
+// ReadProject loads a project like LoadProject but leaves everything on disk
+// as it is, for tools that only inspect or export a project. A bundle is
+// unpacked into scratchDir instead of its working folder. It returns the
+// folder the project's relative paths resolve against.
+func ReadProject(filePath, scratchDir string) (*models.Project, string, error) {
+	if IsBundle(filePath) {
+		var err error
+		if filePath, err = UnpackBundle(filePath, scratchDir); err != nil {
+			return nil, "", err
+		}
+	}
+
+	project, err := readProjectFile(filePath)
+	if err != nil {
+		return nil, "", err
+	}
+	return project, filepath.Dir(filePath), nil
+}
+
+// readProjectFile reads and upgrades a project JSON file
+func readProjectFile(filePath string) (*models.Project, error) {
 	// Read file
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		return nil, err
 	}
 
-	// Unmarshal JSON
-	var project models.Project
-	if err := json.Unmarshal(data, &project); err != nil {
+	// Unmarshal JSON, upgrading files from older versions
+	project, err := decodeProject(data)
+	if err != nil {
 		return nil, err
 	}
 
-	return &project, nil
+	if hasInlineImages(project) {
+		project.NeedsMigration = true
+	}
+	if dataVersion(data) == 0 {
+		pinLegacyPaths(project, filepath.Dir(filePath))
+	}
+
+	return project, nil
+}
+
+// externalizeImages returns a copy of project whose inline panel and
+// character images are replaced by asset references
+func externalizeImages(project *models.Project, r *Resolver) (*models.Project, error) {
+	out := *project
+	out.Panels = make([]models.Panel, len(project.Panels))
+	copy(out.Panels, project.Panels)
+	out.Characters = make([]models.Character, len(project.Characters))
+	copy(out.Characters, project.Characters)
+
+	// The same data URI string is shared by duplicated panels; hash it once
+	refs := make(map[string]string)
+	put := func(store *AssetStore, src string) (string, error) {
+		key := store.Dir + "\x00" + src
+		if ref, ok := refs[key]; ok {
+			return ref, nil
+		}
+		ref, err := store.PutDataURI(src)
+		if err != nil {
+			return "", err
+		}
+		refs[key] = ref
+		return ref, nil
+	}
+
+	panels := r.Store(AssetPanel)
+	for i := range out.Panels {
+		src := out.Panels[i].ImageData
+		if !strings.HasPrefix(src, "data:") {
+			continue
+		}
+		ref, err := put(panels, src)
+		if err != nil {
+			return nil, fmt.Errorf("failed to store image for panel %s: %w", out.Panels[i].ID, err)
+		}
+		out.Panels[i].ImageData = ref
+	}
+
+	characters := r.Store(AssetCharacter)
+	for i := range out.Characters {
+		src := out.Characters[i].ImagePath
+		if !strings.HasPrefix(src, "data:") {
+			continue
+		}
+		ref, err := put(characters, src)
+		if err != nil {
+			return nil, fmt.Errorf("failed to store image for character %s: %w", out.Characters[i].Name, err)
+		}
+		out.Characters[i].ImagePath = ref
+	}
+
+	return &out, nil
+}
+
+// ImageRefs returns the asset reference saving gives each inline panel and
+// character image of project, keyed by the data URI. Images of a type the
+// asset store does not accept are left out.
+func ImageRefs(project *models.Project) map[string]string {
+	refs := make(map[string]string)
+	add := func(src string) {
+		if !strings.HasPrefix(src, "data:") {
+			return
+		}
+		if _, ok := refs[src]; ok {
+			return
+		}
+		if ref, err := DataURIRef(src); err == nil {
+			refs[src] = ref
+		}
+	}
+	for _, panel := range project.Panels {
+		add(panel.ImageData)
+	}
+	for _, char := range project.Characters {
+		add(char.ImagePath)
+	}
+	return refs
+}
+
+// hasInlineImages reports whether any panel still embeds its image in the project file
+func hasInlineImages(project *models.Project) bool {
+	for _, panel := range project.Panels {
+		if strings.HasPrefix(panel.ImageData, "data:") {
+			return true
+		}
+	}
+	return false
+}
+
+// SaveProjectAs saves a project that was loaded from fromPath to a new file.
+// When the folder changes, the stored images and audio the project references
+// are copied into the new folder's asset stores, and images linked by a
+// relative path are copied to the same path in the new folder; inline images
+// are stored by SaveProject.
+func SaveProjectAs(project *models.Project, fromPath, filePath string) error {
+	if fromPath != "" {
+		from, to := ProjectDir(fromPath), ProjectDir(filePath)
+		if !sameDir(from, to) {
+			if err := copyAssets(project, from, to); err != nil {
+				return err
+			}
+		}
+	}
+	return SaveProject(project, filePath)
+}
+
+// CreateFromTemplate starts a project at filePath from the project file at
+// templatePath. The copy is named after filePath and saved straight away so
+// its assets have a folder to live in.
+func CreateFromTemplate(templatePath, filePath string) (*models.Project, error) {
+	project, err := LoadProject(templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template: %w", err)
+	}
+
+	now := time.Now()
+	project.Name = strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	project.CreatedAt = now
+	project.ModifiedAt = now
+
+	if err := SaveProjectAs(project, templatePath, filePath); err != nil {
+		return nil, err
+	}
+	// The new file is written in the current format; the template is left as it was
+	project.NeedsMigration = false
+	return project, nil
+}
+
+// copyAssets copies the files project references from the project folder
+// fromDir to toDir: asset store references into the matching store, and
+// relative image paths to the same path
+func copyAssets(project *models.Project, fromDir, toDir string) error {
+	from, to := &Resolver{Dir: fromDir}, &Resolver{Dir: toDir}
+
+	copyAsset := func(kind AssetKind, ref, what string) error {
+		if ref == "" || strings.HasPrefix(ref, "data:") {
+			return nil
+		}
+		if IsAssetRef(ref) {
+			data, err := from.Store(kind).Read(ref)
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil // still missing; RelinkAssets can find it later
+			}
+			if err != nil {
+				return fmt.Errorf("failed to copy %s: %w", what, err)
+			}
+			_, err = to.Store(kind).Put(data, filepath.Ext(ref))
+			return err
+		}
+
+		// Absolute paths and paths that do not resolve are left as they are
+		rel, err := localPath(ref)
+		if err != nil || filepath.IsAbs(rel) || kind == AssetAudio {
+			return nil
+		}
+		data, err := os.ReadFile(filepath.Join(fromDir, rel))
+		if err != nil {
+			return nil
+		}
+		dest := filepath.Join(toDir, rel)
+		if _, err := os.Stat(dest); err == nil {
+			return nil
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(dest, data, 0644); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", what, err)
+		}
+		return nil
+	}
+
+	for _, clip := range project.AudioTracks {
+		if err := copyAsset(AssetAudio, clip.Source, fmt.Sprintf("audio %q", clip.Name)); err != nil {
+			return err
+		}
+	}
+	for _, panel := range project.Panels {
+		if err := copyAsset(AssetPanel, panel.ImageData, "image of panel "+panel.ID); err != nil {
+			return err
+		}
+		for _, clip := range panel.AudioClips {
+			if err := copyAsset(AssetAudio, clip.Source, fmt.Sprintf("audio %q", clip.Name)); err != nil {
+				return err
+			}
+		}
+	}
+	for _, char := range project.Characters {
+		if err := copyAsset(AssetCharacter, char.ImagePath, "image of "+char.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sameDir reports whether two paths name the same folder
+func sameDir(a, b string) bool {
+	dirA, errA := filepath.Abs(a)
+	dirB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && dirA == dirB
 }
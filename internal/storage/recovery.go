@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"storyboard_flow/internal/models"
+)
+
+// Recovery is an autosave of unsaved changes. Images drawn since the last save
+// are still inline, so they restore even for projects that were never saved;
+// saved images are asset references into the project folder.
+type Recovery struct {
+	ProjectPath string          `json:"project_path"` // file the changes belong to, empty if never saved
+	SavedAt     time.Time       `json:"saved_at"`
+	Project     *models.Project `json:"project"`
+}
+
+// RecoveryPath returns the autosave file for a project: "<file>.autosave" next
+// to a saved project, or a file in the user config directory for one that
+// was never saved
+func RecoveryPath(projectPath string) (string, error) {
+	if projectPath != "" {
+		return projectPath + ".autosave", nil
+	}
+	settings, err := SettingsPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(settings), "untitled.autosave"), nil
+}
+
+// WriteRecovery autosaves project, which belongs to projectPath
+func WriteRecovery(project *models.Project, projectPath string) error {
+	path, err := RecoveryPath(projectPath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(Recovery{
+		ProjectPath: projectPath,
+		SavedAt:     time.Now(),
+		Project:     project,
+	})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0644)
+}
+
+// ReadRecovery reads an autosave file. The project is upgraded like a project
+// file, so autosaves from older builds open and ones from newer builds fail
+// with ErrNewerSchema.
+func ReadRecovery(path string) (*Recovery, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw struct {
+		ProjectPath string          `json:"project_path"`
+		SavedAt     time.Time       `json:"saved_at"`
+		Project     json.RawMessage `json:"project"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid autosave file: %w", err)
+	}
+	if len(raw.Project) == 0 || string(raw.Project) == "null" {
+		return nil, fmt.Errorf("autosave file has no project")
+	}
+	project, err := decodeProject(raw.Project)
+	if err != nil {
+		return nil, fmt.Errorf("invalid autosave file: %w", err)
+	}
+
+	return &Recovery{ProjectPath: raw.ProjectPath, SavedAt: raw.SavedAt, Project: project}, nil
+}
+
+// FindRecovery returns the autosave of projectPath and the file it was read
+// from if it is newer than the saved project, or nil
+func FindRecovery(projectPath string) (*Recovery, string) {
+	path, err := RecoveryPath(projectPath)
+	if err != nil {
+		return nil, ""
+	}
+	rec, err := ReadRecovery(path)
+	if err != nil {
+		return nil, ""
+	}
+	if projectPath != "" {
+		if info, err := os.Stat(projectPath); err == nil && !rec.SavedAt.After(info.ModTime()) {
+			return nil, ""
+		}
+	}
+	return rec, path
+}
+
+// RemoveRecovery deletes the autosave of projectPath, if any
+func RemoveRecovery(projectPath string) error {
+	path, err := RecoveryPath(projectPath)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
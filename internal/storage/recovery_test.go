@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"storyboard_flow/internal/models"
+)
+
+func TestReadRecovery(t *testing.T) {
+	dir := t.TempDir()
+	projectPath := filepath.Join(dir, "board.json")
+
+	project := models.NewProject("board")
+	project.SchemaVersion = models.SchemaVersion
+	if err := WriteRecovery(project, projectPath); err != nil {
+		t.Fatal(err)
+	}
+	path, _ := RecoveryPath(projectPath)
+	rec, err := ReadRecovery(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.ProjectPath != projectPath || rec.Project.Name != "board" || len(rec.Project.Panels) != len(project.Panels) {
+		t.Errorf("read back %q with %d panels for %s", rec.Project.Name, len(rec.Project.Panels), rec.ProjectPath)
+	}
+
+	tests := []struct {
+		name    string
+		project string
+		check   func(t *testing.T, rec *Recovery, err error)
+	}{
+		{
+			name:    "older schema is upgraded",
+			project: `{"name": "old", "panels": [{"id": "p1", "character_ids": null}]}`,
+			check: func(t *testing.T, rec *Recovery, err error) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				p := rec.Project
+				if p.SchemaVersion != models.SchemaVersion || p.AspectRatio != "16:9" || p.FrameRate != 24 || p.Panels[0].CharacterIDs == nil {
+					t.Errorf("autosave was not upgraded: %+v", p)
+				}
+			},
+		},
+		{
+			name:    "newer schema is refused",
+			project: fmt.Sprintf(`{"schema_version": %d, "name": "future"}`, models.SchemaVersion+1),
+			check: func(t *testing.T, rec *Recovery, err error) {
+				if !errors.Is(err, ErrNewerSchema) {
+					t.Errorf("got %v, want ErrNewerSchema", err)
+				}
+			},
+		},
+		{
+			name:    "missing project",
+			project: `null`,
+			check: func(t *testing.T, rec *Recovery, err error) {
+				if err == nil {
+					t.Error("expected an error")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "board.json.autosave")
+			data := fmt.Sprintf(`{"project_path": "board.json", "saved_at": "2024-05-01T10:00:00Z", "project": %s}`, tt.project)
+			if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			rec, err := ReadRecovery(path)
+			tt.check(t, rec, err)
+		})
+	}
+}
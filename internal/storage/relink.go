@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"storyboard_flow/internal/models"
+)
+
+// MissingAsset is a file referenced by a project that cannot be found
+type MissingAsset struct {
+	Kind AssetKind `json:"kind"`
+	Ref  string    `json:"ref"`
+}
+
+// FindMissingAssets lists the files project references that do not resolve,
+// each reference once per kind. Images already held as data URIs are never
+// missing.
+func FindMissingAssets(project *models.Project, r *Resolver) []MissingAsset {
+	var missing []MissingAsset
+	seen := make(map[MissingAsset]bool)
+	check := func(kind AssetKind, ref string) {
+		asset := MissingAsset{Kind: kind, Ref: ref}
+		if ref == "" || strings.HasPrefix(ref, "data:") || seen[asset] {
+			return
+		}
+		seen[asset] = true
+		if _, err := r.Resolve(kind, ref); err != nil {
+			missing = append(missing, asset)
+		}
+	}
+
+	for _, panel := range project.Panels {
+		check(AssetPanel, panel.ImageData)
+		for _, clip := range panel.AudioClips {
+			check(AssetAudio, clip.Source)
+		}
+	}
+	for _, clip := range project.AudioTracks {
+		check(AssetAudio, clip.Source)
+	}
+	for _, char := range project.Characters {
+		check(AssetCharacter, char.ImagePath)
+	}
+	return missing
+}
+
+// RelinkAssets searches dirs, including subfolders, for the files project
+// references but cannot find. A file matches a missing reference when it has
+// the same name or, for asset store references, the content hash the
+// reference is named after. It returns the new value of every reference that
+// was found, keyed by the old value, and the references still missing.
+//
+// Found files are copied into the asset store of a saved project. For a
+// project that was never saved, images are returned as data URIs, which the
+// first save stores, and audio is linked by path.
+func RelinkAssets(project *models.Project, r *Resolver, dirs []string) (map[string]string, []MissingAsset, error) {
+	missing := FindMissingAssets(project, r)
+	found := make(map[MissingAsset]string) // missing asset -> file
+	if len(missing) == 0 {
+		return map[string]string{}, nil, nil
+	}
+
+	byName := make(map[string][]MissingAsset)
+	byHash := make(map[string][]MissingAsset)
+	exts := make(map[string]bool) // extensions worth hashing
+	for _, m := range missing {
+		name := strings.ToLower(path.Base(filepath.ToSlash(strings.TrimPrefix(m.Ref, AssetRefPrefix))))
+		byName[name] = append(byName[name], m)
+		if IsAssetRef(m.Ref) {
+			ext := path.Ext(name)
+			hash := strings.TrimSuffix(name, ext)
+			byHash[hash] = append(byHash[hash], m)
+			exts[ext] = true
+		}
+	}
+
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil // skip unreadable folders
+			}
+			if d.IsDir() {
+				if file != dir && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if len(found) == len(missing) {
+				return filepath.SkipAll
+			}
+
+			name := strings.ToLower(d.Name())
+			for _, m := range byName[name] {
+				if _, ok := found[m]; !ok {
+					found[m] = file
+				}
+			}
+			if exts[path.Ext(name)] {
+				if sum, err := fileHash(file); err == nil {
+					for _, m := range byHash[sum] {
+						if _, ok := found[m]; !ok {
+							found[m] = file
+						}
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	replacements := make(map[string]string, len(found))
+	var still []MissingAsset
+	for _, m := range missing {
+		file, ok := found[m]
+		if !ok {
+			still = append(still, m)
+			continue
+		}
+		value, err := relinkValue(r, m.Kind, file)
+		if err != nil {
+			return nil, nil, err
+		}
+		replacements[m.Ref] = value
+	}
+	return replacements, still, nil
+}
+
+// relinkValue returns what a reference to a relinked file becomes
+func relinkValue(r *Resolver, kind AssetKind, file string) (string, error) {
+	if r.Dir == "" {
+		if kind != AssetAudio {
+			return fileDataURI(file)
+		}
+		return r.Rel(file), nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return r.Store(kind).Put(data, strings.ToLower(filepath.Ext(file)))
+}
+
+// fileHash returns the hex SHA-256 of a file's contents
+func fileHash(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"storyboard_flow/internal/models"
+)
+
+// writeFile writes content to path, creating its folder
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// relinkProject returns a project whose panel image is a store reference to
+// "panel image", whose character image and music are missing plain paths and
+// whose second panel image cannot be found anywhere
+func relinkProject() *models.Project {
+	p := models.NewProject("relink")
+	p.Panels = []models.Panel{
+		{ID: "p1", ImageData: bundleAssetRef([]byte("panel image"), ".png")},
+		{ID: "p2", ImageData: "gone.png"},
+	}
+	p.Characters = []models.Character{{ID: "c1", Name: "Ada", ImagePath: "faces/ada.png"}}
+	p.AudioTracks = []models.AudioClip{{ID: "a1", Source: "music/score.wav"}}
+	return p
+}
+
+// relinkSearchDir returns a folder holding the missing files under other
+// names and places
+func relinkSearchDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "renamed.png"), "panel image") // matches by hash only
+	writeFile(t, filepath.Join(dir, "cast", "ADA.png"), "ada")     // matches by name
+	writeFile(t, filepath.Join(dir, "score.wav"), "music")
+	writeFile(t, filepath.Join(dir, ".cache", "gone.png"), "hidden") // hidden folders are skipped
+	return dir
+}
+
+func TestRelinkAssetsIntoStore(t *testing.T) {
+	project := relinkProject()
+	r := &Resolver{Dir: t.TempDir()}
+
+	found, missing, err := RelinkAssets(project, r, []string{relinkSearchDir(t)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missing) != 1 || missing[0].Ref != "gone.png" {
+		t.Errorf("still missing = %v, want only gone.png", missing)
+	}
+
+	tests := []struct {
+		kind    AssetKind
+		ref     string
+		content string
+	}{
+		{AssetPanel, project.Panels[0].ImageData, "panel image"},
+		{AssetCharacter, "faces/ada.png", "ada"},
+		{AssetAudio, "music/score.wav", "music"},
+	}
+	for _, tt := range tests {
+		value, ok := found[tt.ref]
+		if !ok {
+			t.Errorf("%s was not found", tt.ref)
+			continue
+		}
+		if !IsAssetRef(value) {
+			t.Errorf("%s relinked to %s, want an asset reference", tt.ref, value)
+			continue
+		}
+		if data, err := r.Store(tt.kind).Read(value); err != nil || string(data) != tt.content {
+			t.Errorf("%s holds %q, %v; want %q", value, data, err, tt.content)
+		}
+	}
+	if ref := project.Panels[0].ImageData; found[ref] != ref {
+		t.Errorf("a reference found by hash became %s, want it unchanged", found[ref])
+	}
+}
+
+func TestRelinkAssetsUnsaved(t *testing.T) {
+	project := relinkProject()
+	search := relinkSearchDir(t)
+
+	found, _, err := RelinkAssets(project, &Resolver{}, []string{search})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image := found["faces/ada.png"]; !strings.HasPrefix(image, "data:image/png;base64,") {
+		t.Errorf("image relinked to %.40s, want a data URI", image)
+	}
+	if audio, want := found["music/score.wav"], filepath.Join(search, "score.wav"); audio != want {
+		t.Errorf("audio relinked to %s, want %s", audio, want)
+	}
+}
+
+func TestRelinkAssetsNothingMissing(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "shot.png"), "shot")
+	project := models.NewProject("complete")
+	project.Panels = []models.Panel{{ID: "p1", ImageData: "shot.png"}}
+
+	found, missing, err := RelinkAssets(project, &Resolver{Dir: dir}, []string{dir})
+	if err != nil || len(found) != 0 || missing != nil {
+		t.Errorf("RelinkAssets = %v, %v, %v; want nothing to do", found, missing, err)
+	}
+}
@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// AssetKind names the asset store a reference belongs to
+type AssetKind string
+
+// Asset kinds; each has a store under <project>/assets/<kind>
+const (
+	AssetPanel     AssetKind = "panels"
+	AssetAudio     AssetKind = "audio"
+	AssetCharacter AssetKind = "characters"
+)
+
+// ErrFileNotFound is wrapped by Resolve when an asset's file does not exist
+var ErrFileNotFound = errors.New("file not found")
+
+// Resolver maps the asset paths stored in a project to files on disk. Every
+// path a project stores is an asset store reference or a slash-separated path
+// relative to the project folder; absolute paths and file:// URLs picked by
+// the user are accepted as they are.
+type Resolver struct {
+	Dir string // project folder; empty for a project that was never saved
+}
+
+// NewResolver returns the resolver for the project file at projectPath,
+// which may be empty for a project that was never saved
+func NewResolver(projectPath string) *Resolver {
+	if projectPath == "" {
+		return &Resolver{}
+	}
+	return &Resolver{Dir: ProjectDir(projectPath)}
+}
+
+// Store returns the asset store for kind
+func (r *Resolver) Store(kind AssetKind) *AssetStore {
+	return &AssetStore{Dir: filepath.Join(r.Dir, "assets", string(kind))}
+}
+
+// Resolve returns the file an asset path points to. It fails if the file
+// does not exist.
+func (r *Resolver) Resolve(kind AssetKind, ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return "", fmt.Errorf("no file given")
+	}
+
+	if IsAssetRef(ref) {
+		path, err := r.Store(kind).Path(ref)
+		if err != nil {
+			return "", err
+		}
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("%w: %s", ErrFileNotFound, ref)
+		}
+		return path, nil
+	}
+
+	path, err := localPath(ref)
+	if err != nil {
+		return "", err
+	}
+	if filepath.IsAbs(path) {
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("%w: %s", ErrFileNotFound, ref)
+		}
+		return path, nil
+	}
+
+	// Paths of legacy projects that were relative to the working directory
+	// are made absolute when the project is loaded, see pinLegacyPaths
+	if r.Dir != "" {
+		path = filepath.Join(r.Dir, path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("%w: %s", ErrFileNotFound, ref)
+	}
+	return path, nil
+}
+
+// DataURI returns the file an asset path points to as a base64 data URI,
+// for the page to display
+func (r *Resolver) DataURI(kind AssetKind, ref string) (string, error) {
+	path, err := r.Resolve(kind, ref)
+	if err != nil {
+		return "", err
+	}
+	return fileDataURI(path)
+}
+
+// Rel returns how a project stores the file at path: relative to the project
+// folder when it is inside it, otherwise absolute
+func (r *Resolver) Rel(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	if r.Dir != "" {
+		if dir, err := filepath.Abs(r.Dir); err == nil {
+			if rel, err := filepath.Rel(dir, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return filepath.ToSlash(rel)
+			}
+		}
+	}
+	return abs
+}
+
+// ExportDir returns the folder exports of the project file at projectPath
+// are written to by default: assets/exports next to the project file. Exports
+// of a bundle go next to the bundle rather than into it. A project that was
+// never saved exports to storyboard_flow/exports in the home folder, or in
+// the temp folder when there is no home folder.
+func ExportDir(projectPath string) string {
+	if projectPath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = os.TempDir()
+		}
+		return filepath.Join(home, "storyboard_flow", "exports")
+	}
+	return filepath.Join(filepath.Dir(projectPath), "assets", "exports")
+}
+
+// localPath turns a file:// URL or slash-separated path into an OS path
+func localPath(ref string) (string, error) {
+	if strings.HasPrefix(ref, "file://") {
+		u, err := url.Parse(ref)
+		if err != nil {
+			return "", fmt.Errorf("invalid file URL: %w", err)
+		}
+		ref = u.Path
+		// file:///C:/... parses to /C:/... on Windows
+		if len(ref) > 2 && ref[0] == '/' && ref[2] == ':' {
+			ref = ref[1:]
+		}
+	}
+	return filepath.FromSlash(ref), nil
+}
@@ -0,0 +1,19 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestExportDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	project := filepath.Join("work", "film", "board.json")
+	if got, want := ExportDir(project), filepath.Join("work", "film", "assets", "exports"); got != want {
+		t.Errorf("ExportDir(%s) = %s, want %s", project, got, want)
+	}
+	if got, want := ExportDir(""), filepath.Join(home, "storyboard_flow", "exports"); got != want {
+		t.Errorf("ExportDir of an unsaved project = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// MaxRecentProjects is the length of the recent projects list
+const MaxRecentProjects = 10
+
+// Settings are the per-user preferences kept in the user config directory
+type Settings struct {
+	RecentProjects []string `json:"recent_projects"` // absolute paths, most recent first
+	ReopenLast     bool     `json:"reopen_last"`     // open RecentProjects[0] on launch
+}
+
+// SettingsPath returns the location of the settings file
+func SettingsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("no user config directory: %w", err)
+	}
+	return filepath.Join(dir, "storyboard_flow", "settings.json"), nil
+}
+
+// LoadSettings reads the user settings. A missing file gives the defaults.
+func LoadSettings() (*Settings, error) {
+	settings := &Settings{RecentProjects: []string{}}
+
+	path, err := SettingsPath()
+	if err != nil {
+		return settings, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, err
+	}
+	if err := json.Unmarshal(data, settings); err != nil {
+		return &Settings{RecentProjects: []string{}}, fmt.Errorf("invalid settings file: %w", err)
+	}
+	if settings.RecentProjects == nil {
+		settings.RecentProjects = []string{}
+	}
+	return settings, nil
+}
+
+// SaveSettings writes the user settings
+func SaveSettings(settings *Settings) error {
+	path, err := SettingsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0644)
+}
+
+// AddRecentProject moves path to the front of the recent projects list
+func (s *Settings) AddRecentProject(path string) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	s.RemoveRecentProject(path)
+	s.RecentProjects = append([]string{path}, s.RecentProjects...)
+	if len(s.RecentProjects) > MaxRecentProjects {
+		s.RecentProjects = s.RecentProjects[:MaxRecentProjects]
+	}
+}
+
+// RemoveRecentProject drops path from the recent projects list
+func (s *Settings) RemoveRecentProject(path string) {
+	kept := s.RecentProjects[:0]
+	for _, p := range s.RecentProjects {
+		if p != path {
+			kept = append(kept, p)
+		}
+	}
+	s.RecentProjects = kept
+}
@@ -0,0 +1,112 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"storyboard_flow/internal/storage"
+)
+
+// relinkResult is returned by RelinkAssets
+type relinkResult struct {
+	Relinked int                    `json:"relinked"`
+	Missing  []storage.MissingAsset `json:"missing"`
+}
+
+// GetImage returns a panel or character image as a data URI for the page to
+// display. kind is "panels" or "characters"; src is the image value of the
+// panel or character, which the page holds as an asset reference or path.
+func (h *Handlers) GetImage(kind, src string) (string, error) {
+	if strings.HasPrefix(src, "data:") {
+		return src, nil
+	}
+	switch storage.AssetKind(kind) {
+	case storage.AssetPanel, storage.AssetCharacter:
+	default:
+		return "", fmt.Errorf("unknown image kind %q", kind)
+	}
+	return storage.NewResolver(h.state.GetProjectPath()).DataURI(storage.AssetKind(kind), src)
+}
+
+// GetPanelImage returns a panel's image as a data URI, or "" if it has none.
+// Events leave inline images out, so the page fetches them with this.
+func (h *Handlers) GetPanelImage(panelID string) (string, error) {
+	for _, panel := range h.state.GetPanels() {
+		if panel.ID == panelID {
+			if panel.ImageData == "" {
+				return "", nil
+			}
+			return h.GetImage(string(storage.AssetPanel), panel.ImageData)
+		}
+	}
+	return "", fmt.Errorf("panel %w", ErrNotFound)
+}
+
+// GetCharacterImage returns a character's image as a data URI, or "" if it
+// has none
+func (h *Handlers) GetCharacterImage(characterID string) (string, error) {
+	for _, char := range h.state.GetCharacters() {
+		if char.ID == characterID {
+			if char.ImagePath == "" {
+				return "", nil
+			}
+			return h.GetImage(string(storage.AssetCharacter), char.ImagePath)
+		}
+	}
+	return "", fmt.Errorf("character %w", ErrNotFound)
+}
+
+// GetMissingAssets returns the images and audio files the project references
+// but cannot find, as JSON
+func (h *Handlers) GetMissingAssets() (string, error) {
+	project := h.state.GetProject()
+	if project == nil {
+		return "", fmt.Errorf("no project loaded")
+	}
+
+	missing := storage.FindMissingAssets(project, storage.NewResolver(h.state.GetProjectPath()))
+	if missing == nil {
+		missing = []storage.MissingAsset{}
+	}
+	data, err := json.Marshal(missing)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// RelinkAssets searches searchDir and its subfolders for missing images and
+// audio files, matching them by name or content hash, and relinks the ones it
+// finds as one undo step. An empty searchDir searches the project folder.
+// It returns how many references were relinked and which are still missing.
+func (h *Handlers) RelinkAssets(searchDir string) (string, error) {
+	project := h.state.GetProject()
+	if project == nil {
+		return "", fmt.Errorf("no project loaded")
+	}
+
+	r := storage.NewResolver(h.state.GetProjectPath())
+	if searchDir == "" {
+		if r.Dir == "" {
+			return "", fmt.Errorf("choose a folder to search")
+		}
+		searchDir = r.Dir
+	}
+
+	replacements, missing, err := storage.RelinkAssets(project, r, []string{searchDir})
+	if err != nil {
+		return "", fmt.Errorf("failed to search %s: %w", searchDir, err)
+	}
+	h.state.RelinkAssets(replacements)
+
+	result := relinkResult{Relinked: len(replacements), Missing: missing}
+	if result.Missing == nil {
+		result.Missing = []storage.MissingAsset{}
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
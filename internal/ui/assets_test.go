@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"storyboard_flow/internal/app"
+	"storyboard_flow/internal/models"
+	"storyboard_flow/internal/storage"
+)
+
+func TestSavedImagesStayReferences(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+
+	uri := "data:image/png;base64,iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAAAAAA6fptVAAAACklEQVR4nGNgAAAAAgABSK+kcQAAAABJRU5ErkJggg=="
+	s := app.NewState()
+	s.NewProject("images")
+	panelID := s.GetPanels()[0].ID
+	s.UpdatePanel(panelID, func(p *models.Panel) { p.ImageData = uri })
+	h := NewHandlers(s)
+
+	if _, err := h.SaveProjectAs(filepath.Join(dir, "board.json")); err != nil {
+		t.Fatal(err)
+	}
+	ref := s.GetPanels()[0].ImageData
+	if !storage.IsAssetRef(ref) {
+		t.Fatalf("panel still holds %.40s after saving, want an asset reference", ref)
+	}
+	if s.Snapshot().Dirty {
+		t.Error("swapping in the reference marked the project dirty")
+	}
+
+	image, err := h.GetImage("panels", ref)
+	if err != nil || image != uri {
+		t.Errorf("GetImage = %.40s, %v; want the saved image", image, err)
+	}
+	if image, err := h.GetImage("panels", uri); err != nil || image != uri {
+		t.Errorf("GetImage of a data URI = %.40s, %v; want it unchanged", image, err)
+	}
+	if _, err := h.GetImage("audio", ref); err == nil {
+		t.Error("GetImage accepted the audio store")
+	}
+	if _, err := h.GetImage("panels", "asset:missing.png"); err == nil {
+		t.Error("GetImage of a missing reference succeeded")
+	}
+
+	if image, err := h.GetPanelImage(panelID); err != nil || image != uri {
+		t.Errorf("GetPanelImage = %.40s, %v; want the saved image", image, err)
+	}
+	if image, err := h.GetPanelImage(s.GetPanels()[1].ID); err != nil || image != "" {
+		t.Errorf("GetPanelImage of a panel without an image = %.40s, %v", image, err)
+	}
+	if _, err := h.GetPanelImage("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetPanelImage of an unknown panel = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,171 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"storyboard_flow/internal/app/exporter"
+	"storyboard_flow/internal/models"
+	"storyboard_flow/internal/storage"
+)
+
+// AddAudioTrack stores an audio file (base64 data URI) next to the project and
+// adds it as a music or temp score track. Returns the new clip as JSON.
+func (h *Handlers) AddAudioTrack(name, kind, dataURI string) (string, error) {
+	clip, err := h.storeAudio(name, kind, dataURI)
+	if err != nil {
+		return "", err
+	}
+
+	if !h.state.AddAudioTrack(*clip) {
+		return "", fmt.Errorf("failed to add audio track")
+	}
+
+	return marshalClip(clip)
+}
+
+// UpdateAudioTrack updates a field of a project audio track (name, kind,
+// offset or gain). Invalid values are rejected without touching the project.
+func (h *Handlers) UpdateAudioTrack(clipID, field string, value interface{}) error {
+	set, err := audioFieldSetter(field, value)
+	if err != nil {
+		return err
+	}
+	if !h.state.UpdateAudioTrack(clipID, set) {
+		return fmt.Errorf("audio track %w", ErrNotFound)
+	}
+	return nil
+}
+
+// RemoveAudioTrack removes a project audio track
+func (h *Handlers) RemoveAudioTrack(clipID string) error {
+	if !h.state.RemoveAudioTrack(clipID) {
+		return fmt.Errorf("audio track %w", ErrNotFound)
+	}
+	return nil
+}
+
+// GetAudioTracks returns the project's audio tracks as JSON
+func (h *Handlers) GetAudioTracks() (string, error) {
+	data, err := json.Marshal(h.state.GetAudioTracks())
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// AddPanelAudio stores an audio file (base64 data URI) next to the project and
+// attaches it to a panel as dialogue or SFX. Returns the new clip as JSON.
+func (h *Handlers) AddPanelAudio(panelID, name, kind, dataURI string) (string, error) {
+	clip, err := h.storeAudio(name, kind, dataURI)
+	if err != nil {
+		return "", err
+	}
+
+	if !h.state.AddPanelAudio(panelID, *clip) {
+		return "", fmt.Errorf("panel %w", ErrNotFound)
+	}
+
+	return marshalClip(clip)
+}
+
+// UpdatePanelAudio updates a field of a panel's audio clip (name, kind, offset
+// or gain). Invalid values are rejected without touching the project.
+func (h *Handlers) UpdatePanelAudio(panelID, clipID, field string, value interface{}) error {
+	set, err := audioFieldSetter(field, value)
+	if err != nil {
+		return err
+	}
+	if !h.state.UpdatePanelAudio(panelID, clipID, set) {
+		return fmt.Errorf("audio clip %w", ErrNotFound)
+	}
+	return nil
+}
+
+// RemovePanelAudio detaches an audio clip from a panel
+func (h *Handlers) RemovePanelAudio(panelID, clipID string) error {
+	if !h.state.RemovePanelAudio(panelID, clipID) {
+		return fmt.Errorf("audio clip %w", ErrNotFound)
+	}
+	return nil
+}
+
+// SnapPanelToAudio sets a panel's duration to end with one of its clips.
+// An empty clipID snaps to the clip that ends last.
+func (h *Handlers) SnapPanelToAudio(panelID, clipID string) error {
+	if !h.state.SnapPanelToAudio(panelID, clipID) {
+		return fmt.Errorf("panel has no audio clip with a known length")
+	}
+	return nil
+}
+
+// storeAudio writes an uploaded audio file to the project's audio store and
+// returns a clip for it. The project must have been saved so the audio has a
+// folder to live in.
+func (h *Handlers) storeAudio(name, kind, dataURI string) (*models.AudioClip, error) {
+	if !slices.Contains(models.AudioKinds, kind) {
+		return nil, fmt.Errorf("kind must be one of %s", strings.Join(models.AudioKinds, ", "))
+	}
+
+	projectPath := h.state.GetProjectPath()
+	if projectPath == "" {
+		return nil, fmt.Errorf("save the project before adding audio")
+	}
+
+	store := storage.NewAudioStore(storage.ProjectDir(projectPath))
+	ref, err := store.PutDataURI(dataURI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store audio: %w", err)
+	}
+
+	// A missing ffprobe only costs the snap-to-audio length
+	duration := 0.0
+	if path, err := store.Path(ref); err == nil {
+		if secs, err := exporter.ProbeAudioDuration(path); err == nil {
+			duration = secs
+		}
+	}
+
+	return models.NewAudioClip(name, kind, ref, duration), nil
+}
+
+// audioFieldSetter checks a JSON field update for a clip and returns the edit
+// that applies it
+func audioFieldSetter(field string, value interface{}) (func(c *models.AudioClip), error) {
+	switch field {
+	case "name":
+		v, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("name must be a string")
+		}
+		return func(c *models.AudioClip) { c.Name = v }, nil
+	case "kind":
+		v, ok := value.(string)
+		if !ok || !slices.Contains(models.AudioKinds, v) {
+			return nil, fmt.Errorf("kind must be one of %s", strings.Join(models.AudioKinds, ", "))
+		}
+		return func(c *models.AudioClip) { c.Kind = v }, nil
+	case "offset", "gain":
+		v, ok := value.(float64)
+		if !ok || math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("%s must be a finite number", field)
+		}
+		if field == "offset" {
+			return func(c *models.AudioClip) { c.Offset = v }, nil
+		}
+		return func(c *models.AudioClip) { c.Gain = v }, nil
+	default:
+		return nil, fmt.Errorf("unknown audio field %q", field)
+	}
+}
+
+func marshalClip(clip *models.AudioClip) (string, error) {
+	data, err := json.Marshal(clip)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"math"
+	"testing"
+
+	"storyboard_flow/internal/app"
+	"storyboard_flow/internal/models"
+)
+
+func TestUpdateAudioRejectsInvalidValues(t *testing.T) {
+	s := app.NewState()
+	s.NewProject("audio")
+	track := models.NewAudioClip("score", models.AudioMusic, "score.wav", 10)
+	s.AddAudioTrack(*track)
+	panelID := s.GetPanels()[0].ID
+	clip := models.NewAudioClip("line", models.AudioDialogue, "line.wav", 2)
+	s.AddPanelAudio(panelID, *clip)
+	s.MarkClean()
+	h := NewHandlers(s)
+
+	tests := []struct {
+		field string
+		value interface{}
+	}{
+		{"volume", 1.0},
+		{"name", 3.0},
+		{"kind", "drums"},
+		{"kind", true},
+		{"offset", "1"},
+		{"offset", math.Inf(1)},
+		{"gain", math.NaN()},
+		{"gain", math.Inf(-1)},
+	}
+	undo := len(s.GetHistory().Undo)
+	for _, tt := range tests {
+		if err := h.UpdateAudioTrack(track.ID, tt.field, tt.value); err == nil {
+			t.Errorf("track %s = %v: expected an error", tt.field, tt.value)
+		}
+		if err := h.UpdatePanelAudio(panelID, clip.ID, tt.field, tt.value); err == nil {
+			t.Errorf("panel clip %s = %v: expected an error", tt.field, tt.value)
+		}
+	}
+	if snap := s.Snapshot(); snap.Dirty || len(s.GetHistory().Undo) != undo {
+		t.Fatal("rejected updates marked the project dirty or recorded undo steps")
+	}
+
+	if err := h.UpdateAudioTrack(track.ID, "gain", -6.0); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.UpdatePanelAudio(panelID, clip.ID, "kind", models.AudioSFX); err != nil {
+		t.Fatal(err)
+	}
+	if gain := s.GetAudioTracks()[0].Gain; gain != -6 {
+		t.Errorf("gain = %v, want -6", gain)
+	}
+	if kind := s.GetPanels()[0].AudioClips[0].Kind; kind != models.AudioSFX {
+		t.Errorf("kind = %q, want %q", kind, models.AudioSFX)
+	}
+}
@@ -0,0 +1,139 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"storyboard_flow/internal/storage"
+)
+
+// AutosaveInterval is how often unsaved changes are written to the recovery file
+const AutosaveInterval = 30 * time.Second
+
+// recoveryInfo describes an autosave that is newer than its saved project
+type recoveryInfo struct {
+	RecoveryPath string    `json:"recovery_path"`
+	ProjectPath  string    `json:"project_path"` // empty if the project was never saved
+	Name         string    `json:"name"`
+	SavedAt      time.Time `json:"saved_at"`
+}
+
+// StartAutosave writes unsaved changes to the project's recovery file every
+// interval until stop is called. Failures are pushed as "autosave:failed"
+// events with the error.
+func (h *Handlers) StartAutosave(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := h.autosave(); err != nil {
+					h.emit("autosave:failed", map[string]string{"error": err.Error()})
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// autosave writes the project to its recovery file if it changed since the
+// last autosave
+func (h *Handlers) autosave() error {
+	h.saveMu.Lock()
+	defer h.saveMu.Unlock()
+
+	// Only edits that were never saved are worth recovering
+	snap := h.state.Snapshot()
+	if snap.Project == nil || !snap.Dirty || !snap.Edited || snap.Revision == h.autosaved {
+		return nil
+	}
+	if err := storage.WriteRecovery(snap.Project, snap.Path); err != nil {
+		return err
+	}
+	h.autosaved = snap.Revision
+	return nil
+}
+
+// clearRecovery removes the autosaves made obsolete by saving the project.
+// Callers must hold saveMu.
+func (h *Handlers) clearRecovery(paths ...string) {
+	for _, path := range paths {
+		storage.RemoveRecovery(path)
+	}
+}
+
+// GetRecoveries returns the autosaves that are newer than their saved
+// projects, newest first, as JSON. Unsaved projects and the recent projects
+// are checked.
+func (h *Handlers) GetRecoveries() (string, error) {
+	h.settingsMu.Lock()
+	settings, _ := storage.LoadSettings()
+	h.settingsMu.Unlock()
+
+	list := []recoveryInfo{}
+	for _, projectPath := range append([]string{""}, settings.RecentProjects...) {
+		rec, path := storage.FindRecovery(projectPath)
+		if rec == nil {
+			continue
+		}
+		list = append(list, recoveryInfo{
+			RecoveryPath: path,
+			ProjectPath:  rec.ProjectPath,
+			Name:         rec.Project.Name,
+			SavedAt:      rec.SavedAt,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].SavedAt.After(list[j].SavedAt) })
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// RecoverProject opens an autosave in place of its project. The changes stay
+// unsaved until the project is saved.
+func (h *Handlers) RecoverProject(recoveryPath string) (string, error) {
+	if err := checkRecoveryPath(recoveryPath); err != nil {
+		return "", err
+	}
+	rec, err := storage.ReadRecovery(recoveryPath)
+	if err != nil {
+		return "", err
+	}
+
+	h.state.SetProject(rec.Project, rec.ProjectPath)
+	h.state.MarkDirty()
+
+	return projectInfo(rec.Project, rec.ProjectPath)
+}
+
+// DiscardRecovery deletes an autosave
+func (h *Handlers) DiscardRecovery(recoveryPath string) error {
+	if err := checkRecoveryPath(recoveryPath); err != nil {
+		return err
+	}
+	if _, err := storage.ReadRecovery(recoveryPath); err != nil {
+		return err
+	}
+	return os.Remove(recoveryPath)
+}
+
+// checkRecoveryPath rejects paths that are not autosave files, so the page
+// cannot be used to read or delete arbitrary files
+func checkRecoveryPath(path string) error {
+	if !strings.HasSuffix(filepath.Base(path), ".autosave") {
+		return fmt.Errorf("not an autosave file: %s", path)
+	}
+	return nil
+}
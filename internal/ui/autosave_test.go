@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"storyboard_flow/internal/app"
+	"storyboard_flow/internal/models"
+)
+
+func TestAutosaveFailureIsReported(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+
+	// The recovery file would go inside a regular file, which cannot work
+	blocker := filepath.Join(dir, "blocker")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	s := app.NewState()
+	s.SetProject(models.NewProject("autosave"), filepath.Join(blocker, "board.json"))
+	s.UpdatePanel(s.GetPanels()[0].ID, func(p *models.Panel) { p.Dialogue = "unsaved" })
+
+	h := NewHandlers(s)
+	failures := make(chan string, 10)
+	h.AddNotifier(func(event string, data []byte) {
+		if event != "autosave:failed" {
+			return
+		}
+		var body struct {
+			Error string `json:"error"`
+		}
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("event is not JSON: %s", data)
+		}
+		failures <- body.Error
+	})
+
+	stop := h.StartAutosave(time.Millisecond)
+	defer stop()
+	select {
+	case msg := <-failures:
+		if msg == "" {
+			t.Error("failure event without an error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("autosave failure was not reported")
+	}
+}
@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"storyboard_flow/internal/models"
+)
+
+// DeletePanels removes several panels as one undo step
+func (h *Handlers) DeletePanels(panelIDs []string) error {
+	if !h.state.DeletePanels(panelIDs) {
+		return fmt.Errorf("panel %w", ErrNotFound)
+	}
+	return nil
+}
+
+// DuplicatePanels appends a copy of each panel, in board order, as one undo
+// step. Returns the copies as JSON.
+func (h *Handlers) DuplicatePanels(panelIDs []string) (string, error) {
+	copies := h.state.DuplicatePanels(panelIDs)
+	if copies == nil {
+		return "", fmt.Errorf("panel %w", ErrNotFound)
+	}
+
+	data, err := json.Marshal(copies)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// MovePanels moves several panels, in board order, so they form a block
+// starting at index
+func (h *Handlers) MovePanels(panelIDs []string, index int) error {
+	if !h.state.MovePanels(panelIDs, index) {
+		return fmt.Errorf("failed to move panels")
+	}
+	return nil
+}
+
+// PatchPanels applies a JSON object of panel fields, as accepted by
+// PatchPanel, to several panels as one undo step. Invalid values are returned
+// as field errors and nothing is changed.
+func (h *Handlers) PatchPanels(panelIDs []string, patch json.RawMessage) (string, error) {
+	p, errs := models.DecodePanelPatch(patch)
+	if len(errs) > 0 {
+		project := &models.Project{Characters: h.state.GetCharacters()}
+		errs = append(errs, p.Validate(project)...)
+	} else {
+		var found bool
+		if found, errs = h.state.PatchPanels(panelIDs, p); !found {
+			return "", fmt.Errorf("panel %w", ErrNotFound)
+		}
+	}
+
+	data, err := json.Marshal(PanelPatchResult{Errors: errs})
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ScalePanelDurations multiplies the duration of several panels by factor
+func (h *Handlers) ScalePanelDurations(panelIDs []string, factor float64) error {
+	if factor <= 0 || math.IsInf(factor, 0) || math.IsNaN(factor) {
+		return fmt.Errorf("scale factor must be a positive number")
+	}
+	if !h.state.ScalePanelDurations(panelIDs, factor) {
+		return fmt.Errorf("panel %w", ErrNotFound)
+	}
+	return nil
+}
+
+// AssignCharacters adds characters to several panels
+func (h *Handlers) AssignCharacters(panelIDs, characterIDs []string) error {
+	if !h.state.AssignCharacters(panelIDs, characterIDs) {
+		return fmt.Errorf("panel or character %w", ErrNotFound)
+	}
+	return nil
+}
+
+// UnassignCharacters removes characters from several panels
+func (h *Handlers) UnassignCharacters(panelIDs, characterIDs []string) error {
+	if !h.state.UnassignCharacters(panelIDs, characterIDs) {
+		return fmt.Errorf("panel %w", ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package ui
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"storyboard_flow/internal/app"
+)
+
+func TestScalePanelDurationsErrors(t *testing.T) {
+	s := app.NewState()
+	s.NewProject("batch")
+	h := NewHandlers(s)
+	ids := []string{s.GetPanels()[0].ID}
+
+	for _, factor := range []float64{0, -2, math.NaN(), math.Inf(1), math.Inf(-1)} {
+		err := h.ScalePanelDurations(ids, factor)
+		if err == nil || errors.Is(err, ErrNotFound) {
+			t.Errorf("scaling by %v = %v, want an invalid factor error", factor, err)
+		}
+	}
+	if err := h.ScalePanelDurations([]string{"missing"}, 2); !errors.Is(err, ErrNotFound) {
+		t.Errorf("scaling a missing panel = %v, want ErrNotFound", err)
+	}
+	if err := h.ScalePanelDurations(ids, 2); err != nil {
+		t.Errorf("scaling by 2 = %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// errNoDialog means no native file dialog is available; the page falls back
+// to asking for a path
+var errNoDialog = errors.New("no native file dialog available")
+
+// fileDialog shows the platform's open or save dialog for project files and
+// returns the chosen path, or "" if the user cancelled. suggested is the
+// initial file name of a save dialog.
+func fileDialog(save bool, title, suggested string) (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf(`POSIX path of (choose file with prompt %q of type {"json", "sbf"})`, title)
+		if save {
+			script = fmt.Sprintf(`POSIX path of (choose file name with prompt %q default name %q)`, title, suggested)
+		}
+		cmd = exec.Command("osascript", "-e", script)
+	case "windows":
+		kind := "OpenFileDialog"
+		if save {
+			kind = "SaveFileDialog"
+		}
+		script := fmt.Sprintf(`Add-Type -AssemblyName System.Windows.Forms
+$d = New-Object System.Windows.Forms.%s
+$d.Title = '%s'
+$d.Filter = 'Storyboard projects (*.json;*.sbf)|*.json;*.sbf|All files (*.*)|*.*'
+$d.FileName = '%s'
+if ($d.ShowDialog() -eq 'OK') { $d.FileName }`, kind, psQuote(title), psQuote(suggested))
+		cmd = exec.Command("powershell", "-NoProfile", "-STA", "-Command", script)
+	default:
+		if path, err := exec.LookPath("zenity"); err == nil {
+			args := []string{"--file-selection", "--title=" + title, "--file-filter=Storyboard projects | *.json *.sbf", "--file-filter=All files | *"}
+			if save {
+				args = append(args, "--save", "--confirm-overwrite", "--filename="+suggested)
+			}
+			cmd = exec.Command(path, args...)
+		} else if path, err := exec.LookPath("kdialog"); err == nil {
+			if save {
+				cmd = exec.Command(path, "--title", title, "--getsavefilename", suggested, "*.json *.sbf")
+			} else {
+				cmd = exec.Command(path, "--title", title, "--getopenfilename", ".", "*.json *.sbf")
+			}
+		} else {
+			return "", errNoDialog
+		}
+	}
+
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// Every supported dialog exits non-zero when cancelled
+			return "", nil
+		}
+		return "", errNoDialog
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// psQuote escapes s for a single-quoted PowerShell string
+func psQuote(s string) string {
+	return strings.ReplaceAll(s, "'", "''")
+}
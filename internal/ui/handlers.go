@@ -1,26 +1,50 @@
 package ui
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"storyboard_flow/internal/app"
 	"storyboard_flow/internal/app/exporter"
 	"storyboard_flow/internal/models"
+	"storyboard_flow/internal/script"
 	"storyboard_flow/internal/storage"
 )
 
+// ErrNotFound is wrapped by errors about a panel, scene, character or other
+// item that does not exist
+var ErrNotFound = errors.New("not found")
+
 // Handlers contains all the Go functions bound to JavaScript
 type Handlers struct {
 	state *app.State
+	jobs  exportJobs
+
+	notifyMu  sync.Mutex
+	notifiers []func(event string, data []byte) // push events to the UI and API clients
+
+	settingsMu sync.Mutex // serialises read-modify-write of the user settings file
+
+	saveMu    sync.Mutex // keeps autosaves from landing after a save
+	autosaved int        // state revision last written to the recovery file
 }
 
-// NewHandlers creates a new handlers instance
+// NewHandlers creates a new handlers instance. State events are forwarded to
+// the notifiers under their type, e.g. "panel:updated".
 func NewHandlers(state *app.State) *Handlers {
-	return &Handlers{state: state}
+	h := &Handlers{state: state}
+	state.Subscribe(func(e app.Event) {
+		h.emit(string(e.Type), e)
+	})
+	return h
 }
 
 // CreateNewProject creates a new project
@@ -59,6 +83,72 @@ func (h *Handlers) DuplicatePanel(panelID string) (string, error) {
 	return string(data), nil
 }
 
+// InsertPanelBefore adds a new panel in front of panelID and returns it as JSON
+func (h *Handlers) InsertPanelBefore(panelID string) (string, error) {
+	return marshalPanel(h.state.InsertPanelBefore(panelID))
+}
+
+// InsertPanelAfter adds a new panel right after panelID and returns it as JSON
+func (h *Handlers) InsertPanelAfter(panelID string) (string, error) {
+	return marshalPanel(h.state.InsertPanelAfter(panelID))
+}
+
+// DuplicatePanelInPlace inserts a copy of a panel right after it and returns
+// the copy as JSON
+func (h *Handlers) DuplicatePanelInPlace(panelID string) (string, error) {
+	return marshalPanel(h.state.DuplicatePanelInPlace(panelID))
+}
+
+// SplitPanel divides a panel's duration between parts panels, at most
+// app.MaxSplitParts, and returns them as JSON, the original first
+func (h *Handlers) SplitPanel(panelID string, parts int) (string, error) {
+	if parts < 2 || parts > app.MaxSplitParts {
+		return "", fmt.Errorf("a panel can be split into 2 to %d parts", app.MaxSplitParts)
+	}
+	panels := h.state.SplitPanel(panelID, parts)
+	if panels == nil {
+		for _, panel := range h.state.GetPanels() {
+			if panel.ID == panelID {
+				return "", fmt.Errorf("panel is too short to split into %d parts", parts)
+			}
+		}
+		return "", fmt.Errorf("panel %w", ErrNotFound)
+	}
+
+	data, err := json.Marshal(panels)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// marshalPanel returns a panel as JSON, or "panel not found" for nil
+func marshalPanel(panel *models.Panel) (string, error) {
+	if panel == nil {
+		return "", fmt.Errorf("panel %w", ErrNotFound)
+	}
+
+	data, err := json.Marshal(panel)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// GetProject returns the current project as JSON
+func (h *Handlers) GetProject() (string, error) {
+	project := h.state.GetProject()
+	if project == nil {
+		return "", fmt.Errorf("no project loaded")
+	}
+
+	data, err := json.Marshal(project)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
 // GetPanels returns all panels as JSON
 func (h *Handlers) GetPanels() (string, error) {
 	panels := h.state.GetPanels()
@@ -70,77 +160,114 @@ func (h *Handlers) GetPanels() (string, error) {
 	return string(data), nil
 }
 
-// UpdatePanel updates a specific field of a panel
-func (h *Handlers) UpdatePanel(panelID, field string, value interface{}) error {
-	updated := h.state.UpdatePanel(panelID, func(p *models.Panel) {
-		switch field {
-		case "action_notes":
-			if v, ok := value.(string); ok {
-				p.ActionNotes = v
-			}
-		case "dialogue":
-			if v, ok := value.(string); ok {
-				p.Dialogue = v
-			}
-		case "shot_type":
-			if v, ok := value.(string); ok {
-				p.ShotType = v
-			}
-		case "camera_angle":
-			if v, ok := value.(string); ok {
-				p.CameraAngle = v
-			}
-		case "camera_move":
-			if v, ok := value.(string); ok {
-				p.CameraMove = v
-			}
-		case "duration":
-			if v, ok := value.(float64); ok {
-				p.Duration = v
-			}
-		case "image_data":
-			if v, ok := value.(string); ok {
-				p.ImageData = v
-			}
-		case "character_ids":
-			if v, ok := value.([]interface{}); ok {
-				ids := make([]string, 0, len(v))
-				for _, id := range v {
-					if idStr, ok := id.(string); ok {
-						ids = append(ids, idStr)
-					}
-				}
-				p.CharacterIDs = ids
-			}
+// PanelPatchResult is what PatchPanel and PatchPanels return: the updated
+// panel (PatchPanel only), or the problems with the patch so the editor can
+// show them next to each field
+type PanelPatchResult struct {
+	Panel  *models.Panel      `json:"panel,omitempty"`
+	Errors models.FieldErrors `json:"errors,omitempty"`
+}
 
-		}
-	})
+// PatchPanel applies a JSON object of panel fields, such as
+// {"duration": 2.5, "shot_type": "Wide"}, as one edit. Invalid values are
+// returned as field errors and nothing is changed.
+func (h *Handlers) PatchPanel(panelID string, patch json.RawMessage) (string, error) {
+	panel, errs, err := h.patchPanel(panelID, patch)
+	if err != nil {
+		return "", err
+	}
 
-	if !updated {
-		return fmt.Errorf("panel not found")
+	data, err := json.Marshal(PanelPatchResult{Panel: panel, Errors: errs})
+	if err != nil {
+		return "", err
 	}
+	return string(data), nil
+}
 
+// UpdatePanel sets a single field of a panel, reporting invalid values as an
+// error
+func (h *Handlers) UpdatePanel(panelID, field string, value interface{}) error {
+	patch, err := json.Marshal(map[string]interface{}{field: value})
+	if err != nil {
+		return err
+	}
+	_, errs, err := h.patchPanel(panelID, patch)
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
 	return nil
 }
 
+// patchPanel decodes, validates and applies a patch
+func (h *Handlers) patchPanel(panelID string, patch []byte) (*models.Panel, models.FieldErrors, error) {
+	p, errs := models.DecodePanelPatch(patch)
+	if len(errs) > 0 {
+		// Report the problems with the fields that did decode as well
+		project := &models.Project{Characters: h.state.GetCharacters()}
+		return nil, append(errs, p.Validate(project)...), nil
+	}
+	panel, errs := h.state.PatchPanel(panelID, p)
+	if panel == nil && len(errs) == 0 {
+		return nil, nil, fmt.Errorf("panel %w", ErrNotFound)
+	}
+	return panel, errs, nil
+}
+
+// GetDefaultMotion returns the framing a camera move animates with when the
+// panel has no motion of its own, as JSON ("null" for a static shot)
+func (h *Handlers) GetDefaultMotion(move string) (string, error) {
+	data, err := json.Marshal(models.DefaultMotion(move))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
 // DeletePanel removes a panel
 func (h *Handlers) DeletePanel(panelID string) error {
 	if !h.state.DeletePanel(panelID) {
-		return fmt.Errorf("panel not found")
+		return fmt.Errorf("panel %w", ErrNotFound)
+	}
+	return nil
+}
+
+// SetProjectFormat sets the project's aspect ratio (e.g. "16:9", "2.39:1") and
+// the matte color ("#rrggbb", empty for black) around images of another shape
+func (h *Handlers) SetProjectFormat(aspectRatio, matteColor string) error {
+	if _, err := models.ParseAspectRatio(aspectRatio); err != nil {
+		return err
+	}
+	if matteColor != "" {
+		if _, _, _, err := models.ParseHexColor(matteColor); err != nil {
+			return err
+		}
+	}
+
+	if !h.state.SetProjectFormat(strings.TrimSpace(aspectRatio), matteColor) {
+		return fmt.Errorf("no project loaded")
 	}
 	return nil
 }
 
 // SaveProject saves the current project to a file
 func (h *Handlers) SaveProject() (string, error) {
-	project := h.state.GetProject()
-	projectPath := h.state.GetProjectPath()
+	h.saveMu.Lock()
+	defer h.saveMu.Unlock()
+
+	// Save a snapshot, so edits made while writing neither tear the file nor
+	// get marked as saved
+	snap := h.state.Snapshot()
+	project, projectPath := snap.Project, snap.Path
 
 	if project == nil {
 		return "", fmt.Errorf("no project to save")
 	}
 
 	// If no path set, use default
+	oldPath := projectPath
 	if projectPath == "" {
 		projectPath = fmt.Sprintf("projects/%s.json", project.Name)
 		h.state.SetProjectPath(projectPath)
@@ -150,33 +277,38 @@ func (h *Handlers) SaveProject() (string, error) {
 		return "", err
 	}
 
-	h.state.MarkClean()
+	h.state.MarkSaved(snap.Revision)
+	h.state.UseStoredImages(storage.ImageRefs(project))
+	h.clearRecovery(oldPath, projectPath)
+	h.rememberProject(projectPath)
 
 	return fmt.Sprintf("Project saved to %s", projectPath), nil
 }
 
-// LoadProject loads a project from a file
-func (h *Handlers) LoadProject() (string, error) {
-	// Use default path pattern - in real app would use file dialog
-	// For now, just look for project.json
-	filePath := "projects/project.json"
+// LoadProject opens the project file at filePath and returns its name, path
+// and panel count as JSON
+func (h *Handlers) LoadProject(filePath string) (string, error) {
+	filePath = strings.TrimSpace(filePath)
+	if filePath == "" {
+		return "", fmt.Errorf("no project file given")
+	}
 
 	project, err := storage.LoadProject(filePath)
 	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			h.forgetProject(filePath)
+		}
 		return "", err
 	}
 
 	h.state.SetProject(project, filePath)
-
-	data, err := json.Marshal(map[string]interface{}{
-		"name":   project.Name,
-		"panels": len(project.Panels),
-	})
-	if err != nil {
-		return "", err
+	if project.NeedsMigration {
+		// Keep the upgrade pending until the user saves
+		h.state.MarkDirty()
 	}
+	h.rememberProject(filePath)
 
-	return string(data), nil
+	return projectInfo(project, filePath)
 }
 
 // RenameProject renames the current project
@@ -195,21 +327,190 @@ func (h *Handlers) ReorderPanel(panelID string, newIndex int) error {
 	return nil
 }
 
+// Undo reverts the last change and returns the new history state as JSON
+func (h *Handlers) Undo() (string, error) {
+	if _, ok := h.state.Undo(); !ok {
+		return "", fmt.Errorf("nothing to undo")
+	}
+	return h.GetHistory()
+}
+
+// Redo re-applies the last undone change and returns the new history state as JSON
+func (h *Handlers) Redo() (string, error) {
+	if _, ok := h.state.Redo(); !ok {
+		return "", fmt.Errorf("nothing to redo")
+	}
+	return h.GetHistory()
+}
+
+// GetHistory returns the undo/redo stacks and the current project name, file
+// and format as JSON
+func (h *Handlers) GetHistory() (string, error) {
+	name, aspect, matte := "", "", ""
+	if project := h.state.GetProject(); project != nil {
+		name, aspect, matte = project.Name, project.AspectRatio, project.MatteColor
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"history":      h.state.GetHistory(),
+		"name":         name,
+		"path":         h.state.GetProjectPath(),
+		"aspect_ratio": aspect,
+		"matte_color":  matte,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// GetScenes returns sequences and per-scene timeline summaries as JSON
+func (h *Handlers) GetScenes() (string, error) {
+	data, err := json.Marshal(map[string]interface{}{
+		"sequences": h.state.GetSequences(),
+		"scenes":    h.state.GetSceneSummaries(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// AddSequence adds a new sequence and returns it as JSON
+func (h *Handlers) AddSequence(name string) (string, error) {
+	seq := h.state.AddSequence(name)
+	if seq == nil {
+		return "", fmt.Errorf("failed to add sequence")
+	}
+
+	data, err := json.Marshal(seq)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// AddScene adds a new scene and returns it as JSON.
+// sequenceID may be empty for a scene outside any sequence.
+func (h *Handlers) AddScene(setting, location, timeOfDay, sequenceID string) (string, error) {
+	heading := models.SceneHeading{Setting: setting, Location: location, TimeOfDay: timeOfDay}
+	scene := h.state.AddScene(heading, sequenceID)
+	if scene == nil {
+		return "", fmt.Errorf("failed to add scene")
+	}
+
+	data, err := json.Marshal(scene)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// UpdateScene sets a scene's heading and number
+func (h *Handlers) UpdateScene(sceneID, setting, location, timeOfDay, number string) error {
+	updated := h.state.UpdateScene(sceneID, func(sc *models.Scene) {
+		sc.Heading = models.SceneHeading{Setting: setting, Location: location, TimeOfDay: timeOfDay}
+		sc.Number = number
+	})
+	if !updated {
+		return fmt.Errorf("scene %w", ErrNotFound)
+	}
+	return nil
+}
+
+// AssignPanelsToScene moves panels into a scene (empty sceneID unassigns them)
+func (h *Handlers) AssignPanelsToScene(sceneID string, panelIDs []string) error {
+	if !h.state.AssignPanelsToScene(sceneID, panelIDs) {
+		return fmt.Errorf("failed to assign panels to scene")
+	}
+	return nil
+}
+
+// ReorderScene moves a scene to a new position
+func (h *Handlers) ReorderScene(sceneID string, newIndex int) error {
+	if !h.state.ReorderScene(sceneID, newIndex) {
+		return fmt.Errorf("failed to reorder scene")
+	}
+	return nil
+}
+
+// MergeScenes moves the panels of source into target and removes source
+func (h *Handlers) MergeScenes(targetID, sourceID string) error {
+	if !h.state.MergeScenes(targetID, sourceID) {
+		return fmt.Errorf("failed to merge scenes")
+	}
+	return nil
+}
+
+// SplitScene splits a scene at a panel and returns the new scene as JSON
+func (h *Handlers) SplitScene(sceneID, atPanelID string) (string, error) {
+	scene := h.state.SplitScene(sceneID, atPanelID)
+	if scene == nil {
+		return "", fmt.Errorf("failed to split scene")
+	}
+
+	data, err := json.Marshal(scene)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// ImportFountain parses Fountain screenplay text and appends its scenes,
+// panels and characters to the current project. filename is recorded on each
+// panel as the source of the script line it came from.
+func (h *Handlers) ImportFountain(filename, content string) (string, error) {
+	doc, err := script.ParseFountain(strings.NewReader(content))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse script: %w", err)
+	}
+
+	return h.importScript(doc, filename)
+}
+
+// ImportFDX parses a Final Draft (.fdx) screenplay and appends its scenes,
+// panels and characters to the current project. Scene numbers and revision
+// colors are kept as panel metadata.
+func (h *Handlers) ImportFDX(filename, content string) (string, error) {
+	doc, err := script.ParseFDX(strings.NewReader(content))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse script: %w", err)
+	}
+
+	return h.importScript(doc, filename)
+}
+
+// importScript adds a parsed screenplay to the project and returns the import summary
+func (h *Handlers) importScript(doc *script.Document, filename string) (string, error) {
+	result, ok := h.state.ImportScript(doc, filepath.Base(filename))
+	if !ok {
+		return "", fmt.Errorf("script contains no panels to import")
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
 // AddCharacter adds a new character to the project
 func (h *Handlers) AddCharacter(name, description, imageData string) (string, error) {
-	// 1. Save image if provided
-	imagePath := ""
+	// The image is kept inline until the project is saved, which moves it
+	// into the project's asset store
 	if imageData != "" {
-		var err error
-		imagePath, err = storage.SaveCharacterImage(imageData, "char")
-		if err != nil {
-			return "", fmt.Errorf("failed to save character image: %w", err)
+		if _, _, err := storage.ParseDataURI(imageData); err != nil {
+			return "", fmt.Errorf("failed to read character image: %w", err)
 		}
 	}
 
-	// 2. Add character to state
-	// Note: state.AddCharacter signature was updated to accept imagePath
-	char := h.state.AddCharacter(name, description, imagePath)
+	char := h.state.AddCharacter(name, description, imageData)
 	if char == nil {
 		return "", fmt.Errorf("failed to add character")
 	}
@@ -236,13 +537,15 @@ func (h *Handlers) GetCharacters() (string, error) {
 // DeleteCharacter removes a character from the project
 func (h *Handlers) DeleteCharacter(characterID string) error {
 	if !h.state.DeleteCharacter(characterID) {
-		return fmt.Errorf("character not found")
+		return fmt.Errorf("character %w", ErrNotFound)
 	}
 	return nil
 }
 
+// SaveExportHTML writes the page's print layout to the project's export
+// folder and returns the path
 func (h *Handlers) SaveExportHTML(filename, content string) (string, error) {
-	dir := filepath.FromSlash("assets/prints")
+	dir := storage.ExportDir(h.state.GetProjectPath())
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return "", err
 	}
@@ -262,43 +565,146 @@ func (h *Handlers) SaveExportHTML(filename, content string) (string, error) {
 	return path, nil
 }
 
-// ExportMP4 exports the current project to an MP4 file and returns the output path.
+// ExportMP4 exports the current project to an MP4 file and returns the export
+// report as JSON (output path plus any panels whose image could not be resolved).
 // filename may be empty to use a generated name. Optional sizing options can be
-// passed in via width/height/fps/bitrate (0 will use defaults).
-func (h *Handlers) ExportMP4(filename string, width, height, fps, bitrate int) (string, error) {
-	project := h.state.GetProject()
-	if project == nil {
+// passed in via width/height/fps/bitrate (0 will use defaults). overlays selects
+// the burn-ins drawn into every frame.
+func (h *Handlers) ExportMP4(filename string, width, height, fps, bitrate int, overlays exporter.Overlays) (string, error) {
+	snap := h.state.Snapshot()
+	if snap.Project == nil {
+		return "", fmt.Errorf("no project to export")
+	}
+
+	outPath, err := exportPath(snap.Project, snap.Path, filename, ".mp4")
+	if err != nil {
+		return "", err
+	}
+	opts := exportOptions(snap.Project, snap.Path, width, height, fps, bitrate)
+	opts.Overlays = overlays
+
+	report, err := exporter.ExportProjectToMP4(context.Background(), snap.Project, outPath, opts)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// ExportPDF exports the current project as a printable PDF storyboard and
+// returns the export report as JSON. perPage is 1, 3 or 6 panels per page
+// (0 uses 6); paperSize is "letter" or "a4". filename may be empty to use a
+// generated name.
+func (h *Handlers) ExportPDF(filename string, perPage int, landscape bool, paperSize string) (string, error) {
+	snap := h.state.Snapshot()
+	if snap.Project == nil {
 		return "", fmt.Errorf("no project to export")
 	}
 
-	dir := filepath.FromSlash("assets/exports")
+	outPath, err := exportPath(snap.Project, snap.Path, filename, ".pdf")
+	if err != nil {
+		return "", err
+	}
+
+	opts := exporter.PDFOptions{
+		PerPage:   perPage,
+		Landscape: landscape,
+		PaperSize: paperSize,
+	}
+	if snap.Path != "" {
+		opts.BaseDir = storage.ProjectDir(snap.Path)
+	}
+
+	report, err := exporter.ExportProjectToPDF(snap.Project, outPath, opts)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// ExportEDL exports the current project as a CMX3600 EDL plus a folder of
+// panel stills and returns the export report as JSON. filename may be empty
+// to use a generated name.
+func (h *Handlers) ExportEDL(filename string) (string, error) {
+	return h.exportEditList(filename, ".edl", exporter.ExportProjectToEDL)
+}
+
+// ExportFCPXML exports the current project as an FCPXML timeline plus a folder
+// of panel stills and returns the export report as JSON. filename may be empty
+// to use a generated name.
+func (h *Handlers) ExportFCPXML(filename string) (string, error) {
+	return h.exportEditList(filename, ".fcpxml", exporter.ExportProjectToFCPXML)
+}
+
+// exportEditList runs an edit list exporter with default options
+func (h *Handlers) exportEditList(filename, ext string, export func(*models.Project, string, exporter.ExportOptions) (*exporter.ExportReport, error)) (string, error) {
+	snap := h.state.Snapshot()
+	if snap.Project == nil {
+		return "", fmt.Errorf("no project to export")
+	}
+
+	outPath, err := exportPath(snap.Project, snap.Path, filename, ext)
+	if err != nil {
+		return "", err
+	}
+
+	report, err := export(snap.Project, outPath, exportOptions(snap.Project, snap.Path, 0, 0, 0, 0))
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// exportPath returns where an export named filename is written, in the
+// export folder of the project saved at projectPath, generating a
+// timestamped name with the given extension when filename is empty
+func exportPath(project *models.Project, projectPath, filename, ext string) (string, error) {
+	dir := storage.ExportDir(projectPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return "", err
 	}
 
 	if filename == "" {
 		ts := time.Now().Format("2006-01-02_15-04-05")
-		filename = fmt.Sprintf("%s_export_%s.mp4", project.Name, ts)
+		filename = fmt.Sprintf("%s_export_%s%s", project.Name, ts, ext)
 	}
 
-	safe := filepath.Base(filename)
-	outPath := filepath.Join(dir, safe)
+	return filepath.Join(dir, filepath.Base(filename)), nil
+}
 
-	// Default options
+// exportOptions returns the export settings for the project saved at
+// projectPath; zero arguments use defaults
+func exportOptions(project *models.Project, projectPath string, width, height, fps, bitrate int) exporter.ExportOptions {
 	opts := exporter.ExportOptions{
-		Width:       1280,
-		Height:      720,
 		FPS:         project.FrameRate,
 		Bitrate:     bitrate,
-		DefaultSecs: 3.0,
+		DefaultSecs: models.DefaultDuration,
 	}
 
-	if width > 0 {
-		opts.Width = width
-	}
-	if height > 0 {
-		opts.Height = height
+	// Relative image paths are stored relative to the project file
+	if projectPath != "" {
+		opts.BaseDir = storage.ProjectDir(projectPath)
 	}
+
+	// Sizes left at 0 follow the project's aspect ratio
+	opts.Width, opts.Height = exporter.FrameSize(project.Aspect(), width, height)
 	if fps > 0 {
 		opts.FPS = fps
 	}
@@ -310,9 +716,5 @@ func (h *Handlers) ExportMP4(filename string, width, height, fps, bitrate int) (
 		opts.Bitrate = 2000
 	}
 
-	if err := exporter.ExportProjectToMP4(project, outPath, opts); err != nil {
-		return "", err
-	}
-
-	return outPath, nil
+	return opts
 }
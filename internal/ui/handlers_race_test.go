@@ -0,0 +1,134 @@
+package ui
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"storyboard_flow/internal/app"
+	"storyboard_flow/internal/storage"
+)
+
+// TestConcurrentBindings calls bindings from many goroutines at once, the way
+// the webview, the HTTP API and autosave do, while the project is saved and
+// exported. Run with -race.
+func TestConcurrentBindings(t *testing.T) {
+	dir := t.TempDir()
+	// Keep settings, recovery files and bundle caches out of the real home
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+
+	h := NewHandlers(app.NewState())
+	var events atomic.Int64
+	h.AddNotifier(func(event string, data []byte) {
+		if !json.Valid(data) {
+			t.Errorf("event %s is not JSON: %s", event, data)
+		}
+		events.Add(1)
+	})
+
+	if err := h.CreateNewProject("race"); err != nil {
+		t.Fatal(err)
+	}
+	projectPath := filepath.Join(dir, "race.json")
+	if _, err := h.SaveProjectAs(projectPath); err != nil {
+		t.Fatal(err)
+	}
+	stop := h.StartAutosave(time.Millisecond)
+	defer stop()
+
+	// panelID picks a panel that exists at the time of the call
+	panelID := func(n int) string {
+		panels := h.state.GetPanels()
+		if len(panels) == 0 {
+			return ""
+		}
+		return panels[n%len(panels)].ID
+	}
+
+	const workers, rounds = 8, 40
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			// Edits may target panels another goroutine just deleted, so
+			// their errors are expected; reads, saves and exports must work
+			for i := 0; i < rounds; i++ {
+				id := panelID(w + i)
+				switch (w + i) % 10 {
+				case 0:
+					h.CreatePanel()
+				case 1:
+					h.DuplicatePanel(id)
+				case 2:
+					h.UpdatePanel(id, "dialogue", "line")
+					h.UpdatePanel(id, "duration", 2.5)
+				case 3:
+					h.ReorderPanel(id, i%6)
+				case 4:
+					if len(h.state.GetPanels()) > 4 {
+						h.DeletePanel(id)
+					}
+				case 5:
+					data, err := h.AddCharacter("Ada", "", "")
+					if err != nil {
+						t.Error(err)
+						continue
+					}
+					var char struct{ ID string }
+					json.Unmarshal([]byte(data), &char)
+					h.UpdatePanel(id, "character_ids", []interface{}{char.ID})
+					h.DeleteCharacter(char.ID)
+				case 6:
+					h.Undo()
+					h.Redo()
+				case 7:
+					if _, err := h.SaveProject(); err != nil {
+						t.Error(err)
+					}
+				case 8:
+					for _, read := range []func() (string, error){h.GetProject, h.GetPanels, h.GetCharacters, h.GetHistory, h.GetScenes} {
+						if _, err := read(); err != nil {
+							t.Error(err)
+						}
+					}
+				case 9:
+					// Exports render every panel, so only a few run
+					if i < 10 && w%2 == 0 {
+						if _, err := h.ExportEDL(""); err != nil {
+							t.Error(err)
+						}
+					} else {
+						h.RenameProject("race")
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if _, err := h.SaveProject(); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := storage.LoadProject(projectPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	live := h.state.GetProject()
+	if len(saved.Panels) != len(live.Panels) {
+		t.Fatalf("saved %d panels, project has %d", len(saved.Panels), len(live.Panels))
+	}
+	for i, panel := range saved.Panels {
+		if panel.ID != live.Panels[i].ID || panel.Order != i {
+			t.Fatalf("saved panel %d is %s (order %d), project has %s", i, panel.ID, panel.Order, live.Panels[i].ID)
+		}
+	}
+	if events.Load() == 0 {
+		t.Fatal("no events were pushed")
+	}
+}
@@ -0,0 +1,251 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"storyboard_flow/internal/app/exporter"
+)
+
+// Export job states
+const (
+	JobRunning   = "running"
+	JobDone      = "done"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// ExportJob is an export running in the background
+type ExportJob struct {
+	ID         string                 `json:"id"`
+	Kind       string                 `json:"kind"` // mp4
+	OutputPath string                 `json:"output_path"`
+	Status     string                 `json:"status"`
+	Done       int                    `json:"done"`  // frames written
+	Total      int                    `json:"total"` // frames in the export, 0 until known
+	Error      string                 `json:"error,omitempty"`
+	Report     *exporter.ExportReport `json:"report,omitempty"`
+	StartedAt  time.Time              `json:"started_at"`
+	FinishedAt *time.Time             `json:"finished_at,omitempty"`
+
+	cancel  context.CancelFunc
+	percent int // last progress percentage sent to the UI
+}
+
+// maxFinishedJobs is how many finished jobs are kept; older ones are dropped
+// as new jobs finish
+const maxFinishedJobs = 20
+
+// exportJobs tracks running and finished export jobs, oldest first
+type exportJobs struct {
+	mu     sync.Mutex
+	jobs   []*ExportJob
+	nextID int
+}
+
+// AddNotifier registers a function that receives pushed events such as export
+// progress, for the webview or API clients. data is JSON.
+func (h *Handlers) AddNotifier(notify func(event string, data []byte)) {
+	h.notifyMu.Lock()
+	defer h.notifyMu.Unlock()
+	h.notifiers = append(h.notifiers, notify)
+}
+
+// emit sends an event to every notifier
+func (h *Handlers) emit(event string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	h.notifyMu.Lock()
+	notifiers := h.notifiers
+	h.notifyMu.Unlock()
+
+	for _, notify := range notifiers {
+		notify(event, data)
+	}
+}
+
+// StartExportMP4 starts an MP4 export in the background and returns the job
+// as JSON. Progress is pushed as "export:progress" events and the outcome as
+// an "export:finished" event. Arguments are the same as ExportMP4.
+func (h *Handlers) StartExportMP4(filename string, width, height, fps, bitrate int, overlays exporter.Overlays) (string, error) {
+	// Export a copy so editing can carry on during the render
+	snap := h.state.Snapshot()
+	if snap.Project == nil {
+		return "", fmt.Errorf("no project to export")
+	}
+
+	outPath, err := exportPath(snap.Project, snap.Path, filename, ".mp4")
+	if err != nil {
+		return "", err
+	}
+	opts := exportOptions(snap.Project, snap.Path, width, height, fps, bitrate)
+	opts.Overlays = overlays
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := h.jobs.add("mp4", outPath, cancel)
+
+	opts.Progress = func(done, total int) {
+		if h.jobs.progress(job, done, total) {
+			h.emit("export:progress", h.jobs.snapshot(job))
+		}
+	}
+
+	go func() {
+		report, err := exporter.ExportProjectToMP4(ctx, snap.Project, outPath, opts)
+		h.jobs.finish(job, report, err)
+		h.emit("export:finished", h.jobs.snapshot(job))
+	}()
+
+	return marshalJob(h.jobs.snapshot(job))
+}
+
+// CancelExport stops a running export job. Its partial output is removed.
+func (h *Handlers) CancelExport(jobID string) error {
+	h.jobs.mu.Lock()
+	defer h.jobs.mu.Unlock()
+
+	for _, job := range h.jobs.jobs {
+		if job.ID == jobID {
+			if job.Status != JobRunning {
+				return fmt.Errorf("export has already finished")
+			}
+			job.cancel()
+			return nil
+		}
+	}
+	return fmt.Errorf("export job %w", ErrNotFound)
+}
+
+// GetExportJobs returns all export jobs, oldest first, as JSON
+func (h *Handlers) GetExportJobs() (string, error) {
+	h.jobs.mu.Lock()
+	list := make([]ExportJob, len(h.jobs.jobs))
+	for i, job := range h.jobs.jobs {
+		list[i] = *job
+	}
+	h.jobs.mu.Unlock()
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ClearFinishedExports removes finished, failed and cancelled jobs from the list
+func (h *Handlers) ClearFinishedExports() {
+	h.jobs.mu.Lock()
+	defer h.jobs.mu.Unlock()
+
+	running := h.jobs.jobs[:0]
+	for _, job := range h.jobs.jobs {
+		if job.Status == JobRunning {
+			running = append(running, job)
+		}
+	}
+	h.jobs.jobs = running
+}
+
+// add registers a new running job
+func (j *exportJobs) add(kind, outputPath string, cancel context.CancelFunc) *ExportJob {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.nextID++
+	job := &ExportJob{
+		ID:         fmt.Sprintf("export-%d", j.nextID),
+		Kind:       kind,
+		OutputPath: outputPath,
+		Status:     JobRunning,
+		StartedAt:  time.Now(),
+		cancel:     cancel,
+		percent:    -1,
+	}
+	j.jobs = append(j.jobs, job)
+	return job
+}
+
+// progress records frames written and reports whether the UI should be told,
+// which happens once per whole percent
+func (j *exportJobs) progress(job *ExportJob, done, total int) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	job.Done, job.Total = done, total
+	percent := 0
+	if total > 0 {
+		percent = done * 100 / total
+	}
+	if percent == job.percent {
+		return false
+	}
+	job.percent = percent
+	return true
+}
+
+// finish records the outcome of a job. An export that stopped because it was
+// cancelled is recorded as cancelled; one that completed is done even if
+// Cancel arrived too late to stop it.
+func (j *exportJobs) finish(job *ExportJob, report *exporter.ExportReport, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	job.FinishedAt = &now
+	job.Report = report
+	job.cancel()
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		job.Status = JobCancelled
+	case err != nil:
+		job.Status = JobFailed
+		job.Error = err.Error()
+	default:
+		job.Status = JobDone
+	}
+	j.prune()
+}
+
+// prune drops the oldest finished jobs beyond maxFinishedJobs. Running jobs
+// are always kept.
+func (j *exportJobs) prune() {
+	finished := 0
+	for _, job := range j.jobs {
+		if job.Status != JobRunning {
+			finished++
+		}
+	}
+
+	kept := j.jobs[:0]
+	for _, job := range j.jobs {
+		if job.Status != JobRunning && finished > maxFinishedJobs {
+			finished--
+			continue
+		}
+		kept = append(kept, job)
+	}
+	j.jobs = kept
+}
+
+// snapshot returns a copy of a job that is safe to marshal
+func (j *exportJobs) snapshot(job *ExportJob) ExportJob {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return *job
+}
+
+func marshalJob(job ExportJob) (string, error) {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
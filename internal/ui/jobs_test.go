@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"storyboard_flow/internal/app"
+)
+
+func TestFinishRecordsOutcome(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		cancel bool // cancel the context before finishing
+		status string
+	}{
+		{"completed", nil, false, JobDone},
+		{"completed after a late cancel", nil, true, JobDone},
+		{"failed", errors.New("encoder crashed"), false, JobFailed},
+		{"failed while cancelling", errors.New("disk full"), true, JobFailed},
+		{"cancelled", context.Canceled, true, JobCancelled},
+		{"cancelled, wrapped", fmt.Errorf("writing frame: %w", context.Canceled), true, JobCancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var jobs exportJobs
+			ctx, cancel := context.WithCancel(context.Background())
+			job := jobs.add("mp4", "out.mp4", cancel)
+			if tt.cancel {
+				cancel()
+			}
+
+			jobs.finish(job, nil, tt.err)
+			got := jobs.snapshot(job)
+			if got.Status != tt.status {
+				t.Errorf("status = %s, want %s", got.Status, tt.status)
+			}
+			if (got.Error != "") != (tt.status == JobFailed) {
+				t.Errorf("error = %q for a %s job", got.Error, got.Status)
+			}
+			if got.FinishedAt == nil {
+				t.Error("finish time not set")
+			}
+			if ctx.Err() == nil {
+				t.Error("finishing left the job's context running")
+			}
+		})
+	}
+}
+
+func TestFinishedJobsAreCapped(t *testing.T) {
+	var jobs exportJobs
+	running := jobs.add("mp4", "running.mp4", func() {})
+	for i := 0; i < maxFinishedJobs+5; i++ {
+		jobs.finish(jobs.add("mp4", fmt.Sprintf("%d.mp4", i), func() {}), nil, nil)
+	}
+
+	if n := len(jobs.jobs); n != maxFinishedJobs+1 {
+		t.Fatalf("kept %d jobs, want %d", n, maxFinishedJobs+1)
+	}
+	if jobs.jobs[0] != running {
+		t.Error("the running job was dropped")
+	}
+	if got, want := jobs.jobs[1].OutputPath, "5.mp4"; got != want {
+		t.Errorf("oldest finished job kept is %s, want %s", got, want)
+	}
+}
+
+func TestExportJobList(t *testing.T) {
+	h := NewHandlers(app.NewState())
+	ctx, cancel := context.WithCancel(context.Background())
+	running := h.jobs.add("mp4", "running.mp4", cancel)
+	done := h.jobs.add("mp4", "done.mp4", func() {})
+	h.jobs.finish(done, nil, nil)
+
+	var list []ExportJob
+	data, err := h.GetExportJobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(data), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].ID != running.ID || list[1].Status != JobDone {
+		t.Fatalf("jobs = %+v", list)
+	}
+
+	if err := h.CancelExport(done.ID); err == nil {
+		t.Error("cancelling a finished export succeeded")
+	}
+	if err := h.CancelExport("export-99"); err == nil {
+		t.Error("cancelling an unknown export succeeded")
+	}
+	if err := h.CancelExport(running.ID); err != nil {
+		t.Fatal(err)
+	}
+	if ctx.Err() == nil {
+		t.Error("cancel did not stop the export")
+	}
+
+	h.ClearFinishedExports()
+	if len(h.jobs.jobs) != 1 || h.jobs.jobs[0] != running {
+		t.Errorf("clearing kept %d jobs, want only the running one", len(h.jobs.jobs))
+	}
+
+	// The export stops and reports the cancellation
+	h.jobs.finish(running, nil, ctx.Err())
+	if got := h.jobs.snapshot(running).Status; got != JobCancelled {
+		t.Errorf("cancelled export finished as %s", got)
+	}
+}
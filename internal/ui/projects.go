@@ -0,0 +1,209 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"storyboard_flow/internal/models"
+	"storyboard_flow/internal/storage"
+)
+
+// recentProject is an entry of the recent projects list
+type recentProject struct {
+	Path   string `json:"path"`
+	Name   string `json:"name"`
+	Exists bool   `json:"exists"`
+}
+
+// ChooseProjectFile shows the native dialog for opening ("open") or saving
+// ("save") a project file. It returns {"path", "available"} as JSON: path is
+// empty if the user cancelled, and available is false when there is no native
+// dialog and the page should ask for a path itself.
+func (h *Handlers) ChooseProjectFile(mode string) (string, error) {
+	suggested := "project.json"
+	if project := h.state.GetProject(); project != nil && project.Name != "" {
+		suggested = project.Name + ".json"
+	}
+
+	var title string
+	switch mode {
+	case "open":
+		title = "Open Project"
+	case "save":
+		title = "Save Project As"
+	default:
+		return "", fmt.Errorf("unknown dialog mode: %s", mode)
+	}
+
+	path, err := fileDialog(mode == "save", title, suggested)
+	available := err != errNoDialog
+	if err != nil && available {
+		return "", err
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"path":      path,
+		"available": available,
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// SaveProjectAs saves the current project to filePath and keeps working on
+// that file. ".json" is added when the name has no extension.
+func (h *Handlers) SaveProjectAs(filePath string) (string, error) {
+	filePath = projectFilePath(filePath)
+	if filePath == "" {
+		return "", fmt.Errorf("no file name given")
+	}
+
+	h.saveMu.Lock()
+	defer h.saveMu.Unlock()
+
+	snap := h.state.Snapshot()
+	project, oldPath := snap.Project, snap.Path
+	if project == nil {
+		return "", fmt.Errorf("no project to save")
+	}
+	if err := storage.SaveProjectAs(project, oldPath, filePath); err != nil {
+		return "", err
+	}
+
+	h.state.SetProjectPath(filePath)
+	h.state.MarkSaved(snap.Revision)
+	h.state.UseStoredImages(storage.ImageRefs(project))
+	h.clearRecovery(oldPath, filePath)
+	h.rememberProject(filePath)
+
+	return projectInfo(project, filePath)
+}
+
+// NewProjectFromTemplate starts a new project at filePath from a copy of the
+// project file at templatePath and opens it
+func (h *Handlers) NewProjectFromTemplate(templatePath, filePath string) (string, error) {
+	templatePath = strings.TrimSpace(templatePath)
+	filePath = projectFilePath(filePath)
+	if templatePath == "" || filePath == "" {
+		return "", fmt.Errorf("a template and a new file name are required")
+	}
+	if _, err := os.Stat(filePath); err == nil {
+		return "", fmt.Errorf("%s already exists", filePath)
+	}
+
+	project, err := storage.CreateFromTemplate(templatePath, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	h.state.SetProject(project, filePath)
+	h.state.UseStoredImages(storage.ImageRefs(project))
+	h.rememberProject(filePath)
+
+	return projectInfo(project, filePath)
+}
+
+// GetRecentProjects returns the recent projects, most recent first, and the
+// reopen-last setting as JSON
+func (h *Handlers) GetRecentProjects() (string, error) {
+	h.settingsMu.Lock()
+	settings, _ := storage.LoadSettings()
+	h.settingsMu.Unlock()
+
+	projects := make([]recentProject, 0, len(settings.RecentProjects))
+	for _, path := range settings.RecentProjects {
+		_, err := os.Stat(path)
+		projects = append(projects, recentProject{
+			Path:   path,
+			Name:   strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+			Exists: err == nil,
+		})
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"projects":    projects,
+		"reopen_last": settings.ReopenLast,
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// SetReopenLast sets whether the most recent project opens on launch
+func (h *Handlers) SetReopenLast(enabled bool) error {
+	return h.updateSettings(func(s *storage.Settings) {
+		s.ReopenLast = enabled
+	})
+}
+
+// ReopenLastProject opens the most recent project when reopen-last is on.
+// It returns the project like LoadProject, or "null" if nothing was opened.
+func (h *Handlers) ReopenLastProject() (string, error) {
+	h.settingsMu.Lock()
+	settings, _ := storage.LoadSettings()
+	h.settingsMu.Unlock()
+
+	if !settings.ReopenLast || len(settings.RecentProjects) == 0 {
+		return "null", nil
+	}
+	return h.LoadProject(settings.RecentProjects[0])
+}
+
+// rememberProject puts path at the top of the recent projects list. Failing
+// to write the settings file does not fail the open or save.
+func (h *Handlers) rememberProject(path string) {
+	h.updateSettings(func(s *storage.Settings) {
+		s.AddRecentProject(path)
+	})
+}
+
+// forgetProject drops a project that no longer exists from the recent list
+func (h *Handlers) forgetProject(path string) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	h.updateSettings(func(s *storage.Settings) {
+		s.RemoveRecentProject(path)
+	})
+}
+
+// updateSettings applies change to the user settings and saves them
+func (h *Handlers) updateSettings(change func(s *storage.Settings)) error {
+	h.settingsMu.Lock()
+	defer h.settingsMu.Unlock()
+
+	settings, err := storage.LoadSettings()
+	if err != nil {
+		return err
+	}
+	change(settings)
+	return storage.SaveSettings(settings)
+}
+
+// projectFilePath cleans a user-entered project path, adding ".json" when it
+// has no extension
+func projectFilePath(path string) string {
+	path = strings.TrimSpace(path)
+	if path != "" && filepath.Ext(path) == "" {
+		path += ".json"
+	}
+	return path
+}
+
+// projectInfo describes an opened or saved project as JSON
+func projectInfo(project *models.Project, path string) (string, error) {
+	data, err := json.Marshal(map[string]interface{}{
+		"name":   project.Name,
+		"path":   path,
+		"panels": len(project.Panels),
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
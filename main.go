@@ -2,13 +2,17 @@ package main
 
 import (
 	"embed"
+	"fmt"
 	"io/fs"
 	"log"
+	"os"
 	"strings"
 
 	webview "github.com/webview/webview_go"
 
+	"storyboard_flow/internal/api"
 	"storyboard_flow/internal/app"
+	"storyboard_flow/internal/cli"
 	"storyboard_flow/internal/ui"
 )
 
@@ -16,12 +20,21 @@ import (
 var webFS embed.FS
 
 func main() {
+	// With arguments, run headless as a command line tool
+	if len(os.Args) > 1 {
+		os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
+	}
+
 	// Create application state
 	state := app.NewState()
 
 	// Create handlers
 	handlers := ui.NewHandlers(state)
 
+	// Keep unsaved changes in a recovery file in case of a crash
+	stopAutosave := handlers.StartAutosave(ui.AutosaveInterval)
+	defer stopAutosave()
+
 	// Create webview
 	debug := true
 	w := webview.New(debug)
@@ -30,22 +43,90 @@ func main() {
 	w.SetTitle("Storyboard Flow")
 	w.SetSize(1400, 900, webview.HintNone)
 
+	// Push backend events (state changes, export progress) into the page
+	handlers.AddNotifier(func(event string, data []byte) {
+		w.Dispatch(func() {
+			w.Eval(fmt.Sprintf("window.onBackendEvent && window.onBackendEvent(%q, %s)", event, data))
+		})
+	})
+
+	// Serve the local HTTP API when an address is configured
+	if addr := os.Getenv("STORYBOARD_FLOW_API"); addr != "" {
+		startAPI(handlers, addr)
+	}
+
 	// Bind Go functions to JavaScript
 	w.Bind("createNewProject", handlers.CreateNewProject)
 	w.Bind("createPanel", handlers.CreatePanel)
 	w.Bind("getPanels", handlers.GetPanels)
 	w.Bind("updatePanel", handlers.UpdatePanel)
+	w.Bind("patchPanel", handlers.PatchPanel)
+	w.Bind("getDefaultMotion", handlers.GetDefaultMotion)
 	w.Bind("deletePanel", handlers.DeletePanel)
 	w.Bind("saveProject", handlers.SaveProject)
 	w.Bind("loadProject", handlers.LoadProject)
+	w.Bind("saveProjectAs", handlers.SaveProjectAs)
+	w.Bind("newProjectFromTemplate", handlers.NewProjectFromTemplate)
+	w.Bind("chooseProjectFile", handlers.ChooseProjectFile)
+	w.Bind("getRecentProjects", handlers.GetRecentProjects)
+	w.Bind("setReopenLast", handlers.SetReopenLast)
+	w.Bind("reopenLastProject", handlers.ReopenLastProject)
+	w.Bind("getRecoveries", handlers.GetRecoveries)
+	w.Bind("recoverProject", handlers.RecoverProject)
+	w.Bind("discardRecovery", handlers.DiscardRecovery)
+	w.Bind("getImage", handlers.GetImage)
+	w.Bind("getPanelImage", handlers.GetPanelImage)
+	w.Bind("getCharacterImage", handlers.GetCharacterImage)
+	w.Bind("getMissingAssets", handlers.GetMissingAssets)
+	w.Bind("relinkAssets", handlers.RelinkAssets)
 	w.Bind("renameProject", handlers.RenameProject)
+	w.Bind("setProjectFormat", handlers.SetProjectFormat)
 	w.Bind("saveExportHTML", handlers.SaveExportHTML)
 	w.Bind("exportMP4", handlers.ExportMP4)
+	w.Bind("startExportMP4", handlers.StartExportMP4)
+	w.Bind("cancelExport", handlers.CancelExport)
+	w.Bind("getExportJobs", handlers.GetExportJobs)
+	w.Bind("clearFinishedExports", handlers.ClearFinishedExports)
+	w.Bind("exportPDF", handlers.ExportPDF)
+	w.Bind("exportEDL", handlers.ExportEDL)
+	w.Bind("exportFCPXML", handlers.ExportFCPXML)
 	w.Bind("duplicatePanel", handlers.DuplicatePanel)
+	w.Bind("duplicatePanelInPlace", handlers.DuplicatePanelInPlace)
+	w.Bind("insertPanelBefore", handlers.InsertPanelBefore)
+	w.Bind("insertPanelAfter", handlers.InsertPanelAfter)
+	w.Bind("splitPanel", handlers.SplitPanel)
 	w.Bind("reorderPanel", handlers.ReorderPanel)
+	w.Bind("deletePanels", handlers.DeletePanels)
+	w.Bind("duplicatePanels", handlers.DuplicatePanels)
+	w.Bind("movePanels", handlers.MovePanels)
+	w.Bind("patchPanels", handlers.PatchPanels)
+	w.Bind("scalePanelDurations", handlers.ScalePanelDurations)
+	w.Bind("assignCharacters", handlers.AssignCharacters)
+	w.Bind("unassignCharacters", handlers.UnassignCharacters)
 	w.Bind("addCharacter", handlers.AddCharacter)
 	w.Bind("getCharacters", handlers.GetCharacters)
 	w.Bind("deleteCharacter", handlers.DeleteCharacter)
+	w.Bind("undo", handlers.Undo)
+	w.Bind("redo", handlers.Redo)
+	w.Bind("getHistory", handlers.GetHistory)
+	w.Bind("getScenes", handlers.GetScenes)
+	w.Bind("addSequence", handlers.AddSequence)
+	w.Bind("addScene", handlers.AddScene)
+	w.Bind("updateScene", handlers.UpdateScene)
+	w.Bind("assignPanelsToScene", handlers.AssignPanelsToScene)
+	w.Bind("reorderScene", handlers.ReorderScene)
+	w.Bind("mergeScenes", handlers.MergeScenes)
+	w.Bind("splitScene", handlers.SplitScene)
+	w.Bind("importFountain", handlers.ImportFountain)
+	w.Bind("importFDX", handlers.ImportFDX)
+	w.Bind("addAudioTrack", handlers.AddAudioTrack)
+	w.Bind("updateAudioTrack", handlers.UpdateAudioTrack)
+	w.Bind("removeAudioTrack", handlers.RemoveAudioTrack)
+	w.Bind("getAudioTracks", handlers.GetAudioTracks)
+	w.Bind("addPanelAudio", handlers.AddPanelAudio)
+	w.Bind("updatePanelAudio", handlers.UpdatePanelAudio)
+	w.Bind("removePanelAudio", handlers.RemovePanelAudio)
+	w.Bind("snapPanelToAudio", handlers.SnapPanelToAudio)
 
 	// Load HTML from embedded filesystem
 	log.Println("Loading web assets...")
@@ -102,6 +183,11 @@ func main() {
 	html := string(htmlBytes)
 	html = injectAssets(html, string(cssBytes), string(appJSBytes), string(panelsJSBytes), string(charactersJSBytes), string(timelineCSSBytes), string(timelineJSBytes))
 
+	// Later feature scripts are inlined straight from the embedded filesystem
+	for _, name := range []string{"soundtrack.js", "jobs.js", "events.js", "projects.js"} {
+		html = inlineScript(html, name)
+	}
+
 	log.Printf("Final HTML length: %d bytes\n", len(html))
 	log.Println("Setting HTML content...")
 
@@ -121,3 +207,44 @@ func injectAssets(html, css, appJS, panelsJS, charactersJS, timelineCSS, timelin
 	html = strings.ReplaceAll(html, `<script src="js/timeline.js"></script>`, `<script>`+timelineJS+`</script>`)
 	return html
 }
+
+// inlineScript replaces the script tag for web/js/<name> with its content
+func inlineScript(html, name string) string {
+	data, err := fs.ReadFile(webFS, "web/js/"+name)
+	if err != nil {
+		log.Printf("Warning: Failed to read %s: %v\n", name, err)
+		return html
+	}
+	log.Printf("Loaded %s: %d bytes\n", name, len(data))
+	return strings.ReplaceAll(html, `<script src="js/`+name+`"></script>`, `<script>`+string(data)+`</script>`)
+}
+
+// startAPI serves the HTTP API on addr in the background. Edits made through
+// the API reach the page as state events, like any other edit. addr must be
+// a loopback address unless STORYBOARD_FLOW_API_ALLOW_REMOTE is set to 1.
+func startAPI(handlers *ui.Handlers, addr string) {
+	allowRemote := os.Getenv("STORYBOARD_FLOW_API_ALLOW_REMOTE") == "1"
+	if err := api.CheckAddr(addr, allowRemote); err != nil {
+		log.Printf("API disabled: %v; set STORYBOARD_FLOW_API_ALLOW_REMOTE=1 to serve it there", err)
+		return
+	}
+
+	token := os.Getenv("STORYBOARD_FLOW_API_TOKEN")
+	if token == "" {
+		var err error
+		if token, err = api.NewToken(); err != nil {
+			log.Println("API disabled:", err)
+			return
+		}
+		log.Println("API token:", token)
+	}
+
+	server := api.NewServer(handlers, token)
+	server.AllowRemote = allowRemote
+	go func() {
+		log.Println("API listening on", addr)
+		if err := server.ListenAndServe(addr); err != nil {
+			log.Println("API stopped:", err)
+		}
+	}()
+}
@@ -32,14 +32,58 @@ function updateThemeToggle() {
     btn.title = isDark ? 'Switch to light mode' : 'Switch to dark mode';
 }
 
+// Images turns the image values of panels and characters into something an
+// <img> can show. Saved images are asset references that the backend reads
+// on demand; images not saved yet are data URIs and shown as they are.
+const Images = {
+    cache: new Map(), // kind + src -> promise of a data URI
+    seq: 0,
+
+    // load resolves to a data URI for src, or '' if it cannot be read
+    load(kind, src) {
+        if (!src) return Promise.resolve('');
+        if (src.startsWith('data:')) return Promise.resolve(src);
+        const key = kind + '\0' + src;
+        if (!this.cache.has(key)) {
+            this.cache.set(key, getImage(kind, src).catch(() => {
+                this.cache.delete(key); // try again after a relink
+                return '';
+            }));
+        }
+        return this.cache.get(key);
+    },
+
+    // tag returns an <img> for src that fills itself in once the image is read
+    tag(kind, src, attrs) {
+        if (src.startsWith('data:')) return `<img src="${src}" ${attrs}>`;
+        const id = `image-${++this.seq}`;
+        this.load(kind, src).then(uri => {
+            const img = document.getElementById(id);
+            if (img && uri) img.src = uri;
+        });
+        return `<img id="${id}" ${attrs}>`;
+    },
+
+    // clear forgets resolved images; relative paths mean something else in another project
+    clear() {
+        this.cache.clear();
+    }
+};
+
 // Main application state and handlers
 const app = {
     currentProject: null,
     selectedPanelId: null,
+    panels: [], // kept current by BoardEvents
 
     async init() {
-        // Create a default project on startup
-        this.newProject();
+        // Recover autosaved changes, else reopen the last project if asked
+        // to, otherwise start a new one
+        const opened = typeof Projects !== 'undefined' &&
+            (await Projects.recover() || await Projects.reopen());
+        if (!opened) {
+            await this.newProject();
+        }
         await this.refreshPanels();
         // Initialize theme
         Theme.apply(Theme.get());
@@ -50,6 +94,15 @@ const app = {
         if (typeof Characters !== 'undefined') {
             Characters.init();
         }
+        if (typeof Soundtrack !== 'undefined') {
+            Soundtrack.init();
+        }
+        if (typeof ExportJobs !== 'undefined') {
+            ExportJobs.init();
+        }
+        if (typeof Projects !== 'undefined') {
+            Projects.init();
+        }
     },
 
     async newProject() {
@@ -66,6 +119,10 @@ const app = {
             document.getElementById('projectName').textContent = name;
             await this.refreshPanels();
             this.clearEditor();
+            if (typeof Soundtrack !== 'undefined') {
+                await Soundtrack.refresh();
+                Soundtrack.renderList();
+            }
         } catch (err) {
             alert('Error creating project: ' + err);
         }
@@ -77,30 +134,20 @@ const app = {
             return;
         }
 
-        try {
-            await saveProject();
-        } catch (err) {
-            alert('Error saving project: ' + err);
+        // A project that has never been saved needs a file name first
+        if (!this.currentProject.path && typeof Projects !== 'undefined') {
+            await Projects.saveAs();
+            return;
         }
-    },
 
-    async loadProject() {
         try {
-            const resultStr = await loadProject();
-            const result = JSON.parse(resultStr);
-            this.currentProject = { name: result.name || 'Loaded Project' };
-            this.selectedPanelId = null;
-            document.getElementById('projectName').textContent = this.currentProject.name;
-            await this.refreshPanels();
-            this.clearEditor();
-            
-            // Refresh characters after loading project
-            if (typeof Characters !== 'undefined') {
-                await Characters.refresh();
-                Characters.renderList();
+            await saveProject();
+            if (typeof Projects !== 'undefined') {
+                await Projects.refreshRecent();
+                Projects.renderRecentMenu();
             }
         } catch (err) {
-            alert('Error loading project: ' + err);
+            alert('Error saving project: ' + err);
         }
     },
 
@@ -108,45 +155,75 @@ const app = {
         try {
             const panelStr = await createPanel();
             const panel = JSON.parse(panelStr);
-            await this.refreshPanels();
             this.selectPanel(panel.id);
         } catch (err) {
             alert('Error adding panel: ' + err);
         }
     },
 
+    async insertPanelAfter(panelId) {
+        try {
+            const panel = JSON.parse(await insertPanelAfter(panelId));
+            this.selectPanel(panel.id);
+        } catch (err) {
+            alert('Error inserting panel: ' + err);
+        }
+    },
+
     async duplicatePanel(panelId) {
         try {
-            const panelStr = await duplicatePanel(panelId);
+            const panelStr = await duplicatePanelInPlace(panelId);
             const panel = JSON.parse(panelStr);
-            await this.refreshPanels();
             this.selectPanel(panel.id);
         } catch (err) {
             alert('Error duplicating panel: ' + err);
         }
     },
 
+    async splitPanel(panelId) {
+        const answer = prompt('Split this panel into how many panels?', '2');
+        if (answer === null) return;
+        const parts = parseInt(answer, 10);
+        if (!(parts >= 2 && parts <= 100)) {
+            alert('Enter a number from 2 to 100');
+            return;
+        }
+
+        try {
+            await splitPanel(panelId, parts);
+            if (this.selectedPanelId === panelId) this.selectPanel(panelId);
+        } catch (err) {
+            alert('Error splitting panel: ' + err);
+        }
+    },
+
+    // refreshPanels fetches every panel and redraws the board. Edits don't
+    // need it: their events keep this.panels current (see events.js).
     async refreshPanels() {
         try {
             const panelsStr = await getPanels();
-            const panels = JSON.parse(panelsStr);
-            renderPanelGrid(panels);
-            
-            // Refresh timeline if it's visible
-            if (typeof Timeline !== 'undefined' && Timeline) {
-                const container = document.getElementById('timelineContainer');
-                if (container && container.style.display !== 'none') {
-                    await Timeline.refresh();
-                }
-            }
+            this.panels = JSON.parse(panelsStr);
+            renderPanelGrid(this.panels);
+            this.refreshHistory();
+            await this.refreshTimeline();
         } catch (err) {
             console.error('Error refreshing panels:', err);
         }
     },
 
+    // Refresh timeline if it's visible
+    async refreshTimeline() {
+        if (typeof Timeline !== 'undefined' && Timeline) {
+            const container = document.getElementById('timelineContainer');
+            if (container && container.style.display !== 'none') {
+                await Timeline.refresh(this.panels);
+            }
+        }
+    },
+
     selectPanel(panelId) {
         this.selectedPanelId = panelId;
-        this.refreshPanels(); // Re-render to show selection
+        renderPanelGrid(this.panels); // Re-render to show selection
         this.loadPanelEditor(panelId);
     },
 
@@ -170,7 +247,8 @@ const app = {
 
     async updatePanelField(panelId, field, value) {
         try {
-            await updatePanel(panelId, field, value);
+            const result = JSON.parse(await patchPanel(panelId, { [field]: value }));
+            showFieldErrors([field], result.errors || []);
         } catch (err) {
             console.error('Error updating panel:', err);
         }
@@ -185,7 +263,6 @@ const app = {
                 this.selectedPanelId = null;
                 this.clearEditor();
             }
-            await this.refreshPanels();
         } catch (err) {
             alert('Error deleting panel: ' + err);
         }
@@ -211,10 +288,31 @@ const app = {
         }
     },
 
+    // Exports are sized to the aspect ratio; images of another shape get
+    // bars in the matte color
+    async setProjectFormat() {
+        if (!this.currentProject) {
+            alert('No project loaded');
+            return;
+        }
+
+        const aspect = prompt('Aspect ratio (e.g. 16:9, 4:3, 1.85:1, 2.39:1):', this.currentProject.aspect_ratio || '16:9');
+        if (!aspect || aspect.trim() === '') return;
+        const matte = prompt('Matte color for letterbox/pillarbox bars (#rrggbb, empty for black):', this.currentProject.matte_color || '');
+        if (matte === null) return;
+
+        try {
+            await setProjectFormat(aspect.trim(), matte.trim());
+            this.currentProject.aspect_ratio = aspect.trim();
+            this.currentProject.matte_color = matte.trim();
+        } catch (err) {
+            alert('Error changing aspect ratio: ' + err);
+        }
+    },
+
     async reorderPanel(panelId, newIndex) {
         try {
             await reorderPanel(panelId, newIndex);
-            await this.refreshPanels();
         } catch (err) {
             console.error('Error reordering panel:', err);
         }
@@ -222,16 +320,14 @@ const app = {
 
     async movePanel(panelId, direction) {
         try {
-            const panelsStr = await getPanels();
-            const panels = JSON.parse(panelsStr);
-            const index = panels.findIndex(p => p.id === panelId);
+            const index = this.panels.findIndex(p => p.id === panelId);
 
             if (index === -1) return;
 
             const newIndex = index + direction;
 
             // Check bounds
-            if (newIndex < 0 || newIndex >= panels.length) return;
+            if (newIndex < 0 || newIndex >= this.panels.length) return;
 
             await this.reorderPanel(panelId, newIndex);
         } catch (err) {
@@ -245,6 +341,36 @@ const app = {
             return;
         }
 
+        const layout = prompt('Panels per page (1, 3 or 6). Add "L" for landscape, e.g. 6L:', '6');
+        if (layout === null) return;
+        const match = layout.trim().match(/^([136])\s*(l?)$/i);
+        if (!match) {
+            alert('Please enter 1, 3 or 6, optionally followed by L');
+            return;
+        }
+
+        try {
+            const report = JSON.parse(await exportPDF('', parseInt(match[1], 10), match[2] !== '', 'letter'));
+            let msg = 'PDF export finished. Output: ' + report.output_path;
+            if (report.unresolved && report.unresolved.length > 0) {
+                msg += '\n\nThese panels were exported without an image:';
+                for (const u of report.unresolved) {
+                    msg += `\n  Panel ${u.order + 1}: ${u.reason}`;
+                }
+            }
+            alert(msg);
+        } catch (err) {
+            alert('Error exporting PDF: ' + err);
+        }
+    },
+
+    // printBoard prints the board through the WebView's print dialog
+    async printBoard() {
+        if (!this.currentProject) {
+            alert('No project to export');
+            return;
+        }
+
         try {
             const panelsStr = await getPanels();
             const panels = JSON.parse(panelsStr);
@@ -273,7 +399,8 @@ const app = {
             let body = `<h1>${escapeHtml(title)}</h1>`;
             for (const p of panels) {
                 body += `<div class="panel-page"><div class="panel"><div class="panel-thumbnail">`;
-                if (p.image_data) body += `<img src="${p.image_data}" alt="panel-${p.order}">`;
+                const image = await Images.load('panels', p.image_data);
+                if (image) body += `<img src="${image}" alt="panel-${p.order}">`;
                 else body += `<div style="width:320px;height:180px;background:#eee;display:flex;align-items:center;justify-content:center;color:#999">No image</div>`;
                 body += `</div><div class="meta">`;
                 body += `<strong>Panel ${p.order + 1}</strong><br>`;
@@ -291,7 +418,7 @@ const app = {
 
             const html = `<!doctype html><html><head><meta charset="utf-8"><title>${escapeHtml(title)}</title>${css}</head><body>${body}</body></html>`;
 
-            // Save HTML to disk (assets/prints)
+            // Save HTML to the project's export folder
             try {
                 await saveExportHTML(filename, html);
             } catch (e) {
@@ -306,24 +433,168 @@ const app = {
         }
     },
 
-    async exportMp4() {
+    async exportMp4(withBurnIns = false) {
         if (!this.currentProject) {
             alert('No project to export');
             return;
         }
 
+        let overlays = {};
+        if (withBurnIns) {
+            overlays = this.promptBurnIns();
+            if (!overlays) return;
+        }
+
         try {
-            // Optionally show a quick confirmation or options in the future
             const filename = '';
-            // exportMP4 Go binding expects (filename string, width, height, fps, bitrate)
-            // Pass 0 for numeric options to use server-side defaults
-            const result = await exportMP4(filename, 0, 0, 0, 0);
-            alert('Export started. Output: ' + result);
+            // startExportMP4 Go binding expects (filename string, width, height, fps, bitrate, overlays)
+            // Pass 0 for numeric options to use server-side defaults. The export
+            // runs in the background and reports back through ExportJobs.
+            const job = JSON.parse(await startExportMP4(filename, 0, 0, 0, 0, overlays));
+            ExportJobs.update(job);
         } catch (err) {
             alert('Error exporting MP4: ' + err);
         }
     },
 
+    // exportReportMessage summarizes an export report for an alert
+    exportReportMessage(title, report) {
+        let msg = `${title}. Output: ${report.output_path}`;
+        if (report.unresolved && report.unresolved.length > 0) {
+            msg += '\n\nThese panels were exported as blank frames:';
+            for (const u of report.unresolved) {
+                msg += `\n  Panel ${u.order + 1}: ${u.reason}`;
+            }
+        }
+        if (report.unresolved_audio && report.unresolved_audio.length > 0) {
+            msg += '\n\nThese audio clips were left out:';
+            for (const u of report.unresolved_audio) {
+                msg += `\n  ${u.reason}`;
+            }
+        }
+        return msg;
+    },
+
+    // promptBurnIns asks which overlays to burn into the MP4, remembering the
+    // last answer. Returns null when cancelled.
+    promptBurnIns() {
+        const names = ['timecode', 'panel', 'shot_info', 'subtitles', 'watermark', 'safe_area'];
+        let last = 'timecode, panel, shot_info, subtitles';
+        try {
+            last = localStorage.getItem('burnIns') || last;
+        } catch (e) {
+            // localStorage not available - choice won't persist
+        }
+        const answer = prompt(`Burn-ins (any of: ${names.join(', ')}):`, last);
+        if (answer === null) return null;
+
+        try {
+            localStorage.setItem('burnIns', answer);
+        } catch (e) {}
+        const chosen = answer.split(',').map(s => s.trim().toLowerCase());
+        const overlays = {};
+        for (const name of names) {
+            overlays[name] = chosen.includes(name);
+        }
+        return overlays;
+    },
+
+    async exportEdl() {
+        await this.exportEditList('EDL', exportEDL);
+    },
+
+    async exportFcpxml() {
+        await this.exportEditList('FCPXML', exportFCPXML);
+    },
+
+    // Edit lists are written with a folder of panel stills next to them
+    async exportEditList(label, exportFn) {
+        if (!this.currentProject) {
+            alert('No project to export');
+            return;
+        }
+
+        try {
+            const report = JSON.parse(await exportFn(''));
+            let msg = `${label} export finished. Output: ${report.output_path}`;
+            if (report.unresolved && report.unresolved.length > 0) {
+                msg += '\n\nThese panels were exported as blank stills:';
+                for (const u of report.unresolved) {
+                    msg += `\n  Panel ${u.order + 1}: ${u.reason}`;
+                }
+            }
+            alert(msg);
+        } catch (err) {
+            alert(`Error exporting ${label}: ` + err);
+        }
+    },
+
+    async importScript(input) {
+        const file = input.files && input.files[0];
+        input.value = '';
+        if (!file) return;
+
+        try {
+            const content = await file.text();
+            const resultStr = file.name.toLowerCase().endsWith('.fdx')
+                ? await importFDX(file.name, content)
+                : await importFountain(file.name, content);
+            const result = JSON.parse(resultStr);
+            alert(`Imported ${result.panels} panels in ${result.scenes} scenes with ${result.characters} new characters.`);
+        } catch (err) {
+            alert('Error importing script: ' + err);
+        }
+    },
+
+    async undo() {
+        try {
+            const resultStr = await undo();
+            await this.afterHistoryChange(JSON.parse(resultStr));
+        } catch (err) {
+            console.error('Error undoing:', err);
+        }
+    },
+
+    async redo() {
+        try {
+            const resultStr = await redo();
+            await this.afterHistoryChange(JSON.parse(resultStr));
+        } catch (err) {
+            console.error('Error redoing:', err);
+        }
+    },
+
+    // The board follows an undo/redo step through its events; only the
+    // editor of the selected panel is reloaded here
+    async afterHistoryChange(result) {
+        this.updateHistoryButtons(result.history);
+        if (this.selectedPanelId) {
+            await this.loadPanelEditor(this.selectedPanelId);
+        }
+    },
+
+    async refreshHistory() {
+        try {
+            const resultStr = await getHistory();
+            this.updateHistoryButtons(JSON.parse(resultStr).history);
+        } catch (err) {
+            console.error('Error loading history:', err);
+        }
+    },
+
+    updateHistoryButtons(history) {
+        const undoBtn = document.getElementById('undoButton');
+        const redoBtn = document.getElementById('redoButton');
+        if (undoBtn) {
+            undoBtn.disabled = !history.can_undo;
+            undoBtn.title = history.can_undo ? `Undo ${history.undo[0]} (Ctrl+Z)` : 'Undo (Ctrl+Z)';
+        }
+        if (redoBtn) {
+            redoBtn.disabled = !history.can_redo;
+            redoBtn.title = history.can_redo ? `Redo ${history.redo[0]} (Ctrl+Shift+Z)` : 'Redo (Ctrl+Shift+Z)';
+        }
+    },
+
     async showTimeline() {
         if (typeof Timeline !== 'undefined' && Timeline) {
             await Timeline.show();
@@ -353,6 +624,22 @@ window.addEventListener('DOMContentLoaded', () => {
         // close when clicking outside
         document.addEventListener('click', () => { menu.style.display = 'none'; });
     }
+
+    // Undo/redo shortcuts; text fields keep their own native undo
+    document.addEventListener('keydown', (e) => {
+        if (!(e.ctrlKey || e.metaKey)) return;
+        const tag = (e.target && e.target.tagName) || '';
+        if (tag === 'INPUT' || tag === 'TEXTAREA' || tag === 'SELECT') return;
+
+        const key = e.key.toLowerCase();
+        if (key === 'z' && !e.shiftKey) {
+            e.preventDefault();
+            app.undo();
+        } else if ((key === 'z' && e.shiftKey) || key === 'y') {
+            e.preventDefault();
+            app.redo();
+        }
+    });
 });
 
 // Helpers
@@ -28,7 +28,7 @@ const Characters = {
         container.innerHTML = this.list.map(char => `
             <div class="character-card">
                 <div class="character-img">
-                    ${char.image_path ? `<img src="${char.image_path}" alt="${char.name}">` : '<div class="no-img">No Image</div>'}
+                    ${char.image_path ? Images.tag('characters', char.image_path, `alt="${char.name}"`) : '<div class="no-img">No Image</div>'}
                 </div>
                 <div class="character-info">
                     <strong>${char.name}</strong>
@@ -42,8 +42,6 @@ const Characters = {
     async add(name, description, imageData) {
         try {
             await addCharacter(name, description, imageData);
-            await this.refresh();
-            this.renderList();
             return true;
         } catch (err) {
             alert('Error adding character: ' + err);
@@ -55,8 +53,6 @@ const Characters = {
         if (!confirm('Delete this character?')) return;
         try {
             await deleteCharacter(id);
-            await this.refresh();
-            this.renderList();
         } catch (err) {
             alert('Error deleting character: ' + err);
         }
@@ -0,0 +1,187 @@
+// BoardEvents applies the state events Go pushes through
+// window.onBackendEvent, so the grid, timeline and side lists follow every
+// edit - from this page, undo/redo or the HTTP API - without fetching the
+// whole project again. Updates carry only the changed fields, and inline
+// images are fetched separately. Redraws are batched, so a burst of events
+// such as a script import redraws once.
+const BoardEvents = {
+    pending: {},
+    timer: null,
+
+    onEvent(type, e) {
+        switch (type) {
+            case 'panel:added':
+                app.panels = app.panels.filter(p => p.id !== e.panel_id);
+                app.panels.splice(e.index, 0, e.panel);
+                this.renumber();
+                this.schedule({ grid: true });
+                if (e.image_inline) this.fetchPanelImage(e.panel_id);
+                break;
+            case 'panel:updated': {
+                const i = app.panels.findIndex(p => p.id === e.panel_id);
+                if (i === -1) {
+                    // missed the panel being added; start over
+                    app.refreshPanels();
+                    break;
+                }
+                Object.assign(app.panels[i], e.changes);
+                this.redrawPanel(i);
+                if (e.image_inline) this.fetchPanelImage(e.panel_id);
+                break;
+            }
+            case 'panel:deleted':
+                app.panels = app.panels.filter(p => p.id !== e.panel_id);
+                this.renumber();
+                if (app.selectedPanelId === e.panel_id) {
+                    app.selectedPanelId = null;
+                    app.clearEditor();
+                }
+                this.schedule({ grid: true });
+                break;
+            case 'panels:reordered': {
+                const byId = new Map(app.panels.map(p => [p.id, p]));
+                app.panels = e.order.map(id => byId.get(id)).filter(Boolean);
+                this.renumber();
+                this.schedule({ grid: true });
+                break;
+            }
+            case 'character:added':
+            case 'character:updated':
+            case 'character:deleted':
+                this.applyCharacter(type, e);
+                break;
+            case 'scenes:changed':
+                this.schedule({ timeline: true });
+                break;
+            case 'audio:changed':
+                this.schedule({ audio: true });
+                break;
+            case 'project:loaded':
+                Images.clear();
+                scheduleProjectRefresh();
+                break;
+            case 'project:renamed':
+                if (app.currentProject) app.currentProject.name = e.name;
+                document.getElementById('projectName').textContent = e.name;
+                break;
+            case 'project:format':
+                if (app.currentProject) {
+                    app.currentProject.aspect_ratio = e.aspect_ratio;
+                    app.currentProject.matte_color = e.matte_color;
+                }
+                break;
+            case 'project:dirty': {
+                const name = document.getElementById('projectName');
+                name.classList.toggle('dirty', e.dirty);
+                if (!e.dirty) name.title = ''; // saved; nothing left to recover
+                break;
+            }
+            case 'project:changed':
+                this.schedule({ history: true });
+                break;
+            case 'autosave:failed':
+                console.error('Autosave failed:', e.error);
+                document.getElementById('projectName').title = 'Autosave failed: ' + e.error;
+                break;
+        }
+    },
+
+    // renumber keeps panel numbers in step with positions after panels are
+    // added, removed or moved, the way the backend renumbers them
+    renumber() {
+        app.panels.forEach((p, i) => { p.order = i; });
+    },
+
+    // redrawPanel redraws the card of the panel at index i, or the whole grid
+    redrawPanel(i) {
+        if (this.pending.grid || !updatePanelCard(app.panels[i], i, app.panels.length)) {
+            this.schedule({ grid: true });
+        } else {
+            this.schedule({ timeline: true });
+        }
+    },
+
+    // fetchPanelImage fills in an inline image the event left out
+    async fetchPanelImage(id) {
+        const image = await getPanelImage(id).catch(() => '');
+        const i = app.panels.findIndex(p => p.id === id);
+        if (i === -1) return;
+        app.panels[i].image_data = image;
+        this.redrawPanel(i);
+    },
+
+    applyCharacter(type, e) {
+        if (typeof Characters === 'undefined') return;
+        const current = Characters.list.find(c => c.id === e.character_id);
+        if (type === 'character:updated' && !current) {
+            // missed the character being added; start over
+            Characters.refresh().then(() => Characters.renderList());
+            return;
+        }
+        const list = Characters.list.filter(c => c.id !== e.character_id);
+        if (type === 'character:added') {
+            list.splice(e.index, 0, e.character);
+        } else if (type === 'character:updated') {
+            list.splice(e.index, 0, Object.assign(current, e.changes));
+        }
+        Characters.list = list;
+        Characters.renderList();
+        if (e.image_inline) this.fetchCharacterImage(e.character_id);
+    },
+
+    // fetchCharacterImage fills in an inline image the event left out
+    async fetchCharacterImage(id) {
+        const image = await getCharacterImage(id).catch(() => '');
+        const char = Characters.list.find(c => c.id === id);
+        if (!char) return;
+        char.image_path = image;
+        Characters.renderList();
+    },
+
+    schedule(parts) {
+        Object.assign(this.pending, parts);
+        if (this.timer) return;
+        this.timer = setTimeout(() => this.flush(), 16);
+    },
+
+    async flush() {
+        const parts = this.pending;
+        this.pending = {};
+        this.timer = null;
+
+        if (parts.grid) renderPanelGrid(app.panels);
+        if (parts.grid || parts.timeline) await app.refreshTimeline();
+        if (parts.audio && typeof Soundtrack !== 'undefined') {
+            await Soundtrack.refresh();
+            Soundtrack.renderList();
+        }
+        if (parts.history) app.refreshHistory();
+    }
+};
+
+// onBackendEvent receives events pushed from Go
+window.onBackendEvent = function (event, data) {
+    if (event.startsWith('export:')) {
+        ExportJobs.onEvent(event, data);
+    } else {
+        BoardEvents.onEvent(event, data);
+    }
+};
+
+// scheduleProjectRefresh reloads the whole board after a project is created
+// or opened. Bursts cause a single reload.
+let projectRefreshTimer = null;
+function scheduleProjectRefresh() {
+    clearTimeout(projectRefreshTimer);
+    projectRefreshTimer = setTimeout(async () => {
+        await app.refreshPanels();
+        if (typeof Characters !== 'undefined') {
+            await Characters.refresh();
+            Characters.renderList();
+        }
+        if (typeof Soundtrack !== 'undefined') {
+            await Soundtrack.refresh();
+            Soundtrack.renderList();
+        }
+    }, 150);
+}
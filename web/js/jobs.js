@@ -0,0 +1,93 @@
+// ExportJobs lists background exports with their progress. The backend pushes
+// "export:progress" and "export:finished" events through window.onBackendEvent
+// (see events.js).
+const ExportJobs = {
+    jobs: [],
+
+    async init() {
+        await this.refresh();
+        this.renderList();
+    },
+
+    async refresh() {
+        try {
+            this.jobs = JSON.parse(await getExportJobs());
+        } catch (err) {
+            console.error('Error fetching export jobs:', err);
+            this.jobs = [];
+        }
+    },
+
+    // update replaces a job in the list with the latest state from an event
+    update(job) {
+        const i = this.jobs.findIndex(j => j.id === job.id);
+        if (i >= 0) {
+            this.jobs[i] = job;
+        } else {
+            this.jobs.push(job);
+        }
+        this.renderList();
+    },
+
+    onEvent(event, job) {
+        if (event === 'export:progress') {
+            this.update(job);
+        } else if (event === 'export:finished') {
+            this.update(job);
+            if (job.status === 'done') {
+                alert(app.exportReportMessage('Export finished', job.report));
+            } else if (job.status === 'failed') {
+                alert('Error exporting MP4: ' + job.error);
+            }
+        }
+    },
+
+    renderList() {
+        const container = document.getElementById('exportJobList');
+        if (!container) return;
+
+        if (this.jobs.length === 0) {
+            container.innerHTML = '<div class="empty-state">No exports yet.</div>';
+            return;
+        }
+
+        // Newest first
+        container.innerHTML = this.jobs.slice().reverse().map(job => {
+            const percent = job.total > 0 ? Math.floor(job.done * 100 / job.total) : 0;
+            const name = job.output_path.split(/[\\/]/).pop();
+            let status = job.status;
+            if (job.status === 'running') status = `${percent}%`;
+            if (job.status === 'failed') status = 'failed';
+
+            return `
+                <div class="export-job export-job-${job.status}">
+                    <div class="export-job-header">
+                        <strong title="${escapeHtml(job.output_path)}">${escapeHtml(name)}</strong>
+                        <span class="export-job-status">${escapeHtml(status)}</span>
+                        ${job.status === 'running' ? `<button class="delete-btn" title="Cancel" onclick="ExportJobs.cancel('${job.id}')">&times;</button>` : ''}
+                    </div>
+                    ${job.status === 'running' ? `<div class="export-job-bar"><div style="width: ${percent}%"></div></div>` : ''}
+                    ${job.error ? `<div class="export-job-error">${escapeHtml(job.error)}</div>` : ''}
+                </div>
+            `;
+        }).join('');
+    },
+
+    async cancel(id) {
+        try {
+            await cancelExport(id);
+        } catch (err) {
+            console.error('Error cancelling export:', err);
+        }
+    },
+
+    async clearFinished() {
+        try {
+            await clearFinishedExports();
+            await this.refresh();
+            this.renderList();
+        } catch (err) {
+            console.error('Error clearing exports:', err);
+        }
+    },
+};
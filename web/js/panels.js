@@ -24,7 +24,9 @@ const Drawing = {
                 this.ctx.drawImage(img, 0, 0);
                 this.saveState();
             };
-            img.src = panel.image_data;
+            Images.load('panels', panel.image_data).then(uri => {
+                if (uri) img.src = uri;
+            });
         } else {
             // Fill with white background
             this.ctx.fillStyle = '#ffffff';
@@ -83,7 +85,6 @@ const Drawing = {
         if (!this.currentPanel) return;
         const imageData = this.canvas.toDataURL('image/png');
         await app.updatePanelField(this.currentPanel.id, 'image_data', imageData);
-        await app.refreshPanels();
     },
 
     undo() {
@@ -124,8 +125,23 @@ function renderPanelGrid(panels) {
         return;
     }
 
-    grid.innerHTML = panels.map((panel, index) => `
+    grid.innerHTML = panels.map((panel, index) => panelCardHtml(panel, index, panels.length)).join('');
+}
+
+// updatePanelCard redraws the card of one panel in place. Returns false if
+// the grid does not have a card at index to replace.
+function updatePanelCard(panel, index, count) {
+    const grid = document.getElementById('panelGrid');
+    const card = grid && grid.querySelectorAll('.panel-card')[index];
+    if (!card || card.dataset.id !== panel.id) return false;
+    card.outerHTML = panelCardHtml(panel, index, count);
+    return true;
+}
+
+function panelCardHtml(panel, index, count) {
+    return `
         <div class="panel-card ${panel.id === app.selectedPanelId ? 'selected' : ''}" 
+             data-id="${panel.id}"
              onclick="app.selectPanel('${panel.id}')"
              draggable="true"
              ondragstart="handleDragStart(event, '${panel.id}')"
@@ -134,7 +150,7 @@ function renderPanelGrid(panels) {
              ondrop="handleDrop(event, '${panel.id}')">
             <div class="panel-number">Panel ${panel.order + 1}</div>
             <div class="panel-thumbnail">
-                ${panel.image_data ? `<img src="${panel.image_data}" alt="Panel ${panel.order + 1}">` : 'No image'}
+                ${panel.image_data ? Images.tag('panels', panel.image_data, `alt="Panel ${panel.order + 1}"`) : 'No image'}
             </div>
             <div class="panel-meta">
                 ${panel.shot_type} / ${panel.camera_angle}<br>
@@ -142,12 +158,14 @@ function renderPanelGrid(panels) {
             </div>
             <div class="panel-actions">
                 <button onclick="event.stopPropagation(); app.movePanel('${panel.id}', -1)" ${index === 0 ? 'disabled' : ''} title="Move Backward">&lt;</button>
-                <button onclick="event.stopPropagation(); app.movePanel('${panel.id}', 1)" ${index === panels.length - 1 ? 'disabled' : ''} title="Move Forward">&gt;</button>
-                <button onclick="event.stopPropagation(); app.duplicatePanel('${panel.id}')">Dup</button>
+                <button onclick="event.stopPropagation(); app.movePanel('${panel.id}', 1)" ${index === count - 1 ? 'disabled' : ''} title="Move Forward">&gt;</button>
+                <button onclick="event.stopPropagation(); app.insertPanelAfter('${panel.id}')" title="Insert Panel After">+</button>
+                <button onclick="event.stopPropagation(); app.duplicatePanel('${panel.id}')" title="Duplicate Next to This Panel">Dup</button>
+                <button onclick="event.stopPropagation(); app.splitPanel('${panel.id}')" title="Split into Several Panels">Split</button>
                 <button onclick="event.stopPropagation(); app.deletePanel('${panel.id}')">Del</button>
             </div>
         </div>
-    `).join('');
+    `;
 }
 
 let draggedPanelId = null;
@@ -212,7 +230,7 @@ function renderPanelEditor(panel) {
         charSection = `
             <div class="form-group">
                 <label>Characters</label>
-                <div class="character-tags">
+                <div class="character-tags" id="characterTags">
         `;
 
         Characters.list.forEach(char => {
@@ -307,10 +325,186 @@ function renderPanelEditor(panel) {
                        onchange="app.updatePanelField('${panel.id}', 'duration', parseFloat(this.value))">
             </div>
         </div>
+
+        ${motionSection(panel)}
+
+        ${typeof Soundtrack !== 'undefined' ? Soundtrack.panelSection(panel) : ''}
     `;
 
     // Initialize drawing after rendering
     Drawing.init(panel);
+    fillMotionDefaults(panel);
+}
+
+const TRANSITIONS = [
+    ['cut', 'Cut'],
+    ['dissolve', 'Cross-dissolve'],
+    ['fade_from_black', 'Fade from black'],
+    ['fade_to_black', 'Fade to black'],
+    ['wipe', 'Wipe'],
+];
+
+const FITS = [
+    ['fit', 'Fit (matte bars)'],
+    ['fill', 'Fill (crop to frame)'],
+    ['crop', 'Crop (actual size)'],
+];
+
+const EASINGS = [
+    ['linear', 'Linear'],
+    ['ease_in', 'Ease in'],
+    ['ease_out', 'Ease out'],
+    ['ease_in_out', 'Ease in/out'],
+];
+
+// motionSection renders the animatic controls: the transition into the panel
+// and the start/end framing of its camera move
+function motionSection(panel) {
+    const tr = panel.transition || { type: 'cut', duration: 0 };
+    const m = panel.motion;
+    const options = (list, current) => list.map(([value, label]) =>
+        `<option value="${value}" ${value === current ? 'selected' : ''}>${label}</option>`).join('');
+    const framing = (id, f) => ['x', 'y', 'scale'].map(key => `
+                <label>${key === 'scale' ? 'Scale' : key.toUpperCase()}
+                    <input type="number" id="${id}_${key}" step="0.05" min="${key === 'scale' ? 1 : 0}" max="${key === 'scale' ? 3 : 1}"
+                           value="${f ? f[key] : ''}" onchange="updatePanelMotion('${panel.id}')">
+                </label>`).join('');
+
+    return `
+        <div class="form-row">
+            <div class="form-group">
+                <label>Transition In</label>
+                <select id="transitionType" onchange="updatePanelTransition('${panel.id}')">
+                    ${options(TRANSITIONS, tr.type)}
+                </select>
+            </div>
+            <div class="form-group">
+                <label>Transition (seconds)</label>
+                <input type="number" id="transitionDuration" step="0.1" min="0" value="${tr.duration || ''}"
+                       placeholder="0.5" onchange="updatePanelTransition('${panel.id}')">
+            </div>
+        </div>
+
+        <div class="form-row">
+            <div class="form-group">
+                <label>Image Fit</label>
+                <select id="imageFit" onchange="app.updatePanelField('${panel.id}', 'fit', this.value)">
+                    ${options(FITS, panel.fit || 'fit')}
+                </select>
+            </div>
+            <div class="form-group">
+                <label>Focal Point (x, y)</label>
+                <div class="motion-row">
+                    <input type="number" id="focusX" step="0.05" min="0" max="1" value="${panel.focus ? panel.focus.x : 0.5}"
+                           onchange="updatePanelFocus('${panel.id}')">
+                    <input type="number" id="focusY" step="0.05" min="0" max="1" value="${panel.focus ? panel.focus.y : 0.5}"
+                           onchange="updatePanelFocus('${panel.id}')">
+                </div>
+            </div>
+        </div>
+
+        <div class="form-group motion-group">
+            <label>Camera Motion ${m ? '' : '<span class="motion-default">(default for move)</span>'}</label>
+            <div class="motion-row"><span>Start</span>${framing('motionStart', m && m.start)}</div>
+            <div class="motion-row"><span>End</span>${framing('motionEnd', m && m.end)}</div>
+            <div class="motion-row">
+                <select id="motionEasing" onchange="updatePanelMotion('${panel.id}')">
+                    ${options(EASINGS, m ? m.easing : 'ease_in_out')}
+                </select>
+                <button onclick="resetPanelMotion('${panel.id}')">Reset</button>
+            </div>
+        </div>
+    `;
+}
+
+// fillMotionDefaults shows the move's default framing when the panel has none
+async function fillMotionDefaults(panel) {
+    if (panel.motion) return;
+    try {
+        const m = JSON.parse(await getDefaultMotion(panel.camera_move || ''));
+        if (!m) return;
+        for (const [id, f] of [['motionStart', m.start], ['motionEnd', m.end]]) {
+            for (const key of ['x', 'y', 'scale']) {
+                const input = document.getElementById(`${id}_${key}`);
+                if (input) input.value = f[key];
+            }
+        }
+    } catch (err) {
+        console.error('Error loading default motion:', err);
+    }
+}
+
+// FIELD_CONTROLS maps panel fields to the editor control that shows their
+// errors
+const FIELD_CONTROLS = {
+    action_notes: 'actionNotes',
+    dialogue: 'dialogue',
+    shot_type: 'shotType',
+    camera_angle: 'cameraAngle',
+    camera_move: 'cameraMove',
+    duration: 'duration',
+    character_ids: 'characterTags',
+    transition: 'transitionType',
+    fit: 'imageFit',
+    focus: 'focusX',
+    motion: 'motionEasing',
+};
+
+// showFieldErrors shows the errors returned for a patch of fields next to
+// their controls, clearing earlier errors of those fields
+function showFieldErrors(fields, errors) {
+    for (const field of fields) {
+        const control = document.getElementById(FIELD_CONTROLS[field]);
+        const group = control && control.closest('.form-group');
+        if (!group) continue;
+        group.querySelectorAll('.field-error').forEach(el => el.remove());
+        control.classList.remove('invalid');
+
+        for (const e of errors.filter(e => e.field === field)) {
+            control.classList.add('invalid');
+            const msg = document.createElement('div');
+            msg.className = 'field-error';
+            msg.textContent = e.message;
+            group.appendChild(msg);
+        }
+    }
+    errors.filter(e => !FIELD_CONTROLS[e.field]).forEach(e => console.error('Invalid panel update:', e.message));
+}
+
+async function updatePanelFocus(panelId) {
+    const clamp = v => Math.min(1, Math.max(0, isNaN(v) ? 0.5 : v));
+    await app.updatePanelField(panelId, 'focus', {
+        x: clamp(parseFloat(document.getElementById('focusX').value)),
+        y: clamp(parseFloat(document.getElementById('focusY').value)),
+    });
+}
+
+async function updatePanelTransition(panelId) {
+    const type = document.getElementById('transitionType').value;
+    const duration = parseFloat(document.getElementById('transitionDuration').value) || 0;
+    await app.updatePanelField(panelId, 'transition', type === 'cut' ? null : { type, duration });
+}
+
+async function updatePanelMotion(panelId) {
+    const num = (id, fallback) => {
+        const v = parseFloat(document.getElementById(id).value);
+        return isNaN(v) ? fallback : v;
+    };
+    const read = id => ({
+        x: num(`${id}_x`, 0.5),
+        y: num(`${id}_y`, 0.5),
+        scale: num(`${id}_scale`, 1),
+    });
+    await app.updatePanelField(panelId, 'motion', {
+        start: read('motionStart'),
+        end: read('motionEnd'),
+        easing: document.getElementById('motionEasing').value,
+    });
+}
+
+async function resetPanelMotion(panelId) {
+    await app.updatePanelField(panelId, 'motion', null);
+    await app.selectPanel(panelId);
 }
 
 async function togglePanelCharacter(panelId, charId, isChecked) {
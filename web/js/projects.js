@@ -0,0 +1,214 @@
+// Opening, saving and creating project files, and the recent projects menu
+const Projects = {
+    recent: [],
+    reopenLast: false,
+
+    async init() {
+        await this.refreshRecent();
+        this.renderRecentMenu();
+
+        const btn = document.getElementById('recentButton');
+        const menu = document.getElementById('recentMenuItems');
+        if (btn && menu) {
+            btn.addEventListener('click', (e) => {
+                e.stopPropagation();
+                menu.style.display = menu.style.display === 'none' ? 'flex' : 'none';
+            });
+            document.addEventListener('click', () => { menu.style.display = 'none'; });
+        }
+    },
+
+    // reopen opens the most recent project when the setting is on.
+    // Returns true if a project was opened.
+    async reopen() {
+        try {
+            const result = JSON.parse(await reopenLastProject());
+            if (!result) return false;
+            await this.opened(result);
+            return true;
+        } catch (err) {
+            console.error('Error reopening last project:', err);
+            return false;
+        }
+    },
+
+    // recover offers to restore autosaved changes that were never saved,
+    // newest first. Returns true if a project was restored.
+    async recover() {
+        let recoveries = [];
+        try {
+            recoveries = JSON.parse(await getRecoveries());
+        } catch (err) {
+            console.error('Error checking for autosaves:', err);
+            return false;
+        }
+
+        for (const rec of recoveries) {
+            const when = new Date(rec.saved_at).toLocaleString();
+            const where = rec.project_path ? ` (${rec.project_path})` : '';
+            const msg = `"${rec.name}"${where} has unsaved changes from ${when}.\n\n` +
+                'OK to recover them, Cancel to discard them.';
+            try {
+                if (confirm(msg)) {
+                    await this.opened(JSON.parse(await recoverProject(rec.recovery_path)));
+                    return true;
+                }
+                await discardRecovery(rec.recovery_path);
+            } catch (err) {
+                alert('Error recovering project: ' + err);
+            }
+        }
+        return false;
+    },
+
+    // chooseFile asks for a project file with the native dialog, or with a
+    // path prompt when there is none. Returns '' if cancelled.
+    async chooseFile(mode, promptText, suggested) {
+        try {
+            const result = JSON.parse(await chooseProjectFile(mode));
+            if (result.available) return result.path;
+        } catch (err) {
+            console.error('File dialog failed:', err);
+        }
+        const path = prompt(promptText, suggested || '');
+        return path ? path.trim() : '';
+    },
+
+    async open(path) {
+        if (!path) {
+            path = await this.chooseFile('open', 'Path of the project file to open:', 'projects/');
+            if (!path) return;
+        }
+        try {
+            await this.opened(JSON.parse(await loadProject(path)));
+        } catch (err) {
+            alert('Error opening project: ' + err);
+            await this.refreshRecent();
+            this.renderRecentMenu();
+        }
+    },
+
+    async saveAs() {
+        const name = (app.currentProject && app.currentProject.name) || 'project';
+        const path = await this.chooseFile('save', 'Save the project as (.json, or .sbf for a single-file bundle):', `projects/${name}.json`);
+        if (!path) return;
+        try {
+            const result = JSON.parse(await saveProjectAs(path));
+            this.showProject(result);
+            await this.refreshRecent();
+            this.renderRecentMenu();
+        } catch (err) {
+            alert('Error saving project: ' + err);
+        }
+    },
+
+    async newFromTemplate() {
+        const template = await this.chooseFile('open', 'Path of the template project:', 'projects/');
+        if (!template) return;
+        const path = await this.chooseFile('save', 'Save the new project as:', 'projects/untitled.json');
+        if (!path) return;
+        try {
+            await this.opened(JSON.parse(await newProjectFromTemplate(template, path)));
+        } catch (err) {
+            alert('Error creating project from template: ' + err);
+        }
+    },
+
+    // relinkAssets searches a folder for images and audio files the project
+    // can no longer find and links the ones that turn up
+    async relinkAssets() {
+        let missing = [];
+        try {
+            missing = JSON.parse(await getMissingAssets());
+        } catch (err) {
+            alert('Error checking assets: ' + err);
+            return;
+        }
+        if (missing.length === 0) {
+            alert('All images and audio files of this project were found.');
+            return;
+        }
+
+        const dir = prompt(`${missing.length} file(s) are missing. Folder to search (empty for the project folder):`, '');
+        if (dir === null) return;
+        try {
+            const result = JSON.parse(await relinkAssets(dir.trim()));
+            let msg = `Relinked ${result.relinked} file(s).`;
+            if (result.missing.length > 0) {
+                msg += `\n\nStill missing:\n` + result.missing.map(m => m.ref).join('\n');
+            }
+            alert(msg);
+        } catch (err) {
+            alert('Error relinking assets: ' + err);
+        }
+    },
+
+    // opened redraws the page for a project that was just opened
+    async opened(result) {
+        this.showProject(result);
+        app.selectedPanelId = null;
+        await app.refreshPanels();
+        app.clearEditor();
+        if (typeof Characters !== 'undefined') {
+            await Characters.refresh();
+            Characters.renderList();
+        }
+        if (typeof Soundtrack !== 'undefined') {
+            await Soundtrack.refresh();
+            Soundtrack.renderList();
+        }
+        await this.refreshRecent();
+        this.renderRecentMenu();
+    },
+
+    showProject(result) {
+        app.currentProject = { name: result.name || 'Untitled', path: result.path };
+        const el = document.getElementById('projectName');
+        el.textContent = app.currentProject.name;
+        el.title = result.path || '';
+    },
+
+    async refreshRecent() {
+        try {
+            const result = JSON.parse(await getRecentProjects());
+            this.recent = result.projects || [];
+            this.reopenLast = !!result.reopen_last;
+        } catch (err) {
+            console.error('Error loading recent projects:', err);
+            this.recent = [];
+        }
+    },
+
+    async toggleReopenLast() {
+        try {
+            await setReopenLast(!this.reopenLast);
+            this.reopenLast = !this.reopenLast;
+            this.renderRecentMenu();
+        } catch (err) {
+            alert('Error saving setting: ' + err);
+        }
+    },
+
+    renderRecentMenu() {
+        const menu = document.getElementById('recentMenuItems');
+        if (!menu) return;
+
+        const items = this.recent.map(p => `
+            <button class="export-menu-item recent-project${p.exists ? '' : ' missing'}"
+                    title="${escapeHtml(p.path)}" data-path="${escapeHtml(p.path)}"
+                    ${p.exists ? '' : 'disabled'}>
+                ${escapeHtml(p.name)}${p.exists ? '' : ' (missing)'}
+            </button>`).join('');
+
+        menu.innerHTML = (items || '<span class="recent-empty">No recent projects</span>') + `
+            <label class="recent-option" onclick="event.stopPropagation()">
+                <input type="checkbox" ${this.reopenLast ? 'checked' : ''}
+                       onchange="Projects.toggleReopenLast()">
+                Reopen last project on launch
+            </label>`;
+
+        menu.querySelectorAll('.recent-project').forEach(btn => {
+            btn.addEventListener('click', () => this.open(btn.dataset.path));
+        });
+    }
+};
@@ -0,0 +1,166 @@
+// Soundtrack manages project music/temp score tracks and per-panel audio clips.
+// Files are stored next to the saved project; offsets are in seconds, gain in dB.
+const Soundtrack = {
+    tracks: [],
+
+    async init() {
+        await this.refresh();
+        this.renderList();
+    },
+
+    async refresh() {
+        try {
+            this.tracks = JSON.parse(await getAudioTracks());
+        } catch (err) {
+            console.error('Error fetching audio tracks:', err);
+            this.tracks = [];
+        }
+    },
+
+    renderList() {
+        const container = document.getElementById('audioTrackList');
+        if (!container) return;
+
+        if (this.tracks.length === 0) {
+            container.innerHTML = '<div class="empty-state">No music or temp score yet.</div>';
+            return;
+        }
+
+        container.innerHTML = this.tracks.map(track => `
+            <div class="audio-clip">
+                <div class="audio-clip-header">
+                    <strong title="${escapeHtml(track.name)}">${escapeHtml(track.name)}</strong>
+                    <span class="audio-clip-length">${formatSeconds(track.duration)}</span>
+                    <button class="delete-btn" onclick="Soundtrack.removeTrack('${track.id}')">&times;</button>
+                </div>
+                <div class="audio-clip-controls">
+                    <select onchange="Soundtrack.updateTrack('${track.id}', 'kind', this.value)">
+                        <option value="music" ${track.kind === 'music' ? 'selected' : ''}>Music</option>
+                        <option value="temp_score" ${track.kind === 'temp_score' ? 'selected' : ''}>Temp score</option>
+                    </select>
+                    <label>Start <input type="number" step="0.1" value="${track.offset}"
+                        onchange="Soundtrack.updateTrack('${track.id}', 'offset', parseFloat(this.value) || 0)"></label>
+                    <label>Gain <input type="number" step="0.5" value="${track.gain}"
+                        onchange="Soundtrack.updateTrack('${track.id}', 'gain', parseFloat(this.value) || 0)"> dB</label>
+                </div>
+            </div>
+        `).join('');
+    },
+
+    async addTrack(input) {
+        const file = input.files && input.files[0];
+        input.value = '';
+        if (!file) return;
+
+        try {
+            await addAudioTrack(file.name, 'music', await readFileAsDataURL(file));
+        } catch (err) {
+            alert('Error adding audio: ' + err);
+        }
+    },
+
+    async updateTrack(id, field, value) {
+        try {
+            await updateAudioTrack(id, field, value);
+        } catch (err) {
+            console.error('Error updating audio track:', err);
+        }
+    },
+
+    async removeTrack(id) {
+        try {
+            await removeAudioTrack(id);
+        } catch (err) {
+            alert('Error removing audio: ' + err);
+        }
+    },
+
+    // panelSection returns the audio part of the panel editor
+    panelSection(panel) {
+        const clips = panel.audio_clips || [];
+        const rows = clips.map(clip => `
+            <div class="audio-clip">
+                <div class="audio-clip-header">
+                    <strong title="${escapeHtml(clip.name)}">${escapeHtml(clip.name)}</strong>
+                    <span class="audio-clip-length">${formatSeconds(clip.duration)}</span>
+                    <button onclick="Soundtrack.snapPanel('${panel.id}', '${clip.id}')" title="Set the panel duration to end with this clip">Snap</button>
+                    <button class="delete-btn" onclick="Soundtrack.removePanelClip('${panel.id}', '${clip.id}')">&times;</button>
+                </div>
+                <div class="audio-clip-controls">
+                    <select onchange="Soundtrack.updatePanelClip('${panel.id}', '${clip.id}', 'kind', this.value)">
+                        <option value="dialogue" ${clip.kind === 'dialogue' ? 'selected' : ''}>Dialogue</option>
+                        <option value="sfx" ${clip.kind === 'sfx' ? 'selected' : ''}>SFX</option>
+                    </select>
+                    <label>Offset <input type="number" step="0.1" value="${clip.offset}"
+                        onchange="Soundtrack.updatePanelClip('${panel.id}', '${clip.id}', 'offset', parseFloat(this.value) || 0)"></label>
+                    <label>Gain <input type="number" step="0.5" value="${clip.gain}"
+                        onchange="Soundtrack.updatePanelClip('${panel.id}', '${clip.id}', 'gain', parseFloat(this.value) || 0)"> dB</label>
+                </div>
+            </div>
+        `).join('');
+
+        return `
+            <div class="form-group">
+                <label>Audio</label>
+                ${rows}
+                <button onclick="document.getElementById('panelAudioInput').click()">Attach Audio</button>
+                <input type="file" id="panelAudioInput" accept="audio/*" style="display:none"
+                       onchange="Soundtrack.attachToPanel('${panel.id}', this)">
+            </div>
+        `;
+    },
+
+    async attachToPanel(panelId, input) {
+        const file = input.files && input.files[0];
+        input.value = '';
+        if (!file) return;
+
+        try {
+            await addPanelAudio(panelId, file.name, 'dialogue', await readFileAsDataURL(file));
+            await app.loadPanelEditor(panelId);
+        } catch (err) {
+            alert('Error attaching audio: ' + err);
+        }
+    },
+
+    async updatePanelClip(panelId, clipId, field, value) {
+        try {
+            await updatePanelAudio(panelId, clipId, field, value);
+        } catch (err) {
+            console.error('Error updating audio clip:', err);
+        }
+    },
+
+    async removePanelClip(panelId, clipId) {
+        try {
+            await removePanelAudio(panelId, clipId);
+            await app.loadPanelEditor(panelId);
+        } catch (err) {
+            alert('Error removing audio: ' + err);
+        }
+    },
+
+    async snapPanel(panelId, clipId) {
+        try {
+            await snapPanelToAudio(panelId, clipId);
+            await app.loadPanelEditor(panelId);
+        } catch (err) {
+            alert('Error snapping panel: ' + err);
+        }
+    }
+};
+
+function readFileAsDataURL(file) {
+    return new Promise((resolve, reject) => {
+        const reader = new FileReader();
+        reader.onload = () => resolve(reader.result);
+        reader.onerror = () => reject(reader.error);
+        reader.readAsDataURL(file);
+    });
+}
+
+function formatSeconds(secs) {
+    if (!secs) return '';
+    const total = Math.round(secs);
+    return `${Math.floor(total / 60)}:${(total % 60).toString().padStart(2, '0')}`;
+}
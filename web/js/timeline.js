@@ -1,6 +1,7 @@
 // Timeline / Theatre View Component
 const Timeline = {
     panels: [],
+    scenes: [],
     segments: [],
     totalRuntime: 0,
     playbackState: {
@@ -24,17 +25,24 @@ const Timeline = {
         }, 100);
     },
 
-    // Load panels from backend
-    async loadPanels() {
+    // Load panels from backend, unless the page already has them
+    async loadPanels(panels) {
         try {
-            const panelsStr = await getPanels();
-            this.panels = JSON.parse(panelsStr);
+            this.panels = panels ? panels.slice() : JSON.parse(await getPanels());
             // Sort by order
             this.panels.sort((a, b) => a.order - b.order);
         } catch (err) {
             console.error('Error loading panels:', err);
             this.panels = [];
         }
+
+        try {
+            const scenesStr = await getScenes();
+            this.scenes = JSON.parse(scenesStr).scenes || [];
+        } catch (err) {
+            console.error('Error loading scenes:', err);
+            this.scenes = [];
+        }
     },
 
     // Compute timeline segments from panels
@@ -64,7 +72,12 @@ const Timeline = {
         for (const panel of this.panels) {
             if (panel.image_data) {
                 const img = new Image();
-                img.src = panel.image_data;
+                Images.load('panels', panel.image_data).then(uri => {
+                    if (!uri) return;
+                    img.src = uri;
+                    const current = this.getCurrentPanel();
+                    if (current && current.id === panel.id) this.renderTheatre();
+                });
                 this.preloadedImages.push(img);
             } else {
                 this.preloadedImages.push(null);
@@ -175,6 +188,19 @@ const Timeline = {
 
         timelineBar.appendChild(segmentsContainer);
 
+        // Scene bands along the top of the bar
+        for (const scene of this.scenes) {
+            if (!scene.scene || scene.runtime <= 0) continue;
+            const band = document.createElement('div');
+            band.className = 'timeline-scene';
+            band.style.left = `${scene.start * pxPerSecond}px`;
+            band.style.width = `${scene.runtime * pxPerSecond}px`;
+            const label = scene.scene.number ? `${scene.scene.number}. ${scene.heading}` : scene.heading;
+            band.textContent = label;
+            band.title = `${label} (${scene.runtime.toFixed(1)}s)`;
+            timelineBar.appendChild(band);
+        }
+
         // Create playhead
         const playhead = document.createElement('div');
         playhead.id = 'timelinePlayhead';
@@ -534,9 +560,10 @@ const Timeline = {
         container.style.display = 'none';
     },
 
-    // Refresh timeline (reload panels and recompute)
-    async refresh() {
-        await this.loadPanels();
+    // Refresh timeline (reload panels and recompute). panels is the page's
+    // current list, if it has one.
+    async refresh(panels) {
+        await this.loadPanels(panels);
         this.computeSegments();
         this.preloadImages();
         this.render();